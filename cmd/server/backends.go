@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go-boilerplate/config"
+	"go-boilerplate/internal/cache"
+	"go-boilerplate/internal/db"
+	"go-boilerplate/internal/events"
+	"go-boilerplate/internal/health"
+	"go-boilerplate/internal/idempotency"
+	"go-boilerplate/internal/kafka"
+	"go-boilerplate/internal/service"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// eventSource hands the events the service consumes to its handlers.
+type eventSource interface {
+	Handle(handler func(events.Event))
+}
+
+// backends bundles the storage, cache and event dependencies the service
+// runs on. Consumer is nil when running with in-memory backends, where the
+// publisher is the source of events.
+type backends struct {
+	store     service.MessageStore
+	outbox    service.OutboxStore
+	cache     service.MessageCache
+	publisher service.EventPublisher
+	consumer  *kafka.Consumer
+	events    eventSource
+	// broker checks that Kafka can be reached
+	broker health.Pinger
+	// idempotency keeps the responses replayed to retried requests
+	idempotency idempotency.Store
+	closers     []func()
+}
+
+// Close releases the backends in reverse order of creation.
+func (b *backends) Close() {
+	for i := len(b.closers) - 1; i >= 0; i-- {
+		b.closers[i]()
+	}
+}
+
+// newBackends creates the backends selected by cfg.Backend.Driver.
+func newBackends(cfg *config.Config, logger *zap.Logger) (*backends, error) {
+	if cfg.Backend.Driver == config.BackendMemory {
+		logger.Info("Using in-memory backends")
+		store := service.NewMemoryStore()
+		producer := kafka.NewMemoryProducer()
+		return &backends{
+			store:     store,
+			outbox:    store,
+			cache:     cache.NewMemoryCache(),
+			publisher: producer,
+			events:    producer,
+			broker:    producer,
+
+			idempotency: idempotency.NewMemoryStore(),
+		}, nil
+	}
+
+	b := &backends{}
+
+	// Initialize database connection pool
+	connStr := fmt.Sprintf(
+		"user=%s password=%s host=%s port=%d dbname=%s sslmode=disable",
+		cfg.Database.User,
+		cfg.Database.Password,
+		cfg.Database.Host,
+		cfg.Database.Port,
+		cfg.Database.DBName,
+	)
+	// The password stays out of the logs
+	logger.Info("Connecting to database",
+		zap.String("host", cfg.Database.Host),
+		zap.Int("port", cfg.Database.Port),
+		zap.String("dbname", cfg.Database.DBName),
+		zap.String("user", cfg.Database.User),
+	)
+	dbConfig, err := pgxpool.ParseConfig(connStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database config: %w", err)
+	}
+
+	// Set connection pool settings with safe defaults
+	if cfg.Database.MaxOpenConns <= 0 {
+		logger.Warn("invalid max open connections, using default", zap.Int32("max_open_conns", cfg.Database.MaxOpenConns))
+		dbConfig.MaxConns = 10 // safe default
+	} else {
+		dbConfig.MaxConns = cfg.Database.MaxOpenConns
+	}
+
+	if cfg.Database.MaxIdleConns <= 0 {
+		logger.Warn("invalid max idle connections, using default", zap.Int32("max_idle_conns", cfg.Database.MaxIdleConns))
+		dbConfig.MinConns = 2 // safe default
+	} else {
+		dbConfig.MinConns = cfg.Database.MaxIdleConns
+	}
+
+	// Index and query message content in the configured language
+	dbConfig.ConnConfig.RuntimeParams["app.search_language"] = cfg.Search.Language
+
+	// Scope every connection to the tenant of the request using it, for the
+	// row-level security policies
+	db.ConfigureTenantSession(dbConfig)
+
+	// Create connection pool
+	pool, err := pgxpool.NewWithConfig(context.Background(), dbConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	b.closers = append(b.closers, pool.Close)
+	store := service.NewPostgresStore(pool)
+	b.store = store
+	b.outbox = store
+
+	// Initialize Redis cache
+	redisCache, err := cache.NewRedisCache(&cfg.Redis)
+	if err != nil {
+		b.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	b.cache = redisCache
+
+	// Keep idempotent responses in Redis, falling back to PostgreSQL while
+	// Redis is unavailable
+	b.idempotency = idempotency.NewFallbackStore(
+		idempotency.NewRedisStore(redisCache.Client()),
+		idempotency.NewPostgresStore(pool),
+		logger,
+	)
+
+	// Initialize Kafka producer
+	producer, err := kafka.NewProducer(cfg.Kafka.Brokers, cfg.Kafka.Topic)
+	if err != nil {
+		b.Close()
+		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
+	}
+	b.closers = append(b.closers, func() { _ = producer.Close() })
+	b.publisher = producer
+	b.broker = producer
+
+	// Initialize Kafka consumer
+	consumer, err := kafka.NewConsumer(cfg.Kafka.Brokers, cfg.Kafka.Topic, logger)
+	if err != nil {
+		b.Close()
+		return nil, fmt.Errorf("failed to create Kafka consumer: %w", err)
+	}
+	b.closers = append(b.closers, func() { _ = consumer.Close() })
+	b.consumer = consumer
+	b.events = consumer
+
+	return b, nil
+}
@@ -25,6 +25,7 @@
 // - DB_URL: PostgreSQL connection string
 // - REDIS_URL: Redis connection string
 // - KAFKA_BROKERS: Comma-separated list of Kafka brokers
+// - BACKEND_DRIVER: "external" (default) or "memory" for in-process backends
 //
 // @title Message Service API
 // @version 1.0
@@ -35,25 +36,39 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
 	"go-boilerplate/config"
 	"go-boilerplate/internal/api/grpc"
 	"go-boilerplate/internal/api/http"
-	"go-boilerplate/internal/cache"
-	"go-boilerplate/internal/kafka"
+	"go-boilerplate/internal/blob"
+	"go-boilerplate/internal/expiry"
+	"go-boilerplate/internal/health"
+	"go-boilerplate/internal/idempotency"
+	"go-boilerplate/internal/metadata"
+	"go-boilerplate/internal/moderation"
 	"go-boilerplate/internal/middleware"
+	"go-boilerplate/internal/outbox"
+	"go-boilerplate/internal/pagination"
+	"go-boilerplate/internal/scheduler"
 	"go-boilerplate/internal/service"
+	"go-boilerplate/internal/stream"
+	"go-boilerplate/internal/trash"
 	pb "go-boilerplate/proto/message/v1"
 	"net"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	echomiddleware "github.com/labstack/echo/v4/middleware"
 	"go.uber.org/zap"
 	grpc_server "google.golang.org/grpc"
+	grpc_health "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 
 	_ "go-boilerplate/docs" // Import generated docs
@@ -71,71 +86,99 @@ func main() {
 		logger.Fatal("Failed to load configuration", zap.Error(err))
 	}
 
-	// Initialize database connection pool
-	connStr := fmt.Sprintf(
-		"user=%s password=%s host=%s port=%d dbname=%s sslmode=disable",
-		cfg.Database.User,
-		cfg.Database.Password,
-		cfg.Database.Host,
-		cfg.Database.Port,
-		cfg.Database.DBName,
-	)
-	logger.Info("Database connection string", zap.String("connStr", connStr))
-	dbConfig, err := pgxpool.ParseConfig(connStr)
+	// Initialize storage, cache and event backends
+	b, err := newBackends(cfg, logger)
 	if err != nil {
-		logger.Fatal("Failed to parse database config", zap.Error(err))
-	}
-
-	// Set connection pool settings with safe defaults
-	if cfg.Database.MaxOpenConns <= 0 {
-		logger.Warn("invalid max open connections, using default", zap.Int32("max_open_conns", cfg.Database.MaxOpenConns))
-		dbConfig.MaxConns = 10 // safe default
-	} else {
-		dbConfig.MaxConns = cfg.Database.MaxOpenConns
-	}
-
-	if cfg.Database.MaxIdleConns <= 0 {
-		logger.Warn("invalid max idle connections, using default", zap.Int32("max_idle_conns", cfg.Database.MaxIdleConns))
-		dbConfig.MinConns = 2 // safe default
-	} else {
-		dbConfig.MinConns = cfg.Database.MaxIdleConns
+		logger.Fatal("Failed to initialize backends", zap.Error(err))
 	}
+	defer b.Close()
 
-	// Create connection pool
-	db, err := pgxpool.NewWithConfig(context.Background(), dbConfig)
+	// Load the metadata schema, if the deployment has one
+	schema, err := newMetadataSchema(cfg.Metadata)
 	if err != nil {
-		logger.Fatal("Failed to connect to database", zap.Error(err))
+		logger.Fatal("Failed to load metadata schema", zap.Error(err))
 	}
-	defer db.Close()
 
-	// Initialize Redis cache
-	redisCache, err := cache.NewRedisCache(&cfg.Redis)
+	// Initialize attachment storage
+	blobs, err := newBlobStore(cfg.Attachment)
 	if err != nil {
-		logger.Fatal("Failed to connect to Redis", zap.Error(err))
+		logger.Fatal("Failed to initialize attachment storage", zap.Error(err))
 	}
 
-	// Initialize Kafka producer
-	producer, err := kafka.NewProducer(cfg.Kafka.Brokers, cfg.Kafka.Topic)
+	// Initialize content moderation
+	moderationChain, err := newModerationChain(cfg.Moderation, b.cache, moderation.NewMetrics(prometheus.DefaultRegisterer))
 	if err != nil {
-		logger.Fatal("Failed to create Kafka producer", zap.Error(err))
+		logger.Fatal("Failed to initialize content moderation", zap.Error(err))
 	}
-	defer producer.Close()
 
-	// Initialize Kafka consumer
-	consumer, err := kafka.NewConsumer(cfg.Kafka.Brokers, cfg.Kafka.Topic, logger)
+	// Initialize services
+	messageService := service.NewMessageService(b.store, b.cache, service.Options{
+		MetadataSchema:    schema,
+		DeletePolicy:      service.DeletePolicy(cfg.Thread.DeletePolicy),
+		Blobs:             blobs,
+		MaxAttachmentSize: cfg.Attachment.MaxSize,
+		AttachmentTypes:   cfg.Attachment.AllowedTypes,
+		Moderation:        moderationChain,
+	})
+
+	// Initialize list cursor signing
+	cursors, err := newCursorCodec(cfg.Paging, logger)
 	if err != nil {
-		logger.Fatal("Failed to create Kafka consumer", zap.Error(err))
+		logger.Fatal("Failed to initialize pagination cursors", zap.Error(err))
 	}
-	defer consumer.Close()
 
-	// Initialize services
-	messageService := service.NewMessageService(db, redisCache, producer)
+	// Initialize idempotent request handling
+	keeper := idempotency.NewKeeper(b.idempotency, cfg.Idempotency, logger)
+
+	// Authentication identifies the authors of messages
+	if cfg.Auth.JWTSecret == "" {
+		logger.Warn("JWT_SECRET is not set; authentication is disabled and every caller is anonymous")
+	}
 
 	// Initialize HTTP handlers
-	messageHandler := http.NewMessageHandler(messageService)
+	messageHandler := http.NewMessageHandler(messageService, cursors, keeper)
+
+	// Fan the consumed message events out to stream subscribers
+	broadcaster := stream.NewBroadcaster(cfg.Stream, stream.NewMetrics(prometheus.DefaultRegisterer))
+	b.events.Handle(broadcaster.Publish)
 
 	// Initialize gRPC server
-	grpcServer := grpc.NewMessageServer(messageService)
+	grpcServer := grpc.NewMessageServer(messageService, cursors, keeper, broadcaster, cfg.Stream.HeartbeatInterval)
+	// Calls are logged with the status they end with, panics included
+	server := grpc_server.NewServer(
+		grpc_server.ChainUnaryInterceptor(
+			grpc.LoggingUnaryInterceptor(logger),
+			grpc.RecoveryUnaryInterceptor(logger),
+			grpc.DeadlineUnaryInterceptor(cfg.GRPC.RequestTimeout),
+			grpc.AuthUnaryInterceptor(cfg.Auth.JWTSecret),
+			grpc.TenantUnaryInterceptor(cfg.Tenant.Header),
+			grpc.RBACUnaryInterceptor(grpc.MethodPermissions),
+			grpc.ValidationUnaryInterceptor(),
+		),
+		grpc_server.ChainStreamInterceptor(
+			grpc.LoggingStreamInterceptor(logger),
+			grpc.RecoveryStreamInterceptor(logger),
+			grpc.AuthStreamInterceptor(cfg.Auth.JWTSecret),
+			grpc.TenantStreamInterceptor(cfg.Tenant.Header),
+			grpc.RBACStreamInterceptor(grpc.MethodPermissions),
+			grpc.ValidationStreamInterceptor(),
+		),
+	)
+	pb.RegisterMessageServiceServer(server, grpcServer)
+	if cfg.GRPC.Reflection {
+		reflection.Register(server)
+	}
+
+	// The HTTP health endpoints and the gRPC health service check the same
+	// dependencies
+	checker := health.NewChecker(cfg.Health.CheckTimeout,
+		health.Check{Name: "database", Pinger: b.store},
+		health.Check{Name: "cache", Pinger: b.cache},
+		health.Check{Name: "kafka", Pinger: b.broker},
+	)
+	healthServer := grpc_health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	healthReporter := grpc.NewHealthReporter(healthServer, checker, cfg.Health.CheckInterval, logger)
 
 	// Start servers
 	errChan := make(chan error, 1)
@@ -149,6 +192,9 @@ func main() {
 		// Set custom validator
 		e.Validator = &middleware.CustomValidator{Validator: middleware.GetValidator()}
 
+		// Report errors as problem details
+		e.HTTPErrorHandler = middleware.ErrorHandler(logger)
+
 		// Middleware
 		e.Use(echomiddleware.Logger())
 		e.Use(echomiddleware.Recover())
@@ -157,22 +203,17 @@ func main() {
 		// Swagger docs
 		e.GET("/swagger/*", echoSwagger.WrapHandler)
 
+		// Prometheus metrics
+		e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
+
 		// Health check endpoints
-		healthHandler := http.NewHealthHandler(db, redisCache)
+		healthHandler := http.NewHealthHandler(checker)
 		e.GET("/health", healthHandler.Health)
 		e.GET("/health/live", healthHandler.LivenessProbe)
 		e.GET("/health/ready", healthHandler.ReadinessProbe)
 
 		// API routes
-		v1 := e.Group("/api/v1")
-		{
-			messages := v1.Group("/messages")
-			messages.POST("", messageHandler.CreateMessage)
-			messages.GET("", messageHandler.ListMessages)
-			messages.GET("/:id", messageHandler.GetMessage)
-			messages.PUT("/:id", messageHandler.UpdateMessage)
-			messages.DELETE("/:id", messageHandler.DeleteMessage)
-		}
+		http.RegisterRoutes(e, messageHandler, cfg.Auth.JWTSecret, cfg.Tenant.Header)
 
 		// Start server
 		if err := e.Start(":" + cfg.Server.Port); err != nil {
@@ -188,23 +229,39 @@ func main() {
 			return
 		}
 
-		server := grpc_server.NewServer()
-
-		pb.RegisterMessageServiceServer(server, grpcServer)
-		reflection.Register(server)
-
 		logger.Info("Starting gRPC server", zap.String("port", cfg.GRPC.Port))
 		if err := server.Serve(listener); err != nil {
 			errChan <- fmt.Errorf("failed to start gRPC server: %w", err)
 		}
 	}()
 
+	// Start gRPC health updates
+	go healthReporter.Run(ctx)
+
+	// Start outbox relay
+	relay := outbox.NewRelay(b.outbox, b.publisher, cfg.Outbox, outbox.NewMetrics(prometheus.DefaultRegisterer), logger)
+	go relay.Run(ctx)
+
+	// Start trash retention purger
+	purger := trash.NewPurger(messageService, cfg.Trash, trash.NewMetrics(prometheus.DefaultRegisterer), logger)
+	go purger.Run(ctx)
+
+	// Start expired message sweeper
+	sweeper := expiry.NewSweeper(messageService, cfg.Expiry, expiry.NewMetrics(prometheus.DefaultRegisterer), logger)
+	go sweeper.Run(ctx)
+
+	// Start scheduled message publisher
+	messageScheduler := scheduler.NewScheduler(messageService, cfg.Scheduler, scheduler.NewMetrics(prometheus.DefaultRegisterer), logger)
+	go messageScheduler.Run(ctx)
+
 	// Start Kafka consumer
-	go func() {
-		if err := consumer.Start(ctx); err != nil {
-			errChan <- fmt.Errorf("failed to start Kafka consumer: %w", err)
-		}
-	}()
+	if b.consumer != nil {
+		go func() {
+			if err := b.consumer.Start(ctx); err != nil {
+				errChan <- fmt.Errorf("failed to start Kafka consumer: %w", err)
+			}
+		}()
+	}
 
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -218,6 +275,87 @@ func main() {
 	}
 
 	// Cleanup and shutdown
-	cancel() // Stop Kafka consumer
 	logger.Info("Shutting down servers")
+	healthServer.Shutdown() // Report NOT_SERVING from now on
+	cancel()                // Stop Kafka consumer
+
+	// End the streams first, so that their clients resume elsewhere rather
+	// than hold up the graceful stop. Health watches never end by
+	// themselves, so calls still open after the timeout are cut off.
+	broadcaster.Close()
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(cfg.Server.ShutdownTimeout):
+		server.Stop()
+	}
+}
+
+// newCursorCodec returns the codec that signs list cursors. Without a
+// configured secret it signs with a random key, so cursors stop working on
+// restart and are not accepted by other replicas.
+func newCursorCodec(cfg config.PaginationConfig, logger *zap.Logger) (*pagination.Codec, error) {
+	if cfg.CursorSecret != "" {
+		return pagination.NewCodec([]byte(cfg.CursorSecret)), nil
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	logger.Warn("PAGINATION_CURSOR_SECRET is not set; using a random key, cursors will not survive restarts or work across replicas")
+
+	return pagination.NewCodec(key), nil
+}
+
+// newMetadataSchema loads the JSON Schema that message metadata must match.
+// Without one any metadata is accepted.
+func newMetadataSchema(cfg config.MetadataConfig) (*metadata.Schema, error) {
+	if cfg.SchemaFile == "" {
+		return nil, nil
+	}
+	return metadata.LoadSchema(cfg.SchemaFile)
+}
+
+// newBlobStore returns the store selected by cfg.Storage for the contents of
+// attachments.
+func newBlobStore(cfg config.AttachmentConfig) (service.BlobStore, error) {
+	if cfg.Storage == config.AttachmentStorageS3 {
+		return blob.NewS3Store(cfg.S3, nil)
+	}
+	return blob.NewLocalStore(cfg.Dir)
+}
+
+// newModerationChain returns the content filters enabled by cfg, in order,
+// or nil if none are.
+func newModerationChain(cfg config.ModerationConfig, cache moderation.Cache, metrics *moderation.Metrics) (*moderation.Chain, error) {
+	var filters []moderation.ContentFilter
+	if cfg.Normalize {
+		filters = append(filters, moderation.NewNormalizer(moderation.Action(cfg.ControlCharsAction)))
+	}
+	if len(cfg.BlockedWords) > 0 {
+		filters = append(filters, moderation.NewWordFilter(cfg.BlockedWords, moderation.Action(cfg.BlockedWordsAction)))
+	}
+	if cfg.PatternsFile != "" {
+		patterns, err := moderation.LoadPatterns(cfg.PatternsFile)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, moderation.NewPatternFilter(patterns, moderation.Action(cfg.PatternsAction)))
+	}
+	if cfg.MaxLinks > 0 {
+		filters = append(filters, moderation.NewLinkFilter(cfg.MaxLinks, moderation.Action(cfg.MaxLinksAction)))
+	}
+	if cfg.DuplicateWindow > 0 {
+		filters = append(filters, moderation.NewDuplicateFilter(cache, cfg.DuplicateWindow, moderation.Action(cfg.DuplicateAction)))
+	}
+
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	return moderation.NewChain(metrics, filters...), nil
 }
@@ -1,23 +1,85 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"github.com/spf13/viper"
+	"io/fs"
+	"regexp"
+	"strings"
 	"time"
 )
 
+// Backend drivers selectable through BACKEND_DRIVER.
+const (
+	// BackendExternal runs against PostgreSQL, Redis and Kafka.
+	BackendExternal = "external"
+	// BackendMemory keeps all state in process memory, for local
+	// development and hermetic tests.
+	BackendMemory = "memory"
+)
+
+// Attachment storage drivers selectable through ATTACHMENT_STORAGE.
+const (
+	// AttachmentStorageLocal keeps attachments in ATTACHMENT_DIR.
+	AttachmentStorageLocal = "local"
+	// AttachmentStorageS3 keeps attachments in an S3-compatible bucket.
+	AttachmentStorageS3 = "s3"
+)
+
+// Delete policies for replies, selectable through THREAD_DELETE_POLICY.
+const (
+	// ThreadDeleteKeep leaves the replies to a deleted message in place.
+	ThreadDeleteKeep = "keep"
+	// ThreadDeleteCascade deletes the replies along with the message.
+	ThreadDeleteCascade = "cascade"
+)
+
+// Actions a content filter can take, selectable through the MODERATION_*_ACTION
+// settings.
+const (
+	// ModerationReject refuses the write.
+	ModerationReject = "reject"
+	// ModerationMask hides the offending parts of the content.
+	ModerationMask = "mask"
+	// ModerationFlag lets the write through and flags it for review.
+	ModerationFlag = "flag"
+)
+
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	Kafka    KafkaConfig
-	GRPC     GRPCConfig
+	Backend     BackendConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	Redis       RedisConfig
+	Kafka       KafkaConfig
+	GRPC        GRPCConfig
+	Outbox      OutboxConfig
+	Trash       TrashConfig
+	Expiry      ExpiryConfig
+	Scheduler   SchedulerConfig
+	Paging      PaginationConfig
+	Search      SearchConfig
+	Idempotency IdempotencyConfig
+	Auth        AuthConfig
+	Tenant      TenantConfig
+	Metadata    MetadataConfig
+	Thread      ThreadConfig
+	Attachment  AttachmentConfig
+	Moderation  ModerationConfig
+	Stream      StreamConfig
+	Health      HealthConfig
+}
+
+type BackendConfig struct {
+	Driver string `mapstructure:"BACKEND_DRIVER"`
 }
 
 type ServerConfig struct {
 	Port         string        `mapstructure:"PORT"`
 	ReadTimeout  time.Duration `mapstructure:"READ_TIMEOUT"`
 	WriteTimeout time.Duration `mapstructure:"WRITE_TIMEOUT"`
+	// ShutdownTimeout bounds the wait for calls in flight on shutdown
+	ShutdownTimeout time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
 }
 
 type DatabaseConfig struct {
@@ -46,8 +108,159 @@ type KafkaConfig struct {
 
 type GRPCConfig struct {
 	Port string `mapstructure:"GRPC_PORT"`
+	// RequestTimeout bounds unary calls; clients may set a shorter deadline
+	RequestTimeout time.Duration `mapstructure:"GRPC_REQUEST_TIMEOUT"`
+	// Reflection registers the server reflection service, for grpcurl
+	Reflection bool `mapstructure:"GRPC_REFLECTION"`
+}
+
+type OutboxConfig struct {
+	PollInterval  time.Duration `mapstructure:"OUTBOX_POLL_INTERVAL"`
+	BatchSize     int32         `mapstructure:"OUTBOX_BATCH_SIZE"`
+	MaxBackoff    time.Duration `mapstructure:"OUTBOX_MAX_BACKOFF"`
+	Retention     time.Duration `mapstructure:"OUTBOX_RETENTION"`
+	PurgeInterval time.Duration `mapstructure:"OUTBOX_PURGE_INTERVAL"`
+}
+
+// TrashConfig controls how long deleted messages stay restorable before the
+// retention job purges them. A zero Retention keeps them forever.
+type TrashConfig struct {
+	Retention      time.Duration `mapstructure:"TRASH_RETENTION"`
+	PurgeInterval  time.Duration `mapstructure:"TRASH_PURGE_INTERVAL"`
+	PurgeBatchSize int32         `mapstructure:"TRASH_PURGE_BATCH_SIZE"`
 }
 
+// ExpiryConfig controls how often expired messages are moved to the trash
+// and how many are moved per transaction. Expired messages are hidden from
+// reads as soon as they expire, whenever the sweeper runs.
+type ExpiryConfig struct {
+	SweepInterval  time.Duration `mapstructure:"EXPIRY_SWEEP_INTERVAL"`
+	SweepBatchSize int32         `mapstructure:"EXPIRY_SWEEP_BATCH_SIZE"`
+}
+
+// SchedulerConfig controls how often scheduled messages that are due get
+// published and how many are published per transaction. A scheduled message
+// stays hidden until the scheduler publishes it, so the interval bounds how
+// late it can appear.
+type SchedulerConfig struct {
+	PublishInterval  time.Duration `mapstructure:"SCHEDULER_PUBLISH_INTERVAL"`
+	PublishBatchSize int32         `mapstructure:"SCHEDULER_PUBLISH_BATCH_SIZE"`
+}
+
+// PaginationConfig holds the key that signs list cursors. Replicas behind
+// the same load balancer must share it, or cursors issued by one are
+// rejected by the others.
+type PaginationConfig struct {
+	CursorSecret string `mapstructure:"PAGINATION_CURSOR_SECRET"`
+}
+
+// SearchConfig selects the PostgreSQL text search configuration, such as
+// english or simple, used to index and query message content. Changing it
+// only affects messages written afterwards until the existing ones are
+// reindexed.
+type SearchConfig struct {
+	Language string `mapstructure:"SEARCH_LANGUAGE"`
+}
+
+// IdempotencyConfig controls how long responses to requests sent with an
+// idempotency key are replayed, and how long a request may hold its key
+// before a concurrent retry is let through should the holder have died.
+type IdempotencyConfig struct {
+	TTL         time.Duration `mapstructure:"IDEMPOTENCY_TTL"`
+	LockTimeout time.Duration `mapstructure:"IDEMPOTENCY_LOCK_TIMEOUT"`
+}
+
+// AuthConfig holds the key that verifies the bearer tokens of API callers.
+// Without one, authentication is disabled and every caller is anonymous.
+type AuthConfig struct {
+	JWTSecret string `mapstructure:"JWT_SECRET"`
+}
+
+// TenantConfig names the request header, or gRPC metadata key, that carries
+// the tenant of a request without a token, as sent by a gateway that
+// authenticates callers itself. Only set it behind a gateway that strips the
+// header from untrusted requests. Requests with a token act for its tenant
+// claim, or the default tenant without one, whatever the header says.
+type TenantConfig struct {
+	Header string `mapstructure:"TENANT_HEADER"`
+}
+
+// MetadataConfig names a file holding a JSON Schema that the metadata of
+// every message written must match. Without one any JSON object is
+// accepted.
+type MetadataConfig struct {
+	SchemaFile string `mapstructure:"METADATA_SCHEMA_FILE"`
+}
+
+// ThreadConfig decides what deleting a message does to the replies below
+// it.
+type ThreadConfig struct {
+	DeletePolicy string `mapstructure:"THREAD_DELETE_POLICY"`
+}
+
+// AttachmentConfig selects where the contents of attachments are stored and
+// which files are accepted. AllowedTypes lists media types such as
+// image/png, or whole families such as image/*, matched against the type
+// sniffed from the contents; an empty list accepts any type.
+type AttachmentConfig struct {
+	Storage      string   `mapstructure:"ATTACHMENT_STORAGE"`
+	Dir          string   `mapstructure:"ATTACHMENT_DIR"`
+	MaxSize      int64    `mapstructure:"ATTACHMENT_MAX_SIZE"`
+	AllowedTypes []string `mapstructure:"ATTACHMENT_ALLOWED_TYPES"`
+	S3           S3Config
+}
+
+// S3Config locates the bucket of an S3-compatible object store. Stores
+// other than AWS, such as MinIO, usually need PathStyle addressing.
+type S3Config struct {
+	Endpoint        string `mapstructure:"S3_ENDPOINT"`
+	Region          string `mapstructure:"S3_REGION"`
+	Bucket          string `mapstructure:"S3_BUCKET"`
+	AccessKeyID     string `mapstructure:"S3_ACCESS_KEY_ID"`
+	SecretAccessKey string `mapstructure:"S3_SECRET_ACCESS_KEY"`
+	PathStyle       bool   `mapstructure:"S3_FORCE_PATH_STYLE"`
+}
+
+// ModerationConfig sets up the content filters run on every message
+// written, and the action each takes. Filters run in the order of the
+// fields below; a filter is off while its setting is empty or zero.
+// PatternsFile names a file of regular expressions, one per line.
+// Duplicates cannot be masked, so DuplicateAction is reject or flag.
+type ModerationConfig struct {
+	Normalize          bool          `mapstructure:"MODERATION_NORMALIZE"`
+	ControlCharsAction string        `mapstructure:"MODERATION_CONTROL_CHARS_ACTION"`
+	BlockedWords       []string      `mapstructure:"MODERATION_BLOCKED_WORDS"`
+	BlockedWordsAction string        `mapstructure:"MODERATION_BLOCKED_WORDS_ACTION"`
+	PatternsFile       string        `mapstructure:"MODERATION_PATTERNS_FILE"`
+	PatternsAction     string        `mapstructure:"MODERATION_PATTERNS_ACTION"`
+	MaxLinks           int           `mapstructure:"MODERATION_MAX_LINKS"`
+	MaxLinksAction     string        `mapstructure:"MODERATION_MAX_LINKS_ACTION"`
+	DuplicateWindow    time.Duration `mapstructure:"MODERATION_DUPLICATE_WINDOW"`
+	DuplicateAction    string        `mapstructure:"MODERATION_DUPLICATE_ACTION"`
+}
+
+// StreamConfig sizes the live message stream. Each subscriber buffers up to
+// BufferSize changes and is disconnected when it falls further behind.
+// HistorySize recent changes are kept for subscribers resuming after a
+// reconnect, and idle streams get a heartbeat every HeartbeatInterval.
+type StreamConfig struct {
+	BufferSize        int           `mapstructure:"STREAM_BUFFER_SIZE"`
+	HistorySize       int           `mapstructure:"STREAM_HISTORY_SIZE"`
+	HeartbeatInterval time.Duration `mapstructure:"STREAM_HEARTBEAT_INTERVAL"`
+}
+
+// HealthConfig controls the dependency checks behind the health endpoints
+// and the gRPC health service, which runs them every CheckInterval. Each
+// check gives up after CheckTimeout.
+type HealthConfig struct {
+	CheckInterval time.Duration `mapstructure:"HEALTH_CHECK_INTERVAL"`
+	CheckTimeout  time.Duration `mapstructure:"HEALTH_CHECK_TIMEOUT"`
+}
+
+// searchLanguagePattern matches optionally schema-qualified text search
+// configuration names.
+var searchLanguagePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\.[a-z_][a-z0-9_]*)?$`)
+
 func LoadConfig() (*Config, error) {
 	// Enable environment variables first
 	viper.AutomaticEnv()
@@ -56,16 +269,22 @@ func LoadConfig() (*Config, error) {
 	viper.SetConfigFile(".env")
 	viper.SetConfigType("env")
 
-	// Read config
-	if err := viper.ReadInConfig(); err != nil {
-		fmt.Printf("Warning: failed to read config file: %v\n", err)
+	// Read config; the file is optional, the environment is enough
+	if err := viper.ReadInConfig(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	// Backend defaults
+	viper.SetDefault("BACKEND_DRIVER", BackendExternal)
+
 	// Server defaults
 	viper.SetDefault("PORT", "3000")
 	viper.SetDefault("READ_TIMEOUT", "10s")
 	viper.SetDefault("WRITE_TIMEOUT", "10s")
+	viper.SetDefault("SHUTDOWN_TIMEOUT", "30s")
 	viper.SetDefault("GRPC_PORT", "50051")
+	viper.SetDefault("GRPC_REQUEST_TIMEOUT", "30s")
+	viper.SetDefault("GRPC_REFLECTION", true)
 
 	// Database defaults
 	viper.SetDefault("DB_HOST", "localhost")
@@ -88,12 +307,72 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("KAFKA_BROKERS", []string{"localhost:9092"})
 	viper.SetDefault("KAFKA_TOPIC", "messages")
 
+	// Outbox defaults
+	viper.SetDefault("OUTBOX_POLL_INTERVAL", "1s")
+	viper.SetDefault("OUTBOX_BATCH_SIZE", 100)
+	viper.SetDefault("OUTBOX_MAX_BACKOFF", "30s")
+	viper.SetDefault("OUTBOX_RETENTION", "168h")
+	viper.SetDefault("OUTBOX_PURGE_INTERVAL", "1h")
+
+	// Trash defaults
+	viper.SetDefault("TRASH_RETENTION", "720h")
+	viper.SetDefault("TRASH_PURGE_INTERVAL", "1h")
+	viper.SetDefault("TRASH_PURGE_BATCH_SIZE", 100)
+
+	// Expiry defaults
+	viper.SetDefault("EXPIRY_SWEEP_INTERVAL", "1m")
+	viper.SetDefault("EXPIRY_SWEEP_BATCH_SIZE", 100)
+
+	// Scheduler defaults
+	viper.SetDefault("SCHEDULER_PUBLISH_INTERVAL", "10s")
+	viper.SetDefault("SCHEDULER_PUBLISH_BATCH_SIZE", 100)
+
+	// Search defaults
+	viper.SetDefault("SEARCH_LANGUAGE", "english")
+
+	// Idempotency defaults
+	viper.SetDefault("IDEMPOTENCY_TTL", "24h")
+	viper.SetDefault("IDEMPOTENCY_LOCK_TIMEOUT", "30s")
+
+	// Thread defaults
+	viper.SetDefault("THREAD_DELETE_POLICY", ThreadDeleteKeep)
+
+	// Attachment defaults
+	viper.SetDefault("ATTACHMENT_STORAGE", AttachmentStorageLocal)
+	viper.SetDefault("ATTACHMENT_DIR", "data/attachments")
+	viper.SetDefault("ATTACHMENT_MAX_SIZE", 10<<20)
+	viper.SetDefault("ATTACHMENT_ALLOWED_TYPES", "image/*,application/pdf,text/plain")
+	viper.SetDefault("S3_REGION", "us-east-1")
+
+	// Moderation defaults
+	viper.SetDefault("MODERATION_NORMALIZE", true)
+	viper.SetDefault("MODERATION_CONTROL_CHARS_ACTION", ModerationMask)
+	viper.SetDefault("MODERATION_BLOCKED_WORDS_ACTION", ModerationMask)
+	viper.SetDefault("MODERATION_PATTERNS_ACTION", ModerationReject)
+	viper.SetDefault("MODERATION_MAX_LINKS", 0)
+	viper.SetDefault("MODERATION_MAX_LINKS_ACTION", ModerationReject)
+	viper.SetDefault("MODERATION_DUPLICATE_WINDOW", 0)
+	viper.SetDefault("MODERATION_DUPLICATE_ACTION", ModerationReject)
+
+	// Stream defaults
+	viper.SetDefault("STREAM_BUFFER_SIZE", 256)
+	viper.SetDefault("STREAM_HISTORY_SIZE", 10000)
+	viper.SetDefault("STREAM_HEARTBEAT_INTERVAL", "30s")
+
+	// Health defaults
+	viper.SetDefault("HEALTH_CHECK_INTERVAL", "10s")
+	viper.SetDefault("HEALTH_CHECK_TIMEOUT", "2s")
+
 	// Create config
 	config := &Config{
+		Backend: BackendConfig{
+			Driver: viper.GetString("BACKEND_DRIVER"),
+		},
 		Server: ServerConfig{
-			Port:         viper.GetString("PORT"),
-			ReadTimeout:  viper.GetDuration("READ_TIMEOUT"),
-			WriteTimeout: viper.GetDuration("WRITE_TIMEOUT"),
+			Port:            viper.GetString("PORT"),
+			ReadTimeout:     viper.GetDuration("READ_TIMEOUT"),
+			WriteTimeout:    viper.GetDuration("WRITE_TIMEOUT"),
+			ShutdownTimeout: viper.GetDuration("SHUTDOWN_TIMEOUT"),
 		},
 		Database: DatabaseConfig{
 			Host:            viper.GetString("DB_HOST"),
@@ -117,12 +396,192 @@ func LoadConfig() (*Config, error) {
 			Topic:   viper.GetString("KAFKA_TOPIC"),
 		},
 		GRPC: GRPCConfig{
-			Port: viper.GetString("GRPC_PORT"),
+			Port:           viper.GetString("GRPC_PORT"),
+			RequestTimeout: viper.GetDuration("GRPC_REQUEST_TIMEOUT"),
+			Reflection:     viper.GetBool("GRPC_REFLECTION"),
+		},
+		Outbox: OutboxConfig{
+			PollInterval:  viper.GetDuration("OUTBOX_POLL_INTERVAL"),
+			BatchSize:     viper.GetInt32("OUTBOX_BATCH_SIZE"),
+			MaxBackoff:    viper.GetDuration("OUTBOX_MAX_BACKOFF"),
+			Retention:     viper.GetDuration("OUTBOX_RETENTION"),
+			PurgeInterval: viper.GetDuration("OUTBOX_PURGE_INTERVAL"),
+		},
+		Trash: TrashConfig{
+			Retention:      viper.GetDuration("TRASH_RETENTION"),
+			PurgeInterval:  viper.GetDuration("TRASH_PURGE_INTERVAL"),
+			PurgeBatchSize: viper.GetInt32("TRASH_PURGE_BATCH_SIZE"),
+		},
+		Expiry: ExpiryConfig{
+			SweepInterval:  viper.GetDuration("EXPIRY_SWEEP_INTERVAL"),
+			SweepBatchSize: viper.GetInt32("EXPIRY_SWEEP_BATCH_SIZE"),
+		},
+		Scheduler: SchedulerConfig{
+			PublishInterval:  viper.GetDuration("SCHEDULER_PUBLISH_INTERVAL"),
+			PublishBatchSize: viper.GetInt32("SCHEDULER_PUBLISH_BATCH_SIZE"),
+		},
+		Paging: PaginationConfig{
+			CursorSecret: viper.GetString("PAGINATION_CURSOR_SECRET"),
+		},
+		Search: SearchConfig{
+			Language: viper.GetString("SEARCH_LANGUAGE"),
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:         viper.GetDuration("IDEMPOTENCY_TTL"),
+			LockTimeout: viper.GetDuration("IDEMPOTENCY_LOCK_TIMEOUT"),
+		},
+		Auth: AuthConfig{
+			JWTSecret: viper.GetString("JWT_SECRET"),
+		},
+		Tenant: TenantConfig{
+			Header: viper.GetString("TENANT_HEADER"),
+		},
+		Metadata: MetadataConfig{
+			SchemaFile: viper.GetString("METADATA_SCHEMA_FILE"),
 		},
+		Thread: ThreadConfig{
+			DeletePolicy: viper.GetString("THREAD_DELETE_POLICY"),
+		},
+		Attachment: AttachmentConfig{
+			Storage:      viper.GetString("ATTACHMENT_STORAGE"),
+			Dir:          viper.GetString("ATTACHMENT_DIR"),
+			MaxSize:      viper.GetInt64("ATTACHMENT_MAX_SIZE"),
+			AllowedTypes: splitList(viper.GetString("ATTACHMENT_ALLOWED_TYPES")),
+			S3: S3Config{
+				Endpoint:        viper.GetString("S3_ENDPOINT"),
+				Region:          viper.GetString("S3_REGION"),
+				Bucket:          viper.GetString("S3_BUCKET"),
+				AccessKeyID:     viper.GetString("S3_ACCESS_KEY_ID"),
+				SecretAccessKey: viper.GetString("S3_SECRET_ACCESS_KEY"),
+				PathStyle:       viper.GetBool("S3_FORCE_PATH_STYLE"),
+			},
+		},
+		Moderation: ModerationConfig{
+			Normalize:          viper.GetBool("MODERATION_NORMALIZE"),
+			ControlCharsAction: viper.GetString("MODERATION_CONTROL_CHARS_ACTION"),
+			BlockedWords:       splitList(viper.GetString("MODERATION_BLOCKED_WORDS")),
+			BlockedWordsAction: viper.GetString("MODERATION_BLOCKED_WORDS_ACTION"),
+			PatternsFile:       viper.GetString("MODERATION_PATTERNS_FILE"),
+			PatternsAction:     viper.GetString("MODERATION_PATTERNS_ACTION"),
+			MaxLinks:           viper.GetInt("MODERATION_MAX_LINKS"),
+			MaxLinksAction:     viper.GetString("MODERATION_MAX_LINKS_ACTION"),
+			DuplicateWindow:    viper.GetDuration("MODERATION_DUPLICATE_WINDOW"),
+			DuplicateAction:    viper.GetString("MODERATION_DUPLICATE_ACTION"),
+		},
+		Stream: StreamConfig{
+			BufferSize:        viper.GetInt("STREAM_BUFFER_SIZE"),
+			HistorySize:       viper.GetInt("STREAM_HISTORY_SIZE"),
+			HeartbeatInterval: viper.GetDuration("STREAM_HEARTBEAT_INTERVAL"),
+		},
+		Health: HealthConfig{
+			CheckInterval: viper.GetDuration("HEALTH_CHECK_INTERVAL"),
+			CheckTimeout:  viper.GetDuration("HEALTH_CHECK_TIMEOUT"),
+		},
+	}
+
+	switch config.Backend.Driver {
+	case BackendExternal, BackendMemory:
+	default:
+		return nil, fmt.Errorf("unknown backend driver %q", config.Backend.Driver)
+	}
+
+	if config.Outbox.PollInterval <= 0 || config.Outbox.BatchSize <= 0 {
+		return nil, fmt.Errorf("outbox poll interval and batch size must be positive")
+	}
+
+	if config.Trash.Retention < 0 {
+		return nil, fmt.Errorf("trash retention must not be negative")
+	}
+	if config.Trash.Retention > 0 && (config.Trash.PurgeInterval <= 0 || config.Trash.PurgeBatchSize <= 0) {
+		return nil, fmt.Errorf("trash purge interval and batch size must be positive")
+	}
+
+	if config.GRPC.RequestTimeout <= 0 {
+		return nil, fmt.Errorf("gRPC request timeout must be positive")
+	}
+
+	if config.Expiry.SweepInterval <= 0 || config.Expiry.SweepBatchSize <= 0 {
+		return nil, fmt.Errorf("expiry sweep interval and batch size must be positive")
+	}
+
+	if config.Scheduler.PublishInterval <= 0 || config.Scheduler.PublishBatchSize <= 0 {
+		return nil, fmt.Errorf("scheduler publish interval and batch size must be positive")
+	}
+
+	if !searchLanguagePattern.MatchString(config.Search.Language) {
+		return nil, fmt.Errorf("invalid search language %q", config.Search.Language)
+	}
+
+	if config.Idempotency.TTL <= 0 || config.Idempotency.LockTimeout <= 0 {
+		return nil, fmt.Errorf("idempotency TTL and lock timeout must be positive")
+	}
+
+	switch config.Thread.DeletePolicy {
+	case ThreadDeleteKeep, ThreadDeleteCascade:
+	default:
+		return nil, fmt.Errorf("unknown thread delete policy %q", config.Thread.DeletePolicy)
+	}
+
+	switch config.Attachment.Storage {
+	case AttachmentStorageLocal:
+		if config.Attachment.Dir == "" {
+			return nil, fmt.Errorf("attachment directory must be set")
+		}
+	case AttachmentStorageS3:
+		if config.Attachment.S3.Endpoint == "" || config.Attachment.S3.Bucket == "" {
+			return nil, fmt.Errorf("S3 endpoint and bucket must be set")
+		}
+	default:
+		return nil, fmt.Errorf("unknown attachment storage %q", config.Attachment.Storage)
+	}
+	if config.Attachment.MaxSize <= 0 {
+		return nil, fmt.Errorf("attachment max size must be positive")
 	}
 
-	// Debug config
-	fmt.Printf("Database config: %+v\n", config.Database)
+	for _, action := range []string{
+		config.Moderation.ControlCharsAction,
+		config.Moderation.BlockedWordsAction,
+		config.Moderation.PatternsAction,
+		config.Moderation.MaxLinksAction,
+		config.Moderation.DuplicateAction,
+	} {
+		switch action {
+		case ModerationReject, ModerationMask, ModerationFlag:
+		default:
+			return nil, fmt.Errorf("unknown moderation action %q", action)
+		}
+	}
+	if config.Moderation.DuplicateAction == ModerationMask {
+		return nil, fmt.Errorf("duplicate content cannot be masked, only rejected or flagged")
+	}
+	if config.Moderation.MaxLinks < 0 || config.Moderation.DuplicateWindow < 0 {
+		return nil, fmt.Errorf("moderation max links and duplicate window must not be negative")
+	}
+
+	if config.Stream.BufferSize <= 0 || config.Stream.HeartbeatInterval <= 0 {
+		return nil, fmt.Errorf("stream buffer size and heartbeat interval must be positive")
+	}
+	if config.Stream.HistorySize < 0 {
+		return nil, fmt.Errorf("stream history size must not be negative")
+	}
+
+	if config.Health.CheckInterval <= 0 || config.Health.CheckTimeout <= 0 {
+		return nil, fmt.Errorf("health check interval and timeout must be positive")
+	}
+	if config.Server.ShutdownTimeout <= 0 {
+		return nil, fmt.Errorf("shutdown timeout must be positive")
+	}
 
 	return config, nil
 }
+
+// splitList splits a comma-separated setting, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
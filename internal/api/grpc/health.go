@@ -0,0 +1,92 @@
+package grpc
+
+import (
+	"context"
+	"go-boilerplate/internal/health"
+	pb "go-boilerplate/proto/message/v1"
+	"go.uber.org/zap"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"time"
+)
+
+// HealthReporter keeps the statuses of a grpc.health.v1 health service in
+// step with the dependency checks of a health.Checker. Each dependency is
+// reported as a service of its own, under the name of its check, and the
+// server as a whole ("") and the MessageService are SERVING while every
+// dependency is.
+type HealthReporter struct {
+	server   *grpchealth.Server
+	checker  *health.Checker
+	interval time.Duration
+	logger   *zap.Logger
+	// statuses holds the status last set for each service
+	statuses map[string]healthpb.HealthCheckResponse_ServingStatus
+}
+
+// NewHealthReporter returns a HealthReporter updating server every
+// interval. Until the first update, every service is NOT_SERVING.
+func NewHealthReporter(server *grpchealth.Server, checker *health.Checker, interval time.Duration, logger *zap.Logger) *HealthReporter {
+	r := &HealthReporter{
+		server:   server,
+		checker:  checker,
+		interval: interval,
+		logger:   logger,
+		statuses: make(map[string]healthpb.HealthCheckResponse_ServingStatus),
+	}
+	for _, service := range append([]string{"", pb.MessageService_ServiceDesc.ServiceName}, checker.Names()...) {
+		r.set(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	return r
+}
+
+// Run updates the statuses every interval until ctx is cancelled.
+func (r *HealthReporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.Update(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Update runs the checks once and sets the statuses from their results.
+func (r *HealthReporter) Update(ctx context.Context) {
+	results := r.checker.Run(ctx)
+	for _, result := range results {
+		if result.Err != nil {
+			r.set(result.Name, healthpb.HealthCheckResponse_NOT_SERVING, zap.Error(result.Err))
+		} else {
+			r.set(result.Name, healthpb.HealthCheckResponse_SERVING)
+		}
+	}
+
+	serving := healthpb.HealthCheckResponse_NOT_SERVING
+	if health.Healthy(results) {
+		serving = healthpb.HealthCheckResponse_SERVING
+	}
+	r.set("", serving)
+	r.set(pb.MessageService_ServiceDesc.ServiceName, serving)
+}
+
+// set sets the status of service, logging changes after the initial one
+func (r *HealthReporter) set(service string, status healthpb.HealthCheckResponse_ServingStatus, fields ...zap.Field) {
+	previous, ok := r.statuses[service]
+	if ok && previous != status {
+		fields = append([]zap.Field{zap.String("service", service), zap.String("status", status.String())}, fields...)
+		if status == healthpb.HealthCheckResponse_SERVING {
+			r.logger.Info("gRPC health status changed", fields...)
+		} else {
+			r.logger.Warn("gRPC health status changed", fields...)
+		}
+	}
+
+	r.statuses[service] = status
+	r.server.SetServingStatus(service, status)
+}
@@ -0,0 +1,64 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-boilerplate/internal/health"
+	pb "go-boilerplate/proto/message/v1"
+	"go.uber.org/zap"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"testing"
+	"time"
+)
+
+// pinger fails while err is set
+type pinger struct {
+	err error
+}
+
+func (p *pinger) Ping(ctx context.Context) error {
+	return p.err
+}
+
+func TestHealthReporter(t *testing.T) {
+	ctx := context.Background()
+	database, cache := &pinger{}, &pinger{}
+	checker := health.NewChecker(time.Second, health.Check{Name: "database", Pinger: database}, health.Check{Name: "cache", Pinger: cache})
+	server := grpchealth.NewServer()
+	reporter := NewHealthReporter(server, checker, time.Minute, zap.NewNop())
+
+	statusOf := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := server.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return resp.Status
+	}
+
+	// Nothing is serving before the first check
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, statusOf(""))
+
+	reporter.Update(ctx)
+	for _, service := range []string{"", pb.MessageService_ServiceDesc.ServiceName, "database", "cache"} {
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, statusOf(service), service)
+	}
+
+	// A dependency going down takes the server with it
+	cache.err = errors.New("connection refused")
+	reporter.Update(ctx)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, statusOf("database"))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, statusOf("cache"))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, statusOf(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, statusOf(pb.MessageService_ServiceDesc.ServiceName))
+
+	cache.err = nil
+	reporter.Update(ctx)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, statusOf(""))
+
+	// Once shut down, the checks no longer bring it back
+	server.Shutdown()
+	reporter.Update(ctx)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, statusOf(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, statusOf("database"))
+}
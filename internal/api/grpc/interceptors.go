@@ -0,0 +1,229 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"go-boilerplate/internal/auth"
+	pb "go-boilerplate/proto/message/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"runtime/debug"
+	"time"
+)
+
+// MethodPermissions maps the full name of each method that requires a
+// permission, beyond an authenticated caller, to that permission, written
+// "service:permission" as in the perms claim checked by the HTTP RBAC
+// middleware. Only PurgeMessage, the counterpart of the /admin REST route,
+// requires one; the changes a caller may make to other people's messages
+// are decided by the service.
+var MethodPermissions = map[string]string{
+	pb.MessageService_PurgeMessage_FullMethodName: "messages:purge",
+}
+
+// LoggingUnaryInterceptor logs every call with its method, status code and
+// duration. Calls failing with a server error are logged as errors, other
+// failures as warnings.
+func LoggingUnaryInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(logger, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// LoggingStreamInterceptor is LoggingUnaryInterceptor for streaming calls,
+// which are logged once they end.
+func LoggingStreamInterceptor(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, stream)
+		logCall(logger, info.FullMethod, start, err)
+		return err
+	}
+}
+
+func logCall(logger *zap.Logger, method string, start time.Time, err error) {
+	code := status.Code(err)
+	fields := []zap.Field{
+		zap.String("method", method),
+		zap.String("code", code.String()),
+		zap.Duration("duration", time.Since(start)),
+	}
+	switch code {
+	case codes.OK:
+		logger.Info("gRPC call", fields...)
+	case codes.Unknown, codes.Internal, codes.DataLoss, codes.Unimplemented:
+		logger.Error("gRPC call failed", append(fields, zap.Error(err))...)
+	default:
+		logger.Warn("gRPC call failed", append(fields, zap.Error(err))...)
+	}
+}
+
+// RecoveryUnaryInterceptor turns a panic in a call into an Internal error,
+// logging the panic with its stack, instead of crashing the server.
+func RecoveryUnaryInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer recoverCall(logger, info.FullMethod, &err)
+		return handler(ctx, req)
+	}
+}
+
+// RecoveryStreamInterceptor is RecoveryUnaryInterceptor for streaming calls.
+func RecoveryStreamInterceptor(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer recoverCall(logger, info.FullMethod, &err)
+		return handler(srv, stream)
+	}
+}
+
+// recoverCall is deferred by the recovery interceptors to set *err when the
+// call panics
+func recoverCall(logger *zap.Logger, method string, err *error) {
+	if r := recover(); r != nil {
+		logger.Error("gRPC call panicked",
+			zap.String("method", method),
+			zap.String("panic", fmt.Sprint(r)),
+			zap.ByteString("stack", debug.Stack()),
+		)
+		*err = status.Error(codes.Internal, "internal error")
+	}
+}
+
+// RBACUnaryInterceptor rejects calls to the methods in permissions whose
+// caller has not been granted the permission, like the HTTP RBAC middleware.
+// It runs after AuthUnaryInterceptor.
+func RBACUnaryInterceptor(permissions map[string]string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := authorize(ctx, permissions[info.FullMethod]); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// RBACStreamInterceptor is RBACUnaryInterceptor for streaming calls.
+func RBACStreamInterceptor(permissions map[string]string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorize(stream.Context(), permissions[info.FullMethod]); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+// authorize checks that the caller in ctx holds permission, if it is set
+func authorize(ctx context.Context, permission string) error {
+	if permission == "" {
+		return nil
+	}
+
+	claims, ok := auth.FromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "unauthenticated")
+	}
+	if !claims.HasPermission(permission) {
+		return status.Error(codes.PermissionDenied, "insufficient permissions")
+	}
+	return nil
+}
+
+// DeadlineUnaryInterceptor bounds each call by timeout, or by the deadline
+// of the client if that is sooner, and reports calls that fail because it
+// passed or the client went away with DeadlineExceeded or Canceled.
+// Streaming calls are long-lived and only end with their client.
+func DeadlineUnaryInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		resp, err := handler(ctx, req)
+		if err != nil && ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		return resp, err
+	}
+}
+
+// ValidationUnaryInterceptor rejects requests with InvalidArgument before
+// they reach the handler if they fail the checks that need no state: IDs
+// must be UUIDs and batches must hold 1 to service.MaxBatchSize items. The
+// handlers check the rest.
+func ValidationUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := validateRequest(req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// ValidationStreamInterceptor is ValidationUnaryInterceptor for streaming
+// calls, whose requests are checked as they are received.
+func ValidationStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &validatingStream{ServerStream: stream})
+	}
+}
+
+// validatingStream is a ServerStream that validates the requests received
+type validatingStream struct {
+	grpc.ServerStream
+}
+
+func (s *validatingStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return validateRequest(m)
+}
+
+// validateRequest runs the checks of ValidationUnaryInterceptor on req
+func validateRequest(req interface{}) error {
+	var ids []string
+	batch := -1
+	switch r := req.(type) {
+	case *pb.GetMessageRequest:
+		ids = []string{r.Id}
+	case *pb.UpdateMessageRequest:
+		ids = []string{r.Id}
+	case *pb.DeleteMessageRequest:
+		ids = []string{r.Id}
+	case *pb.ListMessageRevisionsRequest:
+		ids = []string{r.Id}
+	case *pb.GetMessageRevisionRequest:
+		ids = []string{r.Id}
+	case *pb.RestoreMessageRevisionRequest:
+		ids = []string{r.Id}
+	case *pb.ListMessageRepliesRequest:
+		ids = []string{r.Id}
+	case *pb.GetThreadRequest:
+		ids = []string{r.Id}
+	case *pb.ListMessagesRequest:
+		ids = r.Ids
+	case *pb.StreamMessagesRequest:
+		if r.ThreadId != "" {
+			ids = []string{r.ThreadId}
+		}
+	case *pb.BatchCreateMessagesRequest:
+		batch = len(r.Requests)
+	case *pb.BatchUpdateMessagesRequest:
+		batch = len(r.Requests)
+	case *pb.BatchDeleteMessagesRequest:
+		batch = len(r.Ids)
+	}
+
+	for _, id := range ids {
+		if _, err := uuid.Parse(id); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid ID %q", id)
+		}
+	}
+	if batch >= 0 {
+		return checkBatchSize(batch)
+	}
+	return nil
+}
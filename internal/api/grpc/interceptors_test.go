@@ -0,0 +1,124 @@
+package grpc
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-boilerplate/internal/auth"
+	pb "go-boilerplate/proto/message/v1"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+var getMessage = &grpc.UnaryServerInfo{FullMethod: pb.MessageService_GetMessage_FullMethodName}
+
+func TestRecoveryUnaryInterceptor(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	}
+
+	// Logging wraps recovery, so the panic is logged as an Internal error
+	_, err := LoggingUnaryInterceptor(logger)(context.Background(), nil, getMessage, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return RecoveryUnaryInterceptor(logger)(ctx, req, getMessage, handler)
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	entries := logs.All()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "gRPC call panicked", entries[0].Message)
+	assert.Equal(t, "boom", entries[0].ContextMap()["panic"])
+	assert.Equal(t, zapcore.ErrorLevel, entries[1].Level)
+	assert.Equal(t, "Internal", entries[1].ContextMap()["code"])
+	assert.Equal(t, pb.MessageService_GetMessage_FullMethodName, entries[1].ContextMap()["method"])
+}
+
+func TestRBACUnaryInterceptor(t *testing.T) {
+	interceptor := RBACUnaryInterceptor(map[string]string{pb.MessageService_GetMessage_FullMethodName: "messages:read"})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	tests := []struct {
+		name   string
+		claims *auth.Claims
+		info   *grpc.UnaryServerInfo
+		code   codes.Code
+	}{
+		{"granted", &auth.Claims{UserID: "reader", Permissions: []string{"messages:read"}}, getMessage, codes.OK},
+		{"not granted", &auth.Claims{UserID: "reader", Permissions: []string{"messages:purge"}}, getMessage, codes.PermissionDenied},
+		{"anonymous", nil, getMessage, codes.Unauthenticated},
+		{"open method", nil, &grpc.UnaryServerInfo{FullMethod: pb.MessageService_ListMessages_FullMethodName}, codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.claims != nil {
+				ctx = auth.NewContext(ctx, tt.claims)
+			}
+			_, err := interceptor(ctx, nil, tt.info, handler)
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
+
+func TestDeadlineUnaryInterceptor(t *testing.T) {
+	interceptor := DeadlineUnaryInterceptor(20 * time.Millisecond)
+
+	// The service sees the deadline, and failures caused by it are reported
+	// as such rather than as whatever the handler made of them
+	_, err := interceptor(context.Background(), nil, getMessage, func(ctx context.Context, req interface{}) (interface{}, error) {
+		_, ok := ctx.Deadline()
+		assert.True(t, ok)
+		<-ctx.Done()
+		return nil, status.Errorf(codes.Internal, "failed to get message: %v", ctx.Err())
+	})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	// A sooner client deadline is kept
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	client, _ := ctx.Deadline()
+	_, err = interceptor(ctx, nil, getMessage, func(ctx context.Context, req interface{}) (interface{}, error) {
+		deadline, _ := ctx.Deadline()
+		assert.Equal(t, client, deadline)
+		return "ok", nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestValidationUnaryInterceptor(t *testing.T) {
+	interceptor := ValidationUnaryInterceptor()
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	tests := []struct {
+		name string
+		req  interface{}
+		code codes.Code
+	}{
+		{"valid ID", &pb.GetMessageRequest{Id: "00000000-0000-0000-0000-000000000001"}, codes.OK},
+		{"invalid ID", &pb.GetMessageRequest{Id: "not-a-uuid"}, codes.InvalidArgument},
+		{"invalid listed ID", &pb.ListMessagesRequest{Ids: []string{"not-a-uuid"}}, codes.InvalidArgument},
+		{"invalid thread", &pb.StreamMessagesRequest{ThreadId: "not-a-uuid"}, codes.InvalidArgument},
+		{"empty batch", &pb.BatchDeleteMessagesRequest{}, codes.InvalidArgument},
+		{"batch with an invalid ID", &pb.BatchDeleteMessagesRequest{Ids: []string{"not-a-uuid"}}, codes.OK},
+		{"no checks", &pb.CreateMessageRequest{}, codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := interceptor(context.Background(), tt.req, getMessage, handler)
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
@@ -2,41 +2,154 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"github.com/google/uuid"
+	"go-boilerplate/internal/apperr"
+	"go-boilerplate/internal/auth"
+	"go-boilerplate/internal/idempotency"
 	"go-boilerplate/internal/models"
+	"go-boilerplate/internal/pagination"
 	"go-boilerplate/internal/service"
+	"go-boilerplate/internal/stream"
+	"go-boilerplate/internal/tenant"
 	pb "go-boilerplate/proto/message/v1"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
+	"strings"
+	"time"
 )
 
+// updateFields copies each field that UpdateMessage can update, keyed by its
+// update_mask path, from the request onto the message. Labels, metadata and
+// the expiry and publish time are copied non-nil, so that clearing them is
+// told apart from leaving them out of a batch update; a zero time clears
+// them. An unspecified status keeps the current one. The expiry and
+// publication must have been checked with expiry and publication
+// beforehand.
+var updateFields = map[string]func(*models.Message, *pb.UpdateMessageRequest){
+	"content":  func(m *models.Message, req *pb.UpdateMessageRequest) { m.Content = req.Content },
+	"labels":   func(m *models.Message, req *pb.UpdateMessageRequest) { m.Labels = append([]string{}, req.Labels...) },
+	"metadata": func(m *models.Message, req *pb.UpdateMessageRequest) { m.Metadata = req.Metadata.AsMap() },
+	"expires_at": func(m *models.Message, req *pb.UpdateMessageRequest) {
+		expiresAt, _ := expiry(req.ExpiresAt, req.Ttl)
+		m.ExpiresAt = &expiresAt
+	},
+	"status": func(m *models.Message, req *pb.UpdateMessageRequest) {
+		if s, _, _ := publication(req.Status, nil); s != "" {
+			m.Status = s
+		}
+	},
+	"publish_at": func(m *models.Message, req *pb.UpdateMessageRequest) {
+		var publishAt time.Time
+		if _, at, _ := publication(req.Status, req.PublishAt); at != nil {
+			publishAt = *at
+		}
+		m.PublishAt = &publishAt
+	},
+}
+
+// updatablePaths is the update mask used when the request does not set one
+var updatablePaths = []string{"content", "labels", "metadata", "expires_at", "status", "publish_at"}
+
+// statuses maps the status enum of the API to the status of a message
+var statuses = map[pb.MessageStatus]string{
+	pb.MessageStatus_MESSAGE_STATUS_DRAFT:     models.StatusDraft,
+	pb.MessageStatus_MESSAGE_STATUS_SCHEDULED: models.StatusScheduled,
+	pb.MessageStatus_MESSAGE_STATUS_PUBLISHED: models.StatusPublished,
+}
+
+// eventTypes maps the kinds of stream.Change to the event types of the API
+var eventTypes = map[stream.Kind]pb.MessageEventType{
+	stream.KindCreated: pb.MessageEventType_MESSAGE_EVENT_TYPE_CREATED,
+	stream.KindUpdated: pb.MessageEventType_MESSAGE_EVENT_TYPE_UPDATED,
+	stream.KindDeleted: pb.MessageEventType_MESSAGE_EVENT_TYPE_DELETED,
+}
+
 type MessageServer struct {
 	pb.UnimplementedMessageServiceServer
 	messageService *service.MessageService
+	cursors        *pagination.Codec
+	idempotency    *idempotency.Keeper
+	broadcaster    *stream.Broadcaster
+	heartbeat      time.Duration
 }
 
-func NewMessageServer(messageService *service.MessageService) *MessageServer {
+// NewMessageServer returns a MessageServer. StreamMessages subscribes to
+// broadcaster and sends a heartbeat to idle subscribers every heartbeat.
+func NewMessageServer(messageService *service.MessageService, cursors *pagination.Codec, keeper *idempotency.Keeper, broadcaster *stream.Broadcaster, heartbeat time.Duration) *MessageServer {
 	return &MessageServer{
 		messageService: messageService,
+		cursors:        cursors,
+		idempotency:    keeper,
+		broadcaster:    broadcaster,
+		heartbeat:      heartbeat,
 	}
 }
 
 func (s *MessageServer) CreateMessage(ctx context.Context, req *pb.CreateMessageRequest) (*pb.MessageResponse, error) {
-	message := &models.Message{
-		Content: req.Content,
+	message, err := toMessage(req)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.messageService.CreateMessage(ctx, message); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to create message: %v", err)
+	create := func() (*pb.MessageResponse, error) {
+		message := *message
+
+		err := s.messageService.CreateMessage(ctx, &message)
+		if err != nil {
+			return nil, apperr.ToGRPC(err)
+		}
+
+		return toResponse(&message), nil
 	}
 
-	return &pb.MessageResponse{
-		Id:        message.ID.String(),
-		Content:   message.Content,
-		CreatedAt: timestamppb.New(message.CreatedAt),
-	}, nil
+	if req.RequestId == "" {
+		return create()
+	}
+	if len(req.RequestId) > idempotency.MaxKeyLength {
+		return nil, status.Errorf(codes.InvalidArgument, "request_id must not exceed %d characters", idempotency.MaxKeyLength)
+	}
+
+	// Retries must send the same request apart from the request_id itself
+	fingerprint, err := proto.MarshalOptions{Deterministic: true}.Marshal(&pb.CreateMessageRequest{
+		Content:   req.Content,
+		Labels:    req.Labels,
+		Metadata:  req.Metadata,
+		ParentId:  req.ParentId,
+		ExpiresAt: req.ExpiresAt,
+		Ttl:       req.Ttl,
+		Status:    req.Status,
+		PublishAt: req.PublishAt,
+	})
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+
+	stored, _, err := s.idempotency.Do(ctx, "grpc:CreateMessage", req.RequestId, idempotency.Fingerprint(fingerprint), func() (*idempotency.Response, error) {
+		resp, err := create()
+		if err != nil {
+			return nil, err
+		}
+		body, err := proto.Marshal(resp)
+		if err != nil {
+			return nil, apperr.ToGRPC(err)
+		}
+		return &idempotency.Response{Body: body}, nil
+	})
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+
+	resp := &pb.MessageResponse{}
+	if err := proto.Unmarshal(stored.Body, resp); err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return resp, nil
 }
 
 func (s *MessageServer) GetMessage(ctx context.Context, req *pb.GetMessageRequest) (*pb.MessageResponse, error) {
@@ -47,35 +160,844 @@ func (s *MessageServer) GetMessage(ctx context.Context, req *pb.GetMessageReques
 
 	message, err := s.messageService.GetMessage(ctx, id)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to get message: %v", err)
+		return nil, apperr.ToGRPC(err)
+	}
+
+	return toResponse(message), nil
+}
+
+func (s *MessageServer) UpdateMessage(ctx context.Context, req *pb.UpdateMessageRequest) (*pb.MessageResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid message ID: %v", err)
+	}
+	if req.ExpectedVersion < 0 {
+		return nil, status.Error(codes.InvalidArgument, "expected_version must not be negative")
+	}
+	if _, err := expiry(req.ExpiresAt, req.Ttl); err != nil {
+		return nil, err
+	}
+	if _, _, err := publication(req.Status, req.PublishAt); err != nil {
+		return nil, err
+	}
+
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		paths = updatablePaths
+	}
+	for _, path := range paths {
+		if _, ok := updateFields[path]; !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "update_mask: field %q cannot be updated", path)
+		}
+	}
+
+	message, err := s.messageService.PatchMessage(ctx, id, req.ExpectedVersion, func(current *models.Message) error {
+		for _, path := range paths {
+			updateFields[path](current, req)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+
+	return toResponse(message), nil
+}
+
+func (s *MessageServer) DeleteMessage(ctx context.Context, req *pb.DeleteMessageRequest) (*emptypb.Empty, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid message ID: %v", err)
+	}
+
+	err = s.messageService.DeleteMessage(ctx, id)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// PurgeMessage permanently removes a message. MethodPermissions restricts it
+// to holders of messages:purge.
+func (s *MessageServer) PurgeMessage(ctx context.Context, req *pb.PurgeMessageRequest) (*emptypb.Empty, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid message ID: %v", err)
+	}
+
+	if err := s.messageService.PurgeMessage(ctx, id); err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *MessageServer) ListMessages(ctx context.Context, req *pb.ListMessagesRequest) (*pb.ListMessagesResponse, error) {
+	pageSize, err := checkPageSize(req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := toFilter(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	order, err := parseOrderBy(req.OrderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Page != 0 {
+		if req.Page < 0 {
+			return nil, status.Error(codes.InvalidArgument, "page must not be negative")
+		}
+		if req.PageToken != "" {
+			return nil, status.Error(codes.InvalidArgument, "page and page_token are mutually exclusive")
+		}
+
+		messages, total, err := s.messageService.ListMessagesPaginated(ctx, filter, order, uint32(req.Page), pageSize)
+		if err != nil {
+			return nil, apperr.ToGRPC(err)
+		}
+
+		return &pb.ListMessagesResponse{
+			Messages: toResponses(messages),
+			Total:    int32(total),
+		}, nil
+	}
+
+	var after *service.Keyset
+	if req.PageToken != "" {
+		cursor, err := s.cursors.Decode(req.PageToken)
+		if err != nil || cursor.Backward || cursor.Order != order.Key() {
+			return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+		}
+		after = &service.Keyset{At: cursor.At, ID: cursor.ID}
+	}
+
+	page, err := s.messageService.ListMessagesKeyset(ctx, filter, order, pageSize, after, nil)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+
+	resp := &pb.ListMessagesResponse{
+		Messages: toResponses(page.Messages),
+	}
+	if page.Next != nil {
+		resp.NextPageToken = s.cursors.Encode(pagination.Cursor{
+			At:    page.Next.At,
+			ID:    page.Next.ID,
+			Order: order.Key(),
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *MessageServer) BatchCreateMessages(ctx context.Context, req *pb.BatchCreateMessagesRequest) (*pb.BatchMessagesResponse, error) {
+	if err := checkBatchSize(len(req.Requests)); err != nil {
+		return nil, err
+	}
+
+	results := make([]*pb.BatchResult, len(req.Requests))
+	var messages []*models.Message
+	var indexes []int
+	for i, item := range req.Requests {
+		message, err := toMessage(item)
+		if err != nil {
+			results[i] = batchError(err)
+			continue
+		}
+		messages = append(messages, message)
+		indexes = append(indexes, i)
+	}
+
+	if len(messages) == 0 || (req.Atomic && len(messages) < len(results)) {
+		return abortBatch(results), nil
+	}
+
+	errs, err := s.messageService.BatchCreateMessages(ctx, messages, req.Atomic)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+
+	for j, i := range indexes {
+		if errs[j] != nil {
+			results[i] = batchError(errs[j])
+			continue
+		}
+		results[i] = &pb.BatchResult{Code: int32(codes.OK), Message: toResponse(messages[j])}
+	}
+
+	return &pb.BatchMessagesResponse{Results: results}, nil
+}
+
+func (s *MessageServer) BatchUpdateMessages(ctx context.Context, req *pb.BatchUpdateMessagesRequest) (*pb.BatchMessagesResponse, error) {
+	if err := checkBatchSize(len(req.Requests)); err != nil {
+		return nil, err
+	}
+
+	results := make([]*pb.BatchResult, len(req.Requests))
+	var updates []*models.Message
+	var indexes []int
+	for i, item := range req.Requests {
+		update, err := toUpdate(item)
+		if err != nil {
+			results[i] = batchError(err)
+			continue
+		}
+		updates = append(updates, update)
+		indexes = append(indexes, i)
+	}
+
+	if len(updates) == 0 || (req.Atomic && len(updates) < len(results)) {
+		return abortBatch(results), nil
+	}
+
+	errs, err := s.messageService.BatchUpdateMessages(ctx, updates, req.Atomic)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+
+	for j, i := range indexes {
+		if errs[j] != nil {
+			results[i] = batchError(errs[j])
+			continue
+		}
+		results[i] = &pb.BatchResult{Code: int32(codes.OK), Message: toResponse(updates[j])}
+	}
+
+	return &pb.BatchMessagesResponse{Results: results}, nil
+}
+
+func (s *MessageServer) BatchDeleteMessages(ctx context.Context, req *pb.BatchDeleteMessagesRequest) (*pb.BatchMessagesResponse, error) {
+	if err := checkBatchSize(len(req.Ids)); err != nil {
+		return nil, err
+	}
+
+	results := make([]*pb.BatchResult, len(req.Ids))
+	var ids []uuid.UUID
+	var indexes []int
+	for i, param := range req.Ids {
+		id, err := uuid.Parse(param)
+		if err != nil {
+			results[i] = batchError(status.Errorf(codes.InvalidArgument, "invalid message ID: %v", err))
+			continue
+		}
+		ids = append(ids, id)
+		indexes = append(indexes, i)
+	}
+
+	if len(ids) == 0 || (req.Atomic && len(ids) < len(results)) {
+		return abortBatch(results), nil
+	}
+
+	errs, err := s.messageService.BatchDeleteMessages(ctx, ids, req.Atomic)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+
+	for j, i := range indexes {
+		if errs[j] != nil {
+			results[i] = batchError(errs[j])
+			continue
+		}
+		results[i] = &pb.BatchResult{Code: int32(codes.OK)}
+	}
+
+	return &pb.BatchMessagesResponse{Results: results}, nil
+}
+
+func (s *MessageServer) SearchMessages(ctx context.Context, req *pb.SearchMessagesRequest) (*pb.SearchMessagesResponse, error) {
+	if len(req.Query) > 256 {
+		return nil, status.Error(codes.InvalidArgument, "query must not exceed 256 characters")
+	}
+
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize, err := checkPageSize(req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	results, total, err := s.messageService.SearchMessages(ctx, req.Query, uint32(page), pageSize)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+
+	resp := &pb.SearchMessagesResponse{
+		Results: make([]*pb.SearchResult, len(results)),
+		Total:   int32(total),
+	}
+	for i, result := range results {
+		resp.Results[i] = &pb.SearchResult{
+			Message: toResponse(&result.Message),
+			Rank:    result.Rank,
+			Snippet: result.Snippet,
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *MessageServer) ListMessageRevisions(ctx context.Context, req *pb.ListMessageRevisionsRequest) (*pb.ListMessageRevisionsResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid message ID: %v", err)
+	}
+
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize, err := checkPageSize(req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, total, err := s.messageService.ListRevisions(ctx, id, uint32(page), pageSize)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+
+	resp := &pb.ListMessageRevisionsResponse{
+		Revisions: make([]*pb.MessageRevision, len(revisions)),
+		Total:     int32(total),
+	}
+	for i, revision := range revisions {
+		resp.Revisions[i] = toRevisionResponse(revision)
+	}
+
+	return resp, nil
+}
+
+func (s *MessageServer) GetMessageRevision(ctx context.Context, req *pb.GetMessageRevisionRequest) (*pb.MessageRevision, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid message ID: %v", err)
+	}
+	if req.Revision <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "revision must be positive")
+	}
+
+	revision, err := s.messageService.GetRevision(ctx, id, req.Revision)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+
+	return toRevisionResponse(revision), nil
+}
+
+func (s *MessageServer) RestoreMessageRevision(ctx context.Context, req *pb.RestoreMessageRevisionRequest) (*pb.MessageResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid message ID: %v", err)
+	}
+	if req.Revision <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "revision must be positive")
+	}
+	if req.ExpectedVersion < 0 {
+		return nil, status.Error(codes.InvalidArgument, "expected_version must not be negative")
+	}
+
+	message, err := s.messageService.RestoreRevision(ctx, id, req.Revision, req.ExpectedVersion)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+
+	return toResponse(message), nil
+}
+
+func (s *MessageServer) ListMessageReplies(ctx context.Context, req *pb.ListMessageRepliesRequest) (*pb.ListMessagesResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid message ID: %v", err)
 	}
 
-	if message == nil {
-		return nil, status.Error(codes.NotFound, "message not found")
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize, err := checkPageSize(req.PageSize)
+	if err != nil {
+		return nil, err
 	}
 
-	return &pb.MessageResponse{
-		Id:        message.ID.String(),
-		Content:   message.Content,
-		CreatedAt: timestamppb.New(message.CreatedAt),
+	replies, total, err := s.messageService.ListReplies(ctx, id, uint32(page), pageSize)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+
+	return &pb.ListMessagesResponse{
+		Messages: toResponses(replies),
+		Total:    int32(total),
 	}, nil
 }
 
-func (s *MessageServer) StreamMessages(empty *emptypb.Empty, stream pb.MessageService_StreamMessagesServer) error {
-	messages, err := s.messageService.ListMessages(stream.Context())
+func (s *MessageServer) GetThread(ctx context.Context, req *pb.GetThreadRequest) (*pb.Thread, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid thread ID: %v", err)
+	}
+	if req.Depth < 0 || req.Depth > service.MaxThreadDepth {
+		return nil, status.Errorf(codes.InvalidArgument, "depth must be 0 to %d", service.MaxThreadDepth)
+	}
+
+	thread, err := s.messageService.GetThread(ctx, id, int(req.Depth))
 	if err != nil {
-		return status.Errorf(codes.Internal, "failed to list messages: %v", err)
+		return nil, apperr.ToGRPC(err)
 	}
 
-	for _, msg := range messages {
-		if err := stream.Send(&pb.MessageResponse{
-			Id:        msg.ID.String(),
-			Content:   msg.Content,
-			CreatedAt: timestamppb.New(msg.CreatedAt),
-		}); err != nil {
-			return status.Errorf(codes.Internal, "failed to send message: %v", err)
+	return &pb.Thread{
+		Root:      toThreadNode(thread.Root),
+		Truncated: thread.Truncated,
+	}, nil
+}
+
+func (s *MessageServer) StreamMessages(req *pb.StreamMessagesRequest, srv pb.MessageService_StreamMessagesServer) error {
+	ctx := srv.Context()
+	filter, err := toStreamFilter(ctx, req)
+	if err != nil {
+		return err
+	}
+	var position stream.Position
+	switch {
+	case req.ResumeToken != "" && req.Since != nil:
+		return status.Error(codes.InvalidArgument, "resume_token and since are mutually exclusive")
+	case req.ResumeToken != "":
+		position.AfterID = req.ResumeToken
+	case req.Since != nil:
+		if err := req.Since.CheckValid(); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid since: %v", err)
 		}
+		position.Since = req.Since.AsTime()
 	}
 
+	subscription, err := s.broadcaster.Subscribe(filter, position)
+	if err != nil {
+		return streamStatus(err)
+	}
+	defer subscription.Close()
+
+	// Heartbeats carry the last cursor, so that an idle subscriber can
+	// resume from it too
+	cursor := req.ResumeToken
+	heartbeat := time.NewTicker(s.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		var event *pb.MessageEvent
+		select {
+		case change := <-subscription.Changes():
+			cursor = change.ID
+			event = &pb.MessageEvent{
+				Type:    eventTypes[change.Kind],
+				Message: toResponse(&change.Message),
+				Cursor:  change.ID,
+				Time:    timestamppb.New(change.Time),
+			}
+		case <-heartbeat.C:
+			event = &pb.MessageEvent{
+				Type:   pb.MessageEventType_MESSAGE_EVENT_TYPE_HEARTBEAT,
+				Cursor: cursor,
+				Time:   timestamppb.Now(),
+			}
+		case <-subscription.Done():
+			return streamStatus(subscription.Err())
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+
+		if err := srv.Send(event); err != nil {
+			return err
+		}
+		heartbeat.Reset(s.heartbeat)
+	}
+}
+
+// toStreamFilter converts the filter fields of a StreamMessagesRequest; the
+// subscription is limited to the tenant and caller in ctx
+func toStreamFilter(ctx context.Context, req *pb.StreamMessagesRequest) (stream.Filter, error) {
+	if len(req.Labels) > 10 {
+		return stream.Filter{}, status.Error(codes.InvalidArgument, "labels must not hold more than 10 items")
+	}
+
+	filter := stream.Filter{
+		TenantID: tenant.FromContext(ctx),
+		Labels:   req.Labels,
+	}
+	claims, ok := auth.FromContext(ctx)
+	if ok {
+		filter.CallerID = claims.UserID
+	}
+	if req.Mine {
+		if filter.CallerID == "" {
+			return stream.Filter{}, status.Error(codes.Unauthenticated, "mine requires an authenticated caller")
+		}
+		filter.AuthorID = filter.CallerID
+	}
+	for _, t := range req.Types {
+		kind, ok := eventKind(t)
+		if !ok {
+			return stream.Filter{}, status.Errorf(codes.InvalidArgument, "invalid event type %v", t)
+		}
+		filter.Kinds = append(filter.Kinds, kind)
+	}
+	if req.ThreadId != "" {
+		id, err := uuid.Parse(req.ThreadId)
+		if err != nil {
+			return stream.Filter{}, status.Errorf(codes.InvalidArgument, "invalid thread ID: %v", err)
+		}
+		filter.ThreadID = id
+	}
+
+	return filter, nil
+}
+
+// eventKind returns the kind of change of an event type, if it has one
+func eventKind(t pb.MessageEventType) (stream.Kind, bool) {
+	for kind, eventType := range eventTypes {
+		if eventType == t {
+			return kind, true
+		}
+	}
+	return "", false
+}
+
+// streamStatus converts the errors a stream subscription ends with to a
+// status; a subscription closed by the subscriber ends cleanly
+func streamStatus(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, stream.ErrResumeExpired):
+		return status.Error(codes.OutOfRange, "resume point is no longer available, list the messages again")
+	case errors.Is(err, stream.ErrSlowConsumer):
+		return status.Error(codes.ResourceExhausted, "subscriber fell behind, resume from the last cursor")
+	case errors.Is(err, stream.ErrClosed):
+		return status.Error(codes.Unavailable, "server is shutting down, resume from the last cursor")
+	default:
+		return apperr.ToGRPC(err)
+	}
+}
+
+// toFilter converts the filter fields of a ListMessagesRequest; mine
+// resolves to the caller in ctx
+func toFilter(ctx context.Context, req *pb.ListMessagesRequest) (service.MessageFilter, error) {
+	if len(req.Ids) > 100 {
+		return service.MessageFilter{}, status.Error(codes.InvalidArgument, "ids must not hold more than 100 IDs")
+	}
+	if len(req.ContentPrefix) > 1000 {
+		return service.MessageFilter{}, status.Error(codes.InvalidArgument, "content_prefix must not exceed 1000 characters")
+	}
+	if len(req.Labels) > 10 || len(req.MetadataKeys) > 10 {
+		return service.MessageFilter{}, status.Error(codes.InvalidArgument, "labels and metadata_keys must not hold more than 10 items each")
+	}
+
+	filter := service.MessageFilter{
+		ContentPrefix: req.ContentPrefix,
+		Labels:        req.Labels,
+		MetadataKeys:  req.MetadataKeys,
+	}
+	if req.Status != pb.MessageStatus_MESSAGE_STATUS_UNSPECIFIED {
+		s, ok := statuses[req.Status]
+		if !ok {
+			return service.MessageFilter{}, status.Errorf(codes.InvalidArgument, "invalid status %v", req.Status)
+		}
+		filter.Status = s
+	}
+	if req.Mine {
+		claims, ok := auth.FromContext(ctx)
+		if !ok || claims.UserID == "" {
+			return service.MessageFilter{}, status.Error(codes.Unauthenticated, "mine requires an authenticated caller")
+		}
+		filter.AuthorID = claims.UserID
+	}
+	for _, ts := range []struct {
+		name  string
+		value *timestamppb.Timestamp
+		dst   *time.Time
+	}{
+		{"created_after", req.CreatedAfter, &filter.CreatedAfter},
+		{"created_before", req.CreatedBefore, &filter.CreatedBefore},
+		{"updated_after", req.UpdatedAfter, &filter.UpdatedAfter},
+		{"updated_before", req.UpdatedBefore, &filter.UpdatedBefore},
+	} {
+		if ts.value == nil {
+			continue
+		}
+		if err := ts.value.CheckValid(); err != nil {
+			return service.MessageFilter{}, status.Errorf(codes.InvalidArgument, "invalid %s: %v", ts.name, err)
+		}
+		*ts.dst = ts.value.AsTime()
+	}
+
+	for _, param := range req.Ids {
+		id, err := uuid.Parse(param)
+		if err != nil {
+			return service.MessageFilter{}, status.Errorf(codes.InvalidArgument, "invalid message ID %q", param)
+		}
+		filter.IDs = append(filter.IDs, id)
+	}
+
+	return filter, nil
+}
+
+// parseOrderBy parses an AIP-132 order_by of a single field
+func parseOrderBy(orderBy string) (service.Sort, error) {
+	parts := strings.Fields(orderBy)
+	if len(parts) == 0 {
+		return service.Sort{}, nil
+	}
+	if len(parts) > 2 {
+		return service.Sort{}, status.Error(codes.InvalidArgument, "order_by supports a single field")
+	}
+
+	field, ok := service.ParseSortField(parts[0])
+	if !ok {
+		return service.Sort{}, status.Errorf(codes.InvalidArgument, "cannot order by %q", parts[0])
+	}
+
+	// Fields sort ascending unless marked desc
+	order := service.Sort{Field: field, Ascending: true}
+	if len(parts) == 2 {
+		switch strings.ToLower(parts[1]) {
+		case "asc":
+		case "desc":
+			order.Ascending = false
+		default:
+			return service.Sort{}, status.Errorf(codes.InvalidArgument, "invalid order_by direction %q", parts[1])
+		}
+	}
+
+	return order, nil
+}
+
+// toMessage validates a CreateMessageRequest and converts it to the message
+// to create
+func toMessage(req *pb.CreateMessageRequest) (*models.Message, error) {
+	message := &models.Message{
+		Content:  req.Content,
+		Labels:   req.Labels,
+		Metadata: fromStruct(req.Metadata),
+	}
+	if req.ParentId != "" {
+		parentID, err := uuid.Parse(req.ParentId)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid parent_id: %v", err)
+		}
+		message.ParentID = &parentID
+	}
+
+	expiresAt, err := expiry(req.ExpiresAt, req.Ttl)
+	if err != nil {
+		return nil, err
+	}
+	if !expiresAt.IsZero() {
+		message.ExpiresAt = &expiresAt
+	}
+
+	message.Status, message.PublishAt, err = publication(req.Status, req.PublishAt)
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// publication returns the message status of a status enum, empty when
+// unspecified, and the publish time of a publish_at, nil when unset
+func publication(s pb.MessageStatus, publishAt *timestamppb.Timestamp) (string, *time.Time, error) {
+	var messageStatus string
+	if s != pb.MessageStatus_MESSAGE_STATUS_UNSPECIFIED {
+		var ok bool
+		if messageStatus, ok = statuses[s]; !ok {
+			return "", nil, status.Errorf(codes.InvalidArgument, "invalid status %v", s)
+		}
+	}
+	if publishAt == nil {
+		return messageStatus, nil, nil
+	}
+	if err := publishAt.CheckValid(); err != nil {
+		return "", nil, status.Errorf(codes.InvalidArgument, "invalid publish_at: %v", err)
+	}
+	at := publishAt.AsTime()
+	return messageStatus, &at, nil
+}
+
+// expiry returns the expiry set by either an expires_at or a ttl, the zero
+// time for neither
+func expiry(expiresAt *timestamppb.Timestamp, ttl *durationpb.Duration) (time.Time, error) {
+	switch {
+	case expiresAt != nil && ttl != nil:
+		return time.Time{}, status.Error(codes.InvalidArgument, "expires_at and ttl are mutually exclusive")
+	case expiresAt != nil:
+		if err := expiresAt.CheckValid(); err != nil {
+			return time.Time{}, status.Errorf(codes.InvalidArgument, "invalid expires_at: %v", err)
+		}
+		return expiresAt.AsTime(), nil
+	case ttl != nil:
+		if err := ttl.CheckValid(); err != nil || ttl.AsDuration() <= 0 {
+			return time.Time{}, status.Error(codes.InvalidArgument, "ttl must be positive")
+		}
+		return time.Now().Add(ttl.AsDuration()), nil
+	}
+	return time.Time{}, nil
+}
+
+// Page sizes of the listing RPCs, as over REST.
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
+)
+
+// checkPageSize returns the page size a listing RPC asks for, the default
+// when it is unset. Sizes out of range are rejected rather than capped.
+func checkPageSize(pageSize int32) (uint32, error) {
+	if pageSize == 0 {
+		return defaultPageSize, nil
+	}
+	if pageSize < 0 || pageSize > maxPageSize {
+		return 0, status.Errorf(codes.InvalidArgument, "page_size must be 1 to %d", maxPageSize)
+	}
+	return uint32(pageSize), nil
+}
+
+// checkBatchSize rejects empty and oversized batches
+func checkBatchSize(n int) error {
+	if n == 0 || n > service.MaxBatchSize {
+		return status.Errorf(codes.InvalidArgument, "a batch must hold 1 to %d items", service.MaxBatchSize)
+	}
 	return nil
 }
+
+// toUpdate validates one item of a batch update like UpdateMessage does and
+// converts it to the update to apply
+func toUpdate(req *pb.UpdateMessageRequest) (*models.Message, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid message ID: %v", err)
+	}
+	if req.ExpectedVersion < 0 {
+		return nil, status.Error(codes.InvalidArgument, "expected_version must not be negative")
+	}
+	if _, err := expiry(req.ExpiresAt, req.Ttl); err != nil {
+		return nil, err
+	}
+	if _, _, err := publication(req.Status, req.PublishAt); err != nil {
+		return nil, err
+	}
+
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		paths = updatablePaths
+	}
+	update := &models.Message{ID: id, Version: req.ExpectedVersion}
+	for _, path := range paths {
+		apply, ok := updateFields[path]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "update_mask: field %q cannot be updated", path)
+		}
+		apply(update, req)
+	}
+
+	return update, nil
+}
+
+// batchError is the result of a batch item that failed with err
+func batchError(err error) *pb.BatchResult {
+	st := apperr.ToStatus(err)
+	return &pb.BatchResult{Code: int32(st.Code()), Error: st.Message()}
+}
+
+// abortBatch marks the items that have no result yet as aborted
+func abortBatch(results []*pb.BatchResult) *pb.BatchMessagesResponse {
+	for i, result := range results {
+		if result == nil {
+			results[i] = batchError(service.ErrBatchAborted)
+		}
+	}
+	return &pb.BatchMessagesResponse{Results: results}
+}
+
+func toResponse(message *models.Message) *pb.MessageResponse {
+	resp := &pb.MessageResponse{
+		Id:         message.ID.String(),
+		Content:    message.Content,
+		CreatedAt:  timestamppb.New(message.CreatedAt),
+		UpdatedAt:  timestamppb.New(message.UpdatedAt),
+		Version:    message.Version,
+		AuthorId:   message.AuthorID,
+		Labels:     message.Labels,
+		Metadata:   toStruct(message.Metadata),
+		ThreadId:   message.ThreadID.String(),
+		ReplyCount: message.ReplyCount,
+	}
+	if message.ParentID != nil {
+		resp.ParentId = message.ParentID.String()
+	}
+	if message.ExpiresAt != nil {
+		resp.ExpiresAt = timestamppb.New(*message.ExpiresAt)
+	}
+	for enum, s := range statuses {
+		if s == message.Status {
+			resp.Status = enum
+		}
+	}
+	if message.PublishAt != nil {
+		resp.PublishAt = timestamppb.New(*message.PublishAt)
+	}
+	return resp
+}
+
+func toThreadNode(node *models.ThreadNode) *pb.ThreadNode {
+	converted := &pb.ThreadNode{
+		Message:   toResponse(&node.Message),
+		Tombstone: node.Tombstone,
+		Replies:   make([]*pb.ThreadNode, len(node.Replies)),
+	}
+	for i, reply := range node.Replies {
+		converted.Replies[i] = toThreadNode(reply)
+	}
+	return converted
+}
+
+// fromStruct converts request metadata to its JSON form; unset or empty
+// metadata is nil
+func fromStruct(metadata *structpb.Struct) map[string]interface{} {
+	if len(metadata.GetFields()) == 0 {
+		return nil
+	}
+	return metadata.AsMap()
+}
+
+// toStruct converts stored metadata, which is always valid JSON, to a Struct
+func toStruct(metadata map[string]interface{}) *structpb.Struct {
+	if len(metadata) == 0 {
+		return nil
+	}
+	converted, err := structpb.NewStruct(metadata)
+	if err != nil {
+		return nil
+	}
+	return converted
+}
+
+func toResponses(messages []*models.Message) []*pb.MessageResponse {
+	responses := make([]*pb.MessageResponse, len(messages))
+	for i, message := range messages {
+		responses[i] = toResponse(message)
+	}
+	return responses
+}
+
+func toRevisionResponse(revision *models.MessageRevision) *pb.MessageRevision {
+	return &pb.MessageRevision{
+		MessageId: revision.MessageID.String(),
+		Revision:  revision.Revision,
+		Content:   revision.Content,
+		Editor:    revision.Editor,
+		CreatedAt: timestamppb.New(revision.CreatedAt),
+	}
+}
@@ -0,0 +1,356 @@
+package grpc
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-boilerplate/config"
+	"go-boilerplate/internal/auth"
+	"go-boilerplate/internal/cache"
+	"go-boilerplate/internal/events"
+	"go-boilerplate/internal/idempotency"
+	"go-boilerplate/internal/models"
+	"go-boilerplate/internal/pagination"
+	"go-boilerplate/internal/service"
+	"go-boilerplate/internal/stream"
+	pb "go-boilerplate/proto/message/v1"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestServer() *MessageServer {
+	messageService := service.NewMessageService(service.NewMemoryStore(), cache.NewMemoryCache(), service.Options{})
+	keeper := idempotency.NewKeeper(idempotency.NewMemoryStore(), config.IdempotencyConfig{TTL: time.Hour, LockTimeout: time.Minute}, zap.NewNop())
+	broadcaster := stream.NewBroadcaster(config.StreamConfig{BufferSize: 16, HistorySize: 100, HeartbeatInterval: time.Minute}, stream.NewMetrics(prometheus.NewRegistry()))
+	return NewMessageServer(messageService, pagination.NewCodec([]byte("test")), keeper, broadcaster, time.Minute)
+}
+
+func TestUpdateMessage(t *testing.T) {
+	server := newTestServer()
+	ctx := context.Background()
+
+	created, err := server.CreateMessage(ctx, &pb.CreateMessageRequest{Content: "original", Labels: []string{"keep"}})
+	require.NoError(t, err)
+
+	// Fields outside the mask keep their value
+	updated, err := server.UpdateMessage(ctx, &pb.UpdateMessageRequest{
+		Id:         created.Id,
+		Content:    "edited",
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"content"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "edited", updated.Content)
+	assert.Equal(t, []string{"keep"}, updated.Labels)
+	assert.Equal(t, int64(2), updated.Version)
+	assert.False(t, updated.UpdatedAt.AsTime().Before(created.UpdatedAt.AsTime()))
+
+	_, err = server.UpdateMessage(ctx, &pb.UpdateMessageRequest{Id: created.Id, Content: "stale", ExpectedVersion: 1})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = server.UpdateMessage(ctx, &pb.UpdateMessageRequest{Id: "00000000-0000-0000-0000-000000000001", Content: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = server.UpdateMessage(ctx, &pb.UpdateMessageRequest{Id: created.Id, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"id"}}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestMessageContentValidation(t *testing.T) {
+	server := newTestServer()
+	ctx := context.Background()
+
+	// Content is held to the same limits as over HTTP
+	for _, content := range []string{"", strings.Repeat("a", service.MaxContentLength+1)} {
+		_, err := server.CreateMessage(ctx, &pb.CreateMessageRequest{Content: content})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	created, err := server.CreateMessage(ctx, &pb.CreateMessageRequest{Content: strings.Repeat("é", service.MaxContentLength)})
+	require.NoError(t, err)
+
+	_, err = server.UpdateMessage(ctx, &pb.UpdateMessageRequest{Id: created.Id, Content: ""})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestErrorDetails(t *testing.T) {
+	server := newTestServer()
+	ctx := context.Background()
+
+	// Failures carry the code of the error and the fields at fault
+	_, err := server.CreateMessage(ctx, &pb.CreateMessageRequest{Content: ""})
+	st := status.Convert(err)
+	require.Equal(t, codes.InvalidArgument, st.Code())
+	require.Len(t, st.Details(), 2)
+	assert.Equal(t, "INVALID_CONTENT", st.Details()[0].(*errdetails.ErrorInfo).Reason)
+	violations := st.Details()[1].(*errdetails.BadRequest).FieldViolations
+	require.Len(t, violations, 1)
+	assert.Equal(t, "content", violations[0].Field)
+
+	_, err = server.GetMessage(ctx, &pb.GetMessageRequest{Id: uuid.NewString()})
+	st = status.Convert(err)
+	require.Equal(t, codes.NotFound, st.Code())
+	assert.Equal(t, "message not found", st.Message())
+	require.Len(t, st.Details(), 1)
+	assert.Equal(t, "MESSAGE_NOT_FOUND", st.Details()[0].(*errdetails.ErrorInfo).Reason)
+}
+
+func TestDeleteMessage(t *testing.T) {
+	server := newTestServer()
+	ctx := context.Background()
+
+	created, err := server.CreateMessage(ctx, &pb.CreateMessageRequest{Content: "to delete"})
+	require.NoError(t, err)
+
+	_, err = server.DeleteMessage(ctx, &pb.DeleteMessageRequest{Id: created.Id})
+	require.NoError(t, err)
+	_, err = server.GetMessage(ctx, &pb.GetMessageRequest{Id: created.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// Deleting is idempotent, as over HTTP
+	_, err = server.DeleteMessage(ctx, &pb.DeleteMessageRequest{Id: created.Id})
+	assert.NoError(t, err)
+
+	_, err = server.DeleteMessage(ctx, &pb.DeleteMessageRequest{Id: "not-a-uuid"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestPurgeMessage(t *testing.T) {
+	server := newTestServer()
+	created, err := server.CreateMessage(context.Background(), &pb.CreateMessageRequest{Content: "to purge"})
+	require.NoError(t, err)
+
+	// Call the server as registered, behind the RBAC interceptor the
+	// server is wired with
+	var purge grpc.MethodDesc
+	for _, method := range pb.MessageService_ServiceDesc.Methods {
+		if method.MethodName == "PurgeMessage" {
+			purge = method
+		}
+	}
+	require.NotNil(t, purge.Handler)
+	call := func(claims *auth.Claims) error {
+		ctx := auth.NewContext(context.Background(), claims)
+		decode := func(req interface{}) error {
+			req.(*pb.PurgeMessageRequest).Id = created.Id
+			return nil
+		}
+		_, err := purge.Handler(server, ctx, decode, RBACUnaryInterceptor(MethodPermissions))
+		return err
+	}
+
+	err = call(&auth.Claims{UserID: "moderator", Permissions: []string{service.PermissionModerate}})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = server.GetMessage(context.Background(), &pb.GetMessageRequest{Id: created.Id})
+	require.NoError(t, err, "a denied purge leaves the message")
+
+	require.NoError(t, call(&auth.Claims{UserID: "admin", Permissions: []string{"messages:purge"}}))
+	_, err = server.GetMessage(context.Background(), &pb.GetMessageRequest{Id: created.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, codes.NotFound, status.Code(call(&auth.Claims{UserID: "admin", Permissions: []string{"messages:purge"}})))
+}
+
+func TestBatchCreateMessages(t *testing.T) {
+	server := newTestServer()
+	ctx := context.Background()
+
+	codesOf := func(resp *pb.BatchMessagesResponse) []codes.Code {
+		result := make([]codes.Code, len(resp.Results))
+		for i, item := range resp.Results {
+			result[i] = codes.Code(item.Code)
+		}
+		return result
+	}
+
+	// Each failed item is reported at its index without failing the others
+	resp, err := server.BatchCreateMessages(ctx, &pb.BatchCreateMessagesRequest{
+		Requests: []*pb.CreateMessageRequest{
+			{Content: "first"},
+			{Content: ""},
+			{Content: "orphan", ParentId: uuid.NewString()},
+			{Content: "orphan", ParentId: "not-a-uuid"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []codes.Code{codes.OK, codes.InvalidArgument, codes.InvalidArgument, codes.InvalidArgument}, codesOf(resp))
+	assert.Equal(t, "first", resp.Results[0].Message.Content)
+	assert.Contains(t, resp.Results[1].Error, "invalid content")
+	assert.Contains(t, resp.Results[2].Error, "parent message not found")
+
+	resp, err = server.BatchCreateMessages(ctx, &pb.BatchCreateMessagesRequest{
+		Requests: []*pb.CreateMessageRequest{{Content: "second"}, {Content: ""}},
+		Atomic:   true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []codes.Code{codes.Aborted, codes.InvalidArgument}, codesOf(resp))
+
+	list, err := server.ListMessages(ctx, &pb.ListMessagesRequest{})
+	require.NoError(t, err)
+	assert.Len(t, list.Messages, 1)
+}
+
+func TestListMessages(t *testing.T) {
+	server := newTestServer()
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		_, err := server.CreateMessage(ctx, &pb.CreateMessageRequest{Content: "message"})
+		require.NoError(t, err)
+	}
+
+	// Offset paging reports the total
+	resp, err := server.ListMessages(ctx, &pb.ListMessagesRequest{Page: 2, PageSize: 2})
+	require.NoError(t, err)
+	assert.Len(t, resp.Messages, 2)
+	assert.Equal(t, int32(5), resp.Total)
+	assert.Empty(t, resp.NextPageToken)
+
+	// Token paging visits every message once
+	seen := make(map[string]bool)
+	req := &pb.ListMessagesRequest{PageSize: 2}
+	for {
+		resp, err := server.ListMessages(ctx, req)
+		require.NoError(t, err)
+		for _, message := range resp.Messages {
+			assert.False(t, seen[message.Id])
+			seen[message.Id] = true
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		req.PageToken = resp.NextPageToken
+	}
+	assert.Len(t, seen, 5)
+
+	_, err = server.ListMessages(ctx, &pb.ListMessagesRequest{PageSize: 2, PageToken: req.PageToken, OrderBy: "updated_at"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "tokens are tied to their order")
+
+	_, err = server.ListMessages(ctx, &pb.ListMessagesRequest{Page: 1, PageToken: req.PageToken})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestPageSize(t *testing.T) {
+	server := newTestServer()
+	ctx := context.Background()
+
+	created, err := server.CreateMessage(ctx, &pb.CreateMessageRequest{Content: "paged message"})
+	require.NoError(t, err)
+
+	// Every listing RPC holds page_size to the same range
+	list := map[string]func(pageSize int32) error{
+		"ListMessages": func(pageSize int32) error {
+			_, err := server.ListMessages(ctx, &pb.ListMessagesRequest{PageSize: pageSize})
+			return err
+		},
+		"SearchMessages": func(pageSize int32) error {
+			_, err := server.SearchMessages(ctx, &pb.SearchMessagesRequest{Query: "paged", PageSize: pageSize})
+			return err
+		},
+		"ListMessageRevisions": func(pageSize int32) error {
+			_, err := server.ListMessageRevisions(ctx, &pb.ListMessageRevisionsRequest{Id: created.Id, PageSize: pageSize})
+			return err
+		},
+		"ListMessageReplies": func(pageSize int32) error {
+			_, err := server.ListMessageReplies(ctx, &pb.ListMessageRepliesRequest{Id: created.Id, PageSize: pageSize})
+			return err
+		},
+	}
+	for name, call := range list {
+		for pageSize, want := range map[int32]codes.Code{
+			0:   codes.OK,
+			100: codes.OK,
+			101: codes.InvalidArgument,
+			-1:  codes.InvalidArgument,
+		} {
+			assert.Equal(t, want, status.Code(call(pageSize)), "%s with page_size %d", name, pageSize)
+		}
+	}
+}
+
+// eventStream is the server side of a StreamMessages call, collecting the
+// events sent
+type eventStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events chan *pb.MessageEvent
+}
+
+func (s *eventStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *eventStream) Send(event *pb.MessageEvent) error {
+	s.events <- event
+	return nil
+}
+
+func TestStreamMessages(t *testing.T) {
+	server := newTestServer()
+	server.heartbeat = 20 * time.Millisecond
+
+	publish := func(id string, message models.Message) {
+		server.broadcaster.Publish(&events.MessageCreated{
+			Envelope:           events.Envelope{ID: id, Time: time.Now()},
+			MessageCreatedData: events.MessageCreatedData{Message: message},
+		})
+	}
+	publish("1", models.Message{ID: uuid.New(), Content: "before", Status: models.StatusPublished})
+	publish("2", models.Message{ID: uuid.New(), Content: "missed", Status: models.StatusPublished})
+	publish("3", models.Message{ID: uuid.New(), Content: "other label", Status: models.StatusPublished, Labels: []string{"other"}})
+
+	srv := &eventStream{ctx: context.Background(), events: make(chan *pb.MessageEvent, 10)}
+	done := make(chan error, 1)
+	go func() {
+		done <- server.StreamMessages(&pb.StreamMessagesRequest{ResumeToken: "1"}, srv)
+	}()
+
+	// The changes missed since the cursor come first
+	event := <-srv.events
+	assert.Equal(t, pb.MessageEventType_MESSAGE_EVENT_TYPE_CREATED, event.Type)
+	assert.Equal(t, "missed", event.Message.Content)
+	assert.Equal(t, "2", event.Cursor)
+	assert.Equal(t, "3", (<-srv.events).Cursor)
+
+	// An idle stream gets heartbeats carrying the last cursor
+	event = <-srv.events
+	assert.Equal(t, pb.MessageEventType_MESSAGE_EVENT_TYPE_HEARTBEAT, event.Type)
+	assert.Equal(t, "3", event.Cursor)
+	assert.Nil(t, event.Message)
+
+	server.broadcaster.Close()
+	assert.Equal(t, codes.Unavailable, status.Code(<-done))
+}
+
+func TestStreamMessagesRequest(t *testing.T) {
+	server := newTestServer()
+	srv := &eventStream{ctx: context.Background(), events: make(chan *pb.MessageEvent, 10)}
+
+	tests := []struct {
+		name string
+		req  *pb.StreamMessagesRequest
+		code codes.Code
+	}{
+		{"expired cursor", &pb.StreamMessagesRequest{ResumeToken: "unknown"}, codes.OutOfRange},
+		{"expired time", &pb.StreamMessagesRequest{Since: timestamppb.New(time.Now().Add(-time.Hour))}, codes.OutOfRange},
+		{"cursor and time", &pb.StreamMessagesRequest{ResumeToken: "1", Since: timestamppb.Now()}, codes.InvalidArgument},
+		{"anonymous mine", &pb.StreamMessagesRequest{Mine: true}, codes.Unauthenticated},
+		{"thread", &pb.StreamMessagesRequest{ThreadId: "not-a-uuid"}, codes.InvalidArgument},
+		{"heartbeat type", &pb.StreamMessagesRequest{Types: []pb.MessageEventType{pb.MessageEventType_MESSAGE_EVENT_TYPE_HEARTBEAT}}, codes.InvalidArgument},
+		{"labels", &pb.StreamMessagesRequest{Labels: strings.Split("a,b,c,d,e,f,g,h,i,j,k", ",")}, codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := server.StreamMessages(tt.req, srv)
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package http
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go-boilerplate/internal/service"
+)
+
+// multipartOverhead is the room left in an upload request for the multipart
+// framing and headers around the file itself.
+const multipartOverhead = 64 << 10
+
+// UploadAttachment godoc
+// @Summary Attach a file to a message
+// @Description Upload a file as multipart/form-data in the "file" field. The content type is detected from the content and must be one of the allowed types.
+// @Tags messages
+// @Accept mpfd
+// @Produce json
+// @Param id path string true "Message ID"
+// @Param file formData file true "File to attach"
+// @Success 201 {object} models.Attachment
+// @Failure 400 {object} apperr.Problem
+// @Failure 403 {object} apperr.Problem
+// @Failure 404 {object} apperr.Problem
+// @Failure 413 {object} apperr.Problem
+// @Failure 415 {object} apperr.Problem
+// @Failure 422 {object} apperr.Problem
+// @Router /api/v1/messages/{id}/attachments [post]
+func (h *MessageHandler) UploadAttachment(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid UUID format")
+	}
+
+	// Refuse oversized requests before they are spooled to disk
+	if limit := h.messageService.MaxAttachmentSize(); limit > 0 {
+		req := c.Request()
+		req.Body = http.MaxBytesReader(c.Response(), req.Body, limit+multipartOverhead)
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "attachment too large")
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, "a file must be uploaded in the \"file\" form field")
+	}
+
+	content, err := file.Open()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	defer content.Close()
+
+	attachment, err := h.messageService.AddAttachment(c.Request().Context(), id, service.Upload{
+		Filename: file.Filename,
+		Size:     file.Size,
+		Content:  content,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, attachment)
+}
+
+// ListAttachments godoc
+// @Summary List the attachments of a message
+// @Description Get the files attached to a message, oldest first
+// @Tags messages
+// @Produce json
+// @Param id path string true "Message ID"
+// @Success 200 {array} models.Attachment
+// @Failure 404 {object} apperr.Problem
+// @Router /api/v1/messages/{id}/attachments [get]
+func (h *MessageHandler) ListAttachments(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid UUID format")
+	}
+
+	attachments, err := h.messageService.ListAttachments(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"attachments": attachments,
+	})
+}
+
+// DownloadAttachment godoc
+// @Summary Download an attachment
+// @Description Get the content of a file attached to a message. Range requests are supported.
+// @Tags messages
+// @Produce octet-stream
+// @Param id path string true "Message ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 200 {file} binary
+// @Success 206 {file} binary
+// @Failure 404 {object} apperr.Problem
+// @Failure 416 {object} apperr.Problem
+// @Router /api/v1/messages/{id}/attachments/{attachmentId} [get]
+func (h *MessageHandler) DownloadAttachment(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid UUID format")
+	}
+	attachmentID, err := uuid.Parse(c.Param("attachmentId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid UUID format")
+	}
+
+	attachment, content, err := h.messageService.OpenAttachment(c.Request().Context(), id, attachmentID)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, attachment.ContentType)
+	header.Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{
+		"filename": attachment.Filename,
+	}))
+	header.Set("ETag", `"`+attachment.Checksum+`"`)
+	header.Set(echo.HeaderXContentTypeOptions, "nosniff")
+
+	// ServeContent answers Range and conditional requests
+	http.ServeContent(c.Response(), c.Request(), "", attachment.CreatedAt, content)
+	return nil
+}
@@ -0,0 +1,260 @@
+package http
+
+import (
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go-boilerplate/internal/apperr"
+	"go-boilerplate/internal/middleware"
+	"go-boilerplate/internal/models"
+	"go-boilerplate/internal/service"
+	"net/http"
+)
+
+// BatchCreateMessagesRequest creates several messages at once. With Atomic
+// set any failed item rolls back the whole batch.
+type BatchCreateMessagesRequest struct {
+	Messages []CreateMessageRequest `json:"messages" validate:"required,min=1,max=1000"`
+	Atomic   bool                   `json:"atomic"`
+}
+
+// BatchUpdateMessagesRequest replaces the content of several messages at
+// once. With Atomic set any failed item rolls back the whole batch.
+type BatchUpdateMessagesRequest struct {
+	Messages []BatchUpdateMessage `json:"messages" validate:"required,min=1,max=1000"`
+	Atomic   bool                 `json:"atomic"`
+}
+
+// BatchUpdateMessage is one item of a batch update. A non-zero Version is
+// the version the message is expected to have, like If-Match.
+type BatchUpdateMessage struct {
+	ID      string `json:"id" validate:"required,uuid"`
+	Content string `json:"content" validate:"required"`
+	Version int64  `json:"version" validate:"gte=0"`
+}
+
+// BatchDeleteMessagesRequest moves several messages to the trash at once.
+// With Atomic set any missing message rolls back the whole batch.
+type BatchDeleteMessagesRequest struct {
+	IDs    []string `json:"ids" validate:"required,min=1,max=1000"`
+	Atomic bool     `json:"atomic"`
+}
+
+// BatchResult is the outcome of one item of a batch, at the same index as
+// the item. Status is the HTTP status the item would have had as a request
+// of its own; items not applied because another item of an atomic batch
+// failed have status 424 and code BATCH_ABORTED.
+type BatchResult struct {
+	Status  int             `json:"status"`
+	Message *models.Message `json:"message,omitempty"`
+	// Code and Error are the code and detail of the problem of a failed
+	// item.
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// BatchCreateMessages godoc
+// @Summary Create several messages
+// @Description Create up to 1000 messages in one transaction. Every item
+// @Description gets a result at its index; failed items, such as invalid
+// @Description or rejected messages and replies to missing messages, do not
+// @Description stop the others unless atomic is set, in which case any
+// @Description failure rolls back the whole batch.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param request body BatchCreateMessagesRequest true "Messages to create"
+// @Success 200 {array} BatchResult
+// @Failure 400 {object} apperr.Problem
+// @Failure 422 {object} apperr.Problem
+// @Router /api/v1/messages:batchCreate [post]
+func (h *MessageHandler) BatchCreateMessages(c echo.Context) error {
+	req := new(BatchCreateMessagesRequest)
+	if err := c.Bind(req); err != nil {
+		return middleware.InvalidRequest(err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return middleware.InvalidRequest(err)
+	}
+
+	results := make([]BatchResult, len(req.Messages))
+	var messages []*models.Message
+	var indexes []int
+	for i := range req.Messages {
+		item := &req.Messages[i]
+		if err := c.Validate(item); err != nil {
+			results[i] = batchError(middleware.InvalidRequest(err))
+			continue
+		}
+		messages = append(messages, item.newMessage())
+		indexes = append(indexes, i)
+	}
+
+	if len(messages) == 0 || (req.Atomic && len(messages) < len(results)) {
+		abortBatch(results)
+		return batchResponse(c, results)
+	}
+
+	errs, err := h.messageService.BatchCreateMessages(c.Request().Context(), messages, req.Atomic)
+	if err != nil {
+		return err
+	}
+
+	for j, i := range indexes {
+		if errs[j] != nil {
+			results[i] = batchError(errs[j])
+			continue
+		}
+		results[i] = BatchResult{Status: http.StatusCreated, Message: messages[j]}
+	}
+
+	return batchResponse(c, results)
+}
+
+// BatchUpdateMessages godoc
+// @Summary Update several messages
+// @Description Replace the content of up to 1000 messages in one
+// @Description transaction. Every item gets a result at its index; failed
+// @Description items do not stop the others unless atomic is set, in which
+// @Description case any failure rolls back the whole batch.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param request body BatchUpdateMessagesRequest true "Messages to update"
+// @Success 200 {array} BatchResult
+// @Failure 400 {object} apperr.Problem
+// @Failure 422 {object} apperr.Problem
+// @Router /api/v1/messages:batchUpdate [post]
+func (h *MessageHandler) BatchUpdateMessages(c echo.Context) error {
+	req := new(BatchUpdateMessagesRequest)
+	if err := c.Bind(req); err != nil {
+		return middleware.InvalidRequest(err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return middleware.InvalidRequest(err)
+	}
+
+	results := make([]BatchResult, len(req.Messages))
+	var updates []*models.Message
+	var indexes []int
+	for i := range req.Messages {
+		item := &req.Messages[i]
+		if err := c.Validate(item); err != nil {
+			results[i] = batchError(middleware.InvalidRequest(err))
+			continue
+		}
+		updates = append(updates, &models.Message{
+			ID:      uuid.MustParse(item.ID),
+			Content: item.Content,
+			Version: item.Version,
+		})
+		indexes = append(indexes, i)
+	}
+
+	if len(updates) == 0 || (req.Atomic && len(updates) < len(results)) {
+		abortBatch(results)
+		return batchResponse(c, results)
+	}
+
+	errs, err := h.messageService.BatchUpdateMessages(c.Request().Context(), updates, req.Atomic)
+	if err != nil {
+		return err
+	}
+
+	for j, i := range indexes {
+		if errs[j] != nil {
+			results[i] = batchError(errs[j])
+			continue
+		}
+		results[i] = BatchResult{Status: http.StatusOK, Message: updates[j]}
+	}
+
+	return batchResponse(c, results)
+}
+
+// BatchDeleteMessages godoc
+// @Summary Delete several messages
+// @Description Move up to 1000 messages to the trash in one transaction.
+// @Description Unlike a single delete, a missing message is reported as a
+// @Description 404 item. Failed items do not stop the others unless atomic
+// @Description is set, in which case any failure rolls back the whole batch.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param request body BatchDeleteMessagesRequest true "Messages to delete"
+// @Success 200 {array} BatchResult
+// @Failure 400 {object} apperr.Problem
+// @Failure 422 {object} apperr.Problem
+// @Router /api/v1/messages:batchDelete [post]
+func (h *MessageHandler) BatchDeleteMessages(c echo.Context) error {
+	req := new(BatchDeleteMessagesRequest)
+	if err := c.Bind(req); err != nil {
+		return middleware.InvalidRequest(err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return middleware.InvalidRequest(err)
+	}
+
+	results := make([]BatchResult, len(req.IDs))
+	var ids []uuid.UUID
+	var indexes []int
+	for i, param := range req.IDs {
+		id, err := uuid.Parse(param)
+		if err != nil {
+			results[i] = invalidItem("invalid UUID format")
+			continue
+		}
+		ids = append(ids, id)
+		indexes = append(indexes, i)
+	}
+
+	if len(ids) == 0 || (req.Atomic && len(ids) < len(results)) {
+		abortBatch(results)
+		return batchResponse(c, results)
+	}
+
+	errs, err := h.messageService.BatchDeleteMessages(c.Request().Context(), ids, req.Atomic)
+	if err != nil {
+		return err
+	}
+
+	for j, i := range indexes {
+		if errs[j] != nil {
+			results[i] = batchError(errs[j])
+			continue
+		}
+		results[i] = BatchResult{Status: http.StatusNoContent}
+	}
+
+	return batchResponse(c, results)
+}
+
+// batchError is the result of an item that failed with err, reported as
+// the problem details of a request of its own would report it
+func batchError(err error) BatchResult {
+	problem := apperr.ToProblem(err)
+	return BatchResult{Status: problem.Status, Code: problem.Code, Error: problem.Detail}
+}
+
+// invalidItem is the result of an item rejected before reaching the service
+func invalidItem(detail string) BatchResult {
+	problem := apperr.NewProblem(http.StatusBadRequest, detail)
+	return BatchResult{Status: problem.Status, Code: problem.Code, Error: problem.Detail}
+}
+
+// abortBatch marks the items that have no result yet as aborted
+func abortBatch(results []BatchResult) {
+	for i := range results {
+		if results[i].Status == 0 {
+			results[i] = batchError(service.ErrBatchAborted)
+		}
+	}
+}
+
+func batchResponse(c echo.Context, results []BatchResult) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"results": results,
+	})
+}
@@ -2,29 +2,27 @@
 //
 // The health handler implements health check endpoints that provide information
 // about the service's health and its dependencies (database, cache, etc.).
+// The dependencies are checked by a health.Checker shared with the gRPC
+// health service.
 package http
 
 import (
-	"context"
 	"net/http"
 	"time"
 
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
-	"go-boilerplate/internal/cache"
+	"go-boilerplate/internal/health"
 )
 
 // HealthHandler handles health check related endpoints
 type HealthHandler struct {
-	db    *pgxpool.Pool
-	cache *cache.RedisCache
+	checker *health.Checker
 }
 
 // NewHealthHandler creates a new health handler
-func NewHealthHandler(db *pgxpool.Pool, cache *cache.RedisCache) *HealthHandler {
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
 	return &HealthHandler{
-		db:    db,
-		cache: cache,
+		checker: checker,
 	}
 }
 
@@ -51,7 +49,6 @@ type Status struct {
 // @Success 200 {object} HealthResponse
 // @Router /health [get]
 func (h *HealthHandler) Health(c echo.Context) error {
-	ctx := context.Background()
 	response := HealthResponse{
 		Status:    "ok",
 		Timestamp: time.Now(),
@@ -59,29 +56,17 @@ func (h *HealthHandler) Health(c echo.Context) error {
 		Services:  make(map[string]Status),
 	}
 
-	// Check database
-	if err := h.db.Ping(ctx); err != nil {
-		response.Status = "degraded"
-		response.Services["database"] = Status{
-			Status:  "down",
-			Message: "Database connection failed",
-		}
-	} else {
-		response.Services["database"] = Status{
-			Status: "up",
-		}
-	}
-
-	// Check Redis
-	if err := h.cache.Client().Ping(ctx).Err(); err != nil {
-		response.Status = "degraded"
-		response.Services["cache"] = Status{
-			Status:  "down",
-			Message: "Redis connection failed",
-		}
-	} else {
-		response.Services["cache"] = Status{
-			Status: "up",
+	for _, result := range h.checker.Run(c.Request().Context()) {
+		if result.Err != nil {
+			response.Status = "degraded"
+			response.Services[result.Name] = Status{
+				Status:  "down",
+				Message: result.Name + " connection failed",
+			}
+		} else {
+			response.Services[result.Name] = Status{
+				Status: "up",
+			}
 		}
 	}
 
@@ -111,22 +96,14 @@ func (h *HealthHandler) LivenessProbe(c echo.Context) error {
 // @Success 200 {object} map[string]string
 // @Router /health/ready [get]
 func (h *HealthHandler) ReadinessProbe(c echo.Context) error {
-	ctx := context.Background()
-	
-	// Check database connection
-	if err := h.db.Ping(ctx); err != nil {
-		return c.JSON(http.StatusServiceUnavailable, map[string]string{
-			"status": "not ready",
-			"reason": "database connection failed",
-		})
-	}
-
-	// Check Redis connection
-	if err := h.cache.Client().Ping(ctx).Err(); err != nil {
-		return c.JSON(http.StatusServiceUnavailable, map[string]string{
-			"status": "not ready",
-			"reason": "cache connection failed",
-		})
+	// Report the first dependency that is down
+	for _, result := range h.checker.Run(c.Request().Context()) {
+		if result.Err != nil {
+			return c.JSON(http.StatusServiceUnavailable, map[string]string{
+				"status": "not ready",
+				"reason": result.Name + " connection failed",
+			})
+		}
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{
@@ -1,51 +1,136 @@
 package http
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	jsonpatch "github.com/evanphx/json-patch/v5"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"go-boilerplate/internal/apperr"
+	"go-boilerplate/internal/auth"
+	"go-boilerplate/internal/idempotency"
+	"go-boilerplate/internal/middleware"
 	"go-boilerplate/internal/models"
+	"go-boilerplate/internal/pagination"
 	"go-boilerplate/internal/service"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MIMEMergePatch is the media type of an RFC 7396 JSON Merge Patch
+	MIMEMergePatch = "application/merge-patch+json"
+	// MIMEJSONPatch is the media type of an RFC 6902 JSON Patch
+	MIMEJSONPatch = "application/json-patch+json"
+
+	// maxPatchSize bounds the size of a PATCH request body
+	maxPatchSize = 64 << 10
+
+	// HeaderIdempotencyKey makes a request safe to retry
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed marks a response replayed for a retry
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
 )
 
 type MessageHandler struct {
 	messageService *service.MessageService
+	cursors        *pagination.Codec
+	idempotency    *idempotency.Keeper
 }
 
-func NewMessageHandler(messageService *service.MessageService) *MessageHandler {
+func NewMessageHandler(messageService *service.MessageService, cursors *pagination.Codec, keeper *idempotency.Keeper) *MessageHandler {
 	return &MessageHandler{
 		messageService: messageService,
+		cursors:        cursors,
+		idempotency:    keeper,
 	}
 }
 
 // CreateMessage godoc
 // @Summary Create a new message
-// @Description Create a new message with the provided content
+// @Description Create a new message with the provided content. Send an
+// @Description Idempotency-Key to make the request safe to retry: retries
+// @Description with the same key and body replay the original response
+// @Description instead of creating another message. A message with a
+// @Description parent_id replies to that message and joins its thread. Set
+// @Description expires_at, or ttl in seconds, to have the message vanish.
+// @Description Set status draft to keep the message to yourself, or
+// @Description publish_at in the future to schedule it; until published it
+// @Description is only seen by its author.
 // @Tags messages
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Client-chosen key, at most 255 characters"
 // @Param message body CreateMessageRequest true "Message content"
 // @Success 201 {object} models.Message
+// @Header 201 {string} ETag "Entity tag of the message version"
+// @Header 201 {string} Idempotent-Replayed "true when the response is replayed for a retry"
+// @Failure 422 {object} apperr.Problem
 // @Router /api/v1/messages [post]
 func (h *MessageHandler) CreateMessage(c echo.Context) error {
 	req := new(CreateMessageRequest)
 	if err := c.Bind(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return middleware.InvalidRequest(err)
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return middleware.InvalidRequest(err)
 	}
 
-	message := &models.Message{
-		Content: req.Content,
+	create := func() (*idempotency.Response, error) {
+		message := req.newMessage()
+
+		if err := h.messageService.CreateMessage(c.Request().Context(), message); err != nil {
+			return nil, err
+		}
+
+		body, err := json.Marshal(message)
+		if err != nil {
+			return nil, err
+		}
+		return &idempotency.Response{
+			Status: http.StatusCreated,
+			Header: map[string]string{"ETag": etag(message.Version)},
+			Body:   body,
+		}, nil
 	}
 
-	if err := h.messageService.CreateMessage(c.Request().Context(), message); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	var resp *idempotency.Response
+	var err error
+	key := c.Request().Header.Get(HeaderIdempotencyKey)
+	if key == "" {
+		resp, err = create()
+	} else {
+		if len(key) > idempotency.MaxKeyLength {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("%s must not exceed %d characters", HeaderIdempotencyKey, idempotency.MaxKeyLength))
+		}
+
+		// Retries must send the same request, whatever its formatting
+		fingerprint, marshalErr := json.Marshal(req)
+		if marshalErr != nil {
+			return fmt.Errorf("failed to fingerprint request: %w", marshalErr)
+		}
+
+		var replayed bool
+		resp, replayed, err = h.idempotency.Do(c.Request().Context(), "http:CreateMessage", key, idempotency.Fingerprint(fingerprint), create)
+		if replayed {
+			c.Response().Header().Set(HeaderIdempotentReplayed, "true")
+		}
+	}
+	if err != nil {
+		return err
 	}
 
-	return c.JSON(http.StatusCreated, message)
+	for name, value := range resp.Header {
+		c.Response().Header().Set(name, value)
+	}
+	return c.JSONBlob(resp.Status, resp.Body)
 }
 
 // GetMessage godoc
@@ -55,6 +140,8 @@ func (h *MessageHandler) CreateMessage(c echo.Context) error {
 // @Produce json
 // @Param id path string true "Message ID"
 // @Success 200 {object} models.Message
+// @Header 200 {string} ETag "Entity tag of the message version"
+// @Failure 404 {object} apperr.Problem
 // @Router /api/v1/messages/{id} [get]
 func (h *MessageHandler) GetMessage(c echo.Context) error {
 	id, err := uuid.Parse(c.Param("id"))
@@ -64,28 +151,47 @@ func (h *MessageHandler) GetMessage(c echo.Context) error {
 
 	message, err := h.messageService.GetMessage(c.Request().Context(), id)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
-	}
-
-	if message == nil {
-		return echo.NewHTTPError(http.StatusNotFound, "message not found")
+		return err
 	}
 
+	c.Response().Header().Set("ETag", etag(message.Version))
 	return c.JSON(http.StatusOK, message)
 }
 
 // ListMessages godoc
 // @Summary List all messages
-// @Description Get a list of all messages
+// @Description Get a page of messages, newest first unless sort says
+// @Description otherwise. Pages are selected by page number, or by cursor
+// @Description when the cursor parameter is present: send it empty for the
+// @Description first page, then follow next_cursor and prev_cursor (also
+// @Description given as Link headers) with the same filters and sort.
+// @Description Cursor pages do not shift when messages are added or removed.
 // @Tags messages
 // @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Param cursor query string false "Opaque cursor from a previous page"
+// @Param created_after query string false "Only messages created after this RFC 3339 time"
+// @Param created_before query string false "Only messages created before this RFC 3339 time"
+// @Param updated_after query string false "Only messages updated after this RFC 3339 time"
+// @Param updated_before query string false "Only messages updated before this RFC 3339 time"
+// @Param id query []string false "Only these message IDs" collectionFormat(multi)
+// @Param content_prefix query string false "Only messages whose content starts with this text"
+// @Param label query []string false "Only messages carrying all of these labels" collectionFormat(multi)
+// @Param metadata_key query []string false "Only messages whose metadata has all of these keys" collectionFormat(multi)
+// @Param mine query bool false "Only messages written by the caller"
+// @Param status query string false "Only messages in this status: draft, scheduled or published. Unpublished messages are only listed for their author"
+// @Param sort query string false "created_at or updated_at, prefixed with - for descending (default -created_at)"
 // @Success 200 {array} models.Message
+// @Header 200 {string} Link "RFC 8288 links to the next and previous pages in cursor mode"
+// @Failure 400 {object} apperr.Problem
+// @Failure 422 {object} apperr.Problem
 // @Router /api/v1/messages [get]
 func (h *MessageHandler) ListMessages(c echo.Context) error {
 	req := &ListMessagesRequest{}
 
 	if err := c.Bind(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return middleware.InvalidRequest(err)
 	}
 
 	// Set defaults if not provided
@@ -97,12 +203,28 @@ func (h *MessageHandler) ListMessages(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return middleware.InvalidRequest(err)
+	}
+
+	filter, order, err := req.query()
+	if err != nil {
+		return err
+	}
+	if req.Mine {
+		claims, ok := auth.FromContext(c.Request().Context())
+		if !ok || claims.UserID == "" {
+			return echo.NewHTTPError(http.StatusUnauthorized, "mine requires an authenticated caller")
+		}
+		filter.AuthorID = claims.UserID
+	}
+
+	if _, ok := c.QueryParams()["cursor"]; ok {
+		return h.listMessagesByCursor(c, req, filter, order)
 	}
 
-	messages, total, err := h.messageService.ListMessagesPaginated(c.Request().Context(), req.Page, req.PageSize)
+	messages, total, err := h.messageService.ListMessagesPaginated(c.Request().Context(), filter, order, req.Page, req.PageSize)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
@@ -113,28 +235,237 @@ func (h *MessageHandler) ListMessages(c echo.Context) error {
 	})
 }
 
+// listMessagesByCursor serves ListMessages in keyset mode
+func (h *MessageHandler) listMessagesByCursor(c echo.Context, req *ListMessagesRequest, filter service.MessageFilter, order service.Sort) error {
+	var after, before *service.Keyset
+	if req.Cursor != "" {
+		cursor, err := h.cursors.Decode(req.Cursor)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if cursor.Order != order.Key() {
+			return echo.NewHTTPError(http.StatusBadRequest, "cursor was issued for a different sort")
+		}
+
+		position := &service.Keyset{At: cursor.At, ID: cursor.ID}
+		if cursor.Backward {
+			before = position
+		} else {
+			after = position
+		}
+	}
+
+	page, err := h.messageService.ListMessagesKeyset(c.Request().Context(), filter, order, req.PageSize, after, before)
+	if err != nil {
+		return err
+	}
+
+	var next, prev string
+	var links []string
+	if page.Next != nil {
+		next = h.encodeCursor(order, page.Next, false)
+		links = append(links, pageLink(c, next, req.PageSize, "next"))
+	}
+	if page.Prev != nil {
+		prev = h.encodeCursor(order, page.Prev, true)
+		links = append(links, pageLink(c, prev, req.PageSize, "prev"))
+	}
+	if len(links) > 0 {
+		c.Response().Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"messages":    page.Messages,
+		"page_size":   req.PageSize,
+		"next_cursor": next,
+		"prev_cursor": prev,
+	})
+}
+
+func (h *MessageHandler) encodeCursor(order service.Sort, position *service.Keyset, backward bool) string {
+	return h.cursors.Encode(pagination.Cursor{
+		At:       position.At,
+		ID:       position.ID,
+		Order:    order.Key(),
+		Backward: backward,
+	})
+}
+
+// pageLink formats an RFC 8288 link to the page at cursor, keeping the
+// filters and sort of the current request
+func pageLink(c echo.Context, cursor string, pageSize uint32, rel string) string {
+	query := url.Values{}
+	for name, values := range c.QueryParams() {
+		query[name] = values
+	}
+	query.Del("page")
+	query.Set("cursor", cursor)
+	query.Set("page_size", strconv.FormatUint(uint64(pageSize), 10))
+
+	target := url.URL{Path: c.Request().URL.Path, RawQuery: query.Encode()}
+	return fmt.Sprintf("<%s>; rel=%q", target.String(), rel)
+}
+
+// maxTTL bounds a ttl, in seconds, to ten years
+const maxTTL = 10 * 365 * 24 * 60 * 60
+
 type CreateMessageRequest struct {
-	Content string `json:"content" validate:"required,min=1,max=1000"`
+	Content   string                 `json:"content"`
+	Labels    []string               `json:"labels,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	ParentID  string                 `json:"parent_id,omitempty" validate:"omitempty,uuid"`
+	ExpiresAt *time.Time             `json:"expires_at,omitempty"`
+	// TTL sets the expiry in seconds from now instead of ExpiresAt
+	TTL int64 `json:"ttl,omitempty" validate:"omitempty,gt=0,lte=315360000,excluded_with=ExpiresAt"`
+	// Status defaults to scheduled with a future PublishAt, else published
+	Status    string     `json:"status,omitempty"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
 }
 
+// newMessage returns the message a validated request creates
+func (r *CreateMessageRequest) newMessage() *models.Message {
+	message := &models.Message{
+		Content:   r.Content,
+		Labels:    r.Labels,
+		Metadata:  r.Metadata,
+		ExpiresAt: expiry(r.ExpiresAt, r.TTL),
+		Status:    r.Status,
+		PublishAt: r.PublishAt,
+	}
+	if parentID, err := uuid.Parse(r.ParentID); err == nil {
+		message.ParentID = &parentID
+	}
+	return message
+}
+
+// UpdateMessageRequest is the writable representation of a message. PUT
+// replaces it as a whole and PATCH requests are applied to it.
 type UpdateMessageRequest struct {
-	Content string `json:"content" validate:"required,min=1,max=1000"`
+	Content   string                 `json:"content"`
+	Labels    []string               `json:"labels,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	ExpiresAt *time.Time             `json:"expires_at,omitempty"`
+	// TTL sets the expiry in seconds from now instead of ExpiresAt
+	TTL int64 `json:"ttl,omitempty" validate:"omitempty,gt=0,lte=315360000,excluded_with=ExpiresAt"`
+	// Status left out keeps the current status
+	Status    string     `json:"status,omitempty"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+}
+
+// newUpdateMessageRequest returns the writable representation of message
+func newUpdateMessageRequest(message *models.Message) *UpdateMessageRequest {
+	return &UpdateMessageRequest{
+		Content:   message.Content,
+		Labels:    message.Labels,
+		Metadata:  message.Metadata,
+		ExpiresAt: message.ExpiresAt,
+		Status:    message.Status,
+		PublishAt: message.PublishAt,
+	}
+}
+
+// applyTo copies the writable fields onto message
+func (r *UpdateMessageRequest) applyTo(message *models.Message) {
+	message.Content = r.Content
+	message.Labels = r.Labels
+	message.Metadata = r.Metadata
+	message.ExpiresAt = expiry(r.ExpiresAt, r.TTL)
+	if r.Status != "" {
+		message.Status = r.Status
+	}
+	message.PublishAt = r.PublishAt
+}
+
+// expiry returns the expiry set by an expires_at or by a ttl in seconds,
+// nil for neither
+func expiry(expiresAt *time.Time, ttl int64) *time.Time {
+	if ttl > 0 {
+		at := time.Now().Add(time.Duration(ttl) * time.Second)
+		return &at
+	}
+	return expiresAt
 }
 
 type ListMessagesRequest struct {
-	Page     uint32 `query:"page" validate:"gte=0"`
-	PageSize uint32 `query:"page_size" validate:"gt=0,lte=100"`
+	Page          uint32    `query:"page" validate:"gte=0"`
+	PageSize      uint32    `query:"page_size" validate:"gt=0,lte=100"`
+	Cursor        string    `query:"cursor"`
+	CreatedAfter  time.Time `query:"created_after"`
+	CreatedBefore time.Time `query:"created_before"`
+	UpdatedAfter  time.Time `query:"updated_after"`
+	UpdatedBefore time.Time `query:"updated_before"`
+	IDs           []string  `query:"id" validate:"max=100,dive,uuid"`
+	ContentPrefix string    `query:"content_prefix" validate:"max=1000"`
+	Labels        []string  `query:"label" validate:"max=10,dive,min=1,max=64"`
+	MetadataKeys  []string  `query:"metadata_key" validate:"max=10,dive,min=1"`
+	Mine          bool      `query:"mine"`
+	Status        string    `query:"status" validate:"omitempty,oneof=draft scheduled published"`
+	Sort          string    `query:"sort"`
+}
+
+// query returns the filter and sort order the request asks for
+func (r *ListMessagesRequest) query() (service.MessageFilter, service.Sort, error) {
+	filter := service.MessageFilter{
+		CreatedAfter:  r.CreatedAfter,
+		CreatedBefore: r.CreatedBefore,
+		UpdatedAfter:  r.UpdatedAfter,
+		UpdatedBefore: r.UpdatedBefore,
+		ContentPrefix: r.ContentPrefix,
+		Labels:        r.Labels,
+		MetadataKeys:  r.MetadataKeys,
+		Status:        r.Status,
+	}
+	for _, param := range r.IDs {
+		id, err := uuid.Parse(param)
+		if err != nil {
+			return service.MessageFilter{}, service.Sort{}, invalidQuery("id", "UUID", fmt.Sprintf("id %q is not a UUID", param))
+		}
+		filter.IDs = append(filter.IDs, id)
+	}
+
+	var order service.Sort
+	if r.Sort != "" {
+		name := strings.TrimPrefix(r.Sort, "-")
+		field, ok := service.ParseSortField(name)
+		if !ok {
+			return service.MessageFilter{}, service.Sort{}, invalidQuery("sort", "SORT_FIELD", fmt.Sprintf("cannot sort by %q", name))
+		}
+		order = service.Sort{Field: field, Ascending: !strings.HasPrefix(r.Sort, "-")}
+	}
+
+	return filter, order, nil
+}
+
+// invalidQuery reports a query parameter that passes its validation rules
+// but still cannot be used, as a failed validation would
+func invalidQuery(field, reason, description string) error {
+	return &middleware.RequestError{
+		Err:        middleware.ErrInvalidRequest,
+		Violations: []apperr.Violation{{Field: field, Reason: reason, Description: description}},
+	}
 }
 
 // UpdateMessage godoc
 // @Summary Update a message
-// @Description Update a message's content by its ID
+// @Description Replace a message's content, labels, metadata, expiry and
+// @Description publish time by its ID. Labels, metadata, an expiry and a
+// @Description publish time left out are cleared; a status left out is kept.
+// @Description A published message cannot go back to draft or scheduled.
+// @Description Send the ETag from a
+// @Description previous response in If-Match to reject the update if the
+// @Description message has been modified since.
 // @Tags messages
 // @Accept json
 // @Produce json
 // @Param id path string true "Message ID"
+// @Param If-Match header string false "Expected entity tag"
 // @Param message body UpdateMessageRequest true "Updated message content"
 // @Success 200 {object} models.Message
+// @Header 200 {string} ETag "Entity tag of the new message version"
+// @Failure 403 {object} apperr.Problem
+// @Failure 404 {object} apperr.Problem
+// @Failure 412 {object} apperr.Problem
+// @Failure 422 {object} apperr.Problem
 // @Router /api/v1/messages/{id} [put]
 func (h *MessageHandler) UpdateMessage(c echo.Context) error {
 	id, err := uuid.Parse(c.Param("id"))
@@ -144,32 +475,131 @@ func (h *MessageHandler) UpdateMessage(c echo.Context) error {
 
 	req := new(UpdateMessageRequest)
 	if err := c.Bind(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return middleware.InvalidRequest(err)
 	}
 
 	if err := c.Validate(req); err != nil {
+		return middleware.InvalidRequest(err)
+	}
+
+	version, err := parseIfMatch(c.Request().Header.Get("If-Match"))
+	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
 	message := &models.Message{
 		ID:      id,
-		Content: req.Content,
+		Version: version,
 	}
+	req.applyTo(message)
 
 	if err := h.messageService.UpdateMessage(c.Request().Context(), message); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
+	c.Response().Header().Set("ETag", etag(message.Version))
+	return c.JSON(http.StatusOK, message)
+}
+
+// PatchMessage godoc
+// @Summary Partially update a message
+// @Description Apply a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902)
+// @Description to the writable fields of a message. The patch is applied to
+// @Description the latest version of the message; send If-Match to reject it
+// @Description if the message has been modified since.
+// @Tags messages
+// @Accept application/merge-patch+json,application/json-patch+json
+// @Produce json
+// @Param id path string true "Message ID"
+// @Param If-Match header string false "Expected entity tag"
+// @Param patch body object true "Merge patch or JSON Patch document"
+// @Success 200 {object} models.Message
+// @Header 200 {string} ETag "Entity tag of the new message version"
+// @Failure 400 {object} apperr.Problem
+// @Failure 403 {object} apperr.Problem
+// @Failure 404 {object} apperr.Problem
+// @Failure 409 {object} apperr.Problem
+// @Failure 412 {object} apperr.Problem
+// @Failure 415 {object} apperr.Problem
+// @Failure 422 {object} apperr.Problem
+// @Router /api/v1/messages/{id} [patch]
+func (h *MessageHandler) PatchMessage(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid UUID format")
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if mediaType != MIMEMergePatch && mediaType != MIMEJSONPatch {
+		c.Response().Header().Set("Accept-Patch", MIMEMergePatch+", "+MIMEJSONPatch)
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType,
+			fmt.Sprintf("Content-Type must be %s or %s", MIMEMergePatch, MIMEJSONPatch))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxPatchSize+1))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if len(body) > maxPatchSize {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "patch document is too large")
+	}
+
+	apply, err := decodePatch(mediaType, body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	version, err := parseIfMatch(c.Request().Header.Get("If-Match"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	message, err := h.messageService.PatchMessage(c.Request().Context(), id, version, func(current *models.Message) error {
+		doc, err := json.Marshal(newUpdateMessageRequest(current))
+		if err != nil {
+			return err
+		}
+
+		patched, err := apply(doc)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("failed to apply patch: %v", err))
+		}
+
+		req := new(UpdateMessageRequest)
+		dec := json.NewDecoder(bytes.NewReader(patched))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(req); err != nil {
+			return middleware.InvalidRequest(err)
+		}
+		// A patched in ttl replaces the current expiry
+		if req.TTL > 0 {
+			req.ExpiresAt = nil
+		}
+
+		if err := c.Validate(req); err != nil {
+			return middleware.InvalidRequest(err)
+		}
+
+		req.applyTo(current)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set("ETag", etag(message.Version))
 	return c.JSON(http.StatusOK, message)
 }
 
 // DeleteMessage godoc
 // @Summary Delete a message
-// @Description Delete a message by its ID
+// @Description Delete a message by its ID. Its replies are kept, or deleted
+// @Description too when the server's THREAD_DELETE_POLICY is cascade.
 // @Tags messages
 // @Produce json
 // @Param id path string true "Message ID"
 // @Success 204 "No Content"
+// @Failure 403 {object} apperr.Problem
 // @Router /api/v1/messages/{id} [delete]
 func (h *MessageHandler) DeleteMessage(c echo.Context) error {
 	id, err := uuid.Parse(c.Param("id"))
@@ -178,8 +608,56 @@ func (h *MessageHandler) DeleteMessage(c echo.Context) error {
 	}
 
 	if err := h.messageService.DeleteMessage(c.Request().Context(), id); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.NoContent(http.StatusNoContent)
 }
+
+// decodePatch parses a patch document of the given media type and returns a
+// function that applies it to a JSON document
+func decodePatch(mediaType string, body []byte) (func(doc []byte) ([]byte, error), error) {
+	switch mediaType {
+	case MIMEMergePatch:
+		var patch map[string]json.RawMessage
+		if err := json.Unmarshal(body, &patch); err != nil {
+			return nil, fmt.Errorf("merge patch must be a JSON object: %w", err)
+		}
+		return func(doc []byte) ([]byte, error) {
+			return jsonpatch.MergePatch(doc, body)
+		}, nil
+	case MIMEJSONPatch:
+		patch, err := jsonpatch.DecodePatch(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON Patch: %w", err)
+		}
+		return patch.Apply, nil
+	default:
+		return nil, fmt.Errorf("unsupported patch media type %q", mediaType)
+	}
+}
+
+// etag formats a message version as a strong entity tag
+func etag(version int64) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// parseIfMatch returns the message version named by an If-Match header. It
+// returns 0, meaning any version, when the header is empty or "*".
+func parseIfMatch(header string) (int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	if strings.HasPrefix(header, "W/") {
+		return 0, fmt.Errorf("If-Match requires a strong entity tag")
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(header, `"`), 10, 64)
+	if err != nil || version <= 0 || !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) {
+		return 0, fmt.Errorf("If-Match must be a single entity tag returned by this API")
+	}
+
+	return version, nil
+}
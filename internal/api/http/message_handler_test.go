@@ -2,199 +2,1258 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go-boilerplate/config"
+	"go-boilerplate/internal/apperr"
+	"go-boilerplate/internal/auth"
+	"go-boilerplate/internal/blob"
+	"go-boilerplate/internal/cache"
+	"go-boilerplate/internal/idempotency"
+	"go-boilerplate/internal/middleware"
 	"go-boilerplate/internal/models"
+	"go-boilerplate/internal/moderation"
+	"go-boilerplate/internal/pagination"
+	"go-boilerplate/internal/service"
+	"go.uber.org/zap"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"testing"
 	"time"
 )
 
-type MockMessageService struct {
-	mock.Mock
+func newTestService() *service.MessageService {
+	return service.NewMessageService(service.NewMemoryStore(), cache.NewMemoryCache(), service.Options{})
 }
 
-func (m *MockMessageService) CreateMessage(ctx interface{}, message *models.Message) error {
-	args := m.Called(ctx, message)
-	return args.Error(0)
+func setupTestRouter(messageService *service.MessageService) *echo.Echo {
+	return setupAuthTestRouter(messageService, "")
 }
 
-func (m *MockMessageService) GetMessage(ctx interface{}, id uuid.UUID) (*models.Message, error) {
-	args := m.Called(ctx, id)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*models.Message), args.Error(1)
-}
+// setupAuthTestRouter is setupTestRouter with authentication by tokens
+// signed with jwtSecret. The tenant header is X-Tenant-ID.
+func setupAuthTestRouter(messageService *service.MessageService, jwtSecret string) *echo.Echo {
+	e := echo.New()
+	e.Validator = &middleware.CustomValidator{Validator: middleware.GetValidator()}
+	e.HTTPErrorHandler = middleware.ErrorHandler(zap.NewNop())
 
-func (m *MockMessageService) UpdateMessage(ctx interface{}, message *models.Message) error {
-	args := m.Called(ctx, message)
-	return args.Error(0)
-}
+	keeper := idempotency.NewKeeper(idempotency.NewMemoryStore(), config.IdempotencyConfig{TTL: time.Hour, LockTimeout: time.Minute}, zap.NewNop())
+	handler := NewMessageHandler(messageService, pagination.NewCodec([]byte("test")), keeper)
+	RegisterRoutes(e, handler, jwtSecret, "X-Tenant-ID")
 
-func (m *MockMessageService) DeleteMessage(ctx interface{}, id uuid.UUID) error {
-	args := m.Called(ctx, id)
-	return args.Error(0)
-}
-
-func (m *MockMessageService) ListMessages(ctx interface{}) ([]models.Message, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]models.Message), args.Error(1)
+	return e
 }
 
-func setupTestRouter(mockService *MockMessageService) *echo.Echo {
-	e := echo.New()
-	
-	handler := NewMessageHandler(mockService)
-	v1 := e.Group("/api/v1")
-	messages := v1.Group("/messages")
-	messages.POST("", handler.CreateMessage)
-	messages.GET("", handler.ListMessages)
-	messages.GET("/:id", handler.GetMessage)
-	messages.PUT("/:id", handler.UpdateMessage)
-	messages.DELETE("/:id", handler.DeleteMessage)
-	
-	return e
+func createTestMessage(t *testing.T, messageService *service.MessageService, content string) *models.Message {
+	t.Helper()
+	message := &models.Message{Content: content}
+	require.NoError(t, messageService.CreateMessage(context.Background(), message))
+	return message
 }
 
 func TestCreateMessage(t *testing.T) {
-	mockService := new(MockMessageService)
-	router := setupTestRouter(mockService)
+	messageService := newTestService()
+	router := setupTestRouter(messageService)
 
-	message := &models.Message{
-		Content: "Test message",
-	}
+	body, _ := json.Marshal(CreateMessageRequest{Content: "Test message"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/messages", bytes.NewBuffer(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	w := httptest.NewRecorder()
 
-	mockService.On("CreateMessage", mock.Anything, mock.MatchedBy(func(m *models.Message) bool {
-		return m.Content == message.Content
-	})).Return(nil)
+	router.ServeHTTP(w, req)
 
-	body, _ := json.Marshal(CreateMessageRequest{Content: message.Content})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response models.Message
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.NotEqual(t, uuid.Nil, response.ID)
+	assert.Equal(t, "Test message", response.Content)
+}
+
+func TestCreateMessage_ValidationError(t *testing.T) {
+	router := setupTestRouter(newTestService())
+
+	body, _ := json.Marshal(CreateMessageRequest{Content: ""})
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/messages", bytes.NewBuffer(body))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-	rec := httptest.NewRecorder()
-	c := router.NewContext(req, rec)
+	w := httptest.NewRecorder()
 
-	assert.NoError(t, handler.CreateMessage(c))
+	router.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusCreated, w.Code)
-	mockService.AssertExpectations(t)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"INVALID_CONTENT"`)
 }
 
-func TestGetMessage(t *testing.T) {
-	mockService := new(MockMessageService)
-	router := setupTestRouter(mockService)
+func TestCreateMessage_IdempotencyKey(t *testing.T) {
+	messageService := newTestService()
+	router := setupTestRouter(messageService)
 
-	id := uuid.New()
-	message := &models.Message{
-		ID:        id,
-		Content:   "Test message",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	create := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/messages", bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(HeaderIdempotencyKey, key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
 	}
 
-	mockService.On("GetMessage", mock.Anything, id).Return(message, nil)
+	first := create("retry-me", `{"content":"Test message"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(HeaderIdempotentReplayed))
+
+	// Formatting does not matter, only the request itself
+	retry := create("retry-me", `{ "content": "Test message" }`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(HeaderIdempotentReplayed))
+	assert.Equal(t, first.Header().Get("ETag"), retry.Header().Get("ETag"))
+	assert.JSONEq(t, first.Body.String(), retry.Body.String())
 
-	req := httptest.NewRequest(http.MethodGet, "/api/v1/messages/"+id.String(), nil)
-	rec := httptest.NewRecorder()
-	c := router.NewContext(req, rec)
-	c.SetParamNames("id")
-	c.SetParamValues(id.String())
+	_, total, err := messageService.ListMessagesPaginated(context.Background(), service.MessageFilter{}, service.Sort{}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
 
-	assert.NoError(t, handler.GetMessage(c))
+	reused := create("retry-me", `{"content":"Another message"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+}
+
+func TestGetMessage(t *testing.T) {
+	messageService := newTestService()
+	router := setupTestRouter(messageService)
+	message := createTestMessage(t, messageService, "Test message")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/messages/"+message.ID.String(), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
 
 	var response models.Message
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
 	assert.Equal(t, message.ID, response.ID)
 	assert.Equal(t, message.Content, response.Content)
-
-	mockService.AssertExpectations(t)
 }
 
 func TestUpdateMessage(t *testing.T) {
-	mockService := new(MockMessageService)
-	router := setupTestRouter(mockService)
+	messageService := newTestService()
+	router := setupTestRouter(messageService)
+	message := createTestMessage(t, messageService, "Test message")
 
-	id := uuid.New()
 	updatedContent := "Updated message"
-
-	mockService.On("UpdateMessage", mock.Anything, mock.MatchedBy(func(m *models.Message) bool {
-		return m.ID == id && m.Content == updatedContent
-	})).Return(nil)
-
 	body, _ := json.Marshal(UpdateMessageRequest{Content: updatedContent})
-	req := httptest.NewRequest("PUT", "/api/v1/messages/"+id.String(), bytes.NewBuffer(body))
+	req := httptest.NewRequest("PUT", "/api/v1/messages/"+message.ID.String(), bytes.NewBuffer(body))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
-	mockService.AssertExpectations(t)
-}
 
-func TestDeleteMessage(t *testing.T) {
-	mockService := new(MockMessageService)
-	router := setupTestRouter(mockService)
+	stored, err := messageService.GetMessage(context.Background(), message.ID)
+	require.NoError(t, err)
+	assert.Equal(t, updatedContent, stored.Content)
+}
 
-	id := uuid.New()
+func TestUpdateMessage_IfMatch(t *testing.T) {
+	messageService := newTestService()
+	router := setupTestRouter(messageService)
+	message := createTestMessage(t, messageService, "Test message")
 
-	mockService.On("DeleteMessage", mock.Anything, id).Return(nil)
+	update := func(ifMatch string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(UpdateMessageRequest{Content: "Updated message"})
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/messages/"+message.ID.String(), bytes.NewBuffer(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("If-Match", ifMatch)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
 
-	req := httptest.NewRequest(http.MethodDelete, "/api/v1/messages/"+id.String(), nil)
-	rec := httptest.NewRecorder()
-	c := router.NewContext(req, rec)
-	c.SetParamNames("id")
-	c.SetParamValues(id.String())
+	w := update(`"1"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
 
-	assert.NoError(t, handler.DeleteMessage(c))
+	// Reusing the old ETag is a stale write
+	w = update(`"1"`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
 
-	assert.Equal(t, http.StatusNoContent, w.Code)
-	mockService.AssertExpectations(t)
+	w = update(`W/"2"`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
-func TestListMessages(t *testing.T) {
-	mockService := new(MockMessageService)
-	router := setupTestRouter(mockService)
-
-	messages := []models.Message{
+func TestPatchMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantContent string
+	}{
+		{
+			name:        "merge patch",
+			contentType: MIMEMergePatch,
+			body:        `{"content": "Patched message"}`,
+			wantStatus:  http.StatusOK,
+			wantContent: "Patched message",
+		},
+		{
+			name:        "json patch",
+			contentType: MIMEJSONPatch,
+			body:        `[{"op": "test", "path": "/content", "value": "Test message"}, {"op": "replace", "path": "/content", "value": "Patched message"}]`,
+			wantStatus:  http.StatusOK,
+			wantContent: "Patched message",
+		},
+		{
+			name:        "failed test operation",
+			contentType: MIMEJSONPatch,
+			body:        `[{"op": "test", "path": "/content", "value": "Other message"}]`,
+			wantStatus:  http.StatusConflict,
+			wantContent: "Test message",
+		},
+		{
+			name:        "removing a required field",
+			contentType: MIMEMergePatch,
+			body:        `{"content": null}`,
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantContent: "Test message",
+		},
+		{
+			name:        "adding a read-only field",
+			contentType: MIMEJSONPatch,
+			body:        `[{"op": "add", "path": "/version", "value": 7}]`,
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantContent: "Test message",
+		},
 		{
-			ID:        uuid.New(),
-			Content:   "Message 1",
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+			name:        "malformed patch",
+			contentType: MIMEJSONPatch,
+			body:        `{"op": "replace"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantContent: "Test message",
 		},
 		{
-			ID:        uuid.New(),
-			Content:   "Message 2",
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+			name:        "unsupported media type",
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{"content": "Patched message"}`,
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantContent: "Test message",
 		},
 	}
 
-	mockService.On("ListMessages", mock.Anything).Return(messages, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messageService := newTestService()
+			router := setupTestRouter(messageService)
+			message := createTestMessage(t, messageService, "Test message")
+
+			req := httptest.NewRequest(http.MethodPatch, "/api/v1/messages/"+message.ID.String(), bytes.NewBufferString(tt.body))
+			req.Header.Set(echo.HeaderContentType, tt.contentType)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+
+			stored, err := messageService.GetMessage(context.Background(), message.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantContent, stored.Content)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestPatchMessage_IfMatch(t *testing.T) {
+	messageService := newTestService()
+	router := setupTestRouter(messageService)
+	message := createTestMessage(t, messageService, "Test message")
+	require.NoError(t, messageService.UpdateMessage(context.Background(), &models.Message{ID: message.ID, Content: "Updated message"}))
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/messages/"+message.ID.String(), bytes.NewBufferString(`{"content": "Patched message"}`))
+	req.Header.Set(echo.HeaderContentType, MIMEMergePatch)
+	req.Header.Set("If-Match", `"1"`)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+}
+
+func TestGetMessage_NotFound(t *testing.T) {
+	router := setupTestRouter(newTestService())
+
+	path := "/api/v1/messages/" + uuid.New().String()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, apperr.MIMEProblem, w.Header().Get(echo.HeaderContentType))
+	var problem apperr.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, apperr.Problem{
+		Type:     "about:blank",
+		Title:    "Not Found",
+		Status:   http.StatusNotFound,
+		Detail:   "message not found",
+		Instance: path,
+		Code:     "MESSAGE_NOT_FOUND",
+	}, problem)
+}
+
+func TestDeleteMessage(t *testing.T) {
+	messageService := newTestService()
+	router := setupTestRouter(messageService)
+	message := createTestMessage(t, messageService, "Test message")
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/messages/"+message.ID.String(), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	_, err := messageService.GetMessage(context.Background(), message.ID)
+	assert.ErrorIs(t, err, service.ErrMessageNotFound)
+}
+
+func TestListMessages(t *testing.T) {
+	messageService := newTestService()
+	router := setupTestRouter(messageService)
+	createTestMessage(t, messageService, "Message 1")
+	createTestMessage(t, messageService, "Message 2")
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/messages", nil)
-	rec := httptest.NewRecorder()
-	c := router.NewContext(req, rec)
+	w := httptest.NewRecorder()
 
-	assert.NoError(t, handler.ListMessages(c))
+	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	var response []models.Message
+	var response struct {
+		Messages []models.Message `json:"messages"`
+		Total    int64            `json:"total"`
+	}
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Len(t, response, 2)
-	assert.Equal(t, messages[0].Content, response[0].Content)
-	assert.Equal(t, messages[1].Content, response[1].Content)
+	assert.Len(t, response.Messages, 2)
+	assert.Equal(t, int64(2), response.Total)
+
+	contents := []string{response.Messages[0].Content, response.Messages[1].Content}
+	assert.ElementsMatch(t, []string{"Message 1", "Message 2"}, contents)
+}
+
+func TestListMessages_Cursor(t *testing.T) {
+	messageService := newTestService()
+	router := setupTestRouter(messageService)
+	for _, content := range []string{"one", "two", "three", "four", "five"} {
+		createTestMessage(t, messageService, content)
+	}
+
+	type page struct {
+		Messages   []models.Message `json:"messages"`
+		NextCursor string           `json:"next_cursor"`
+		PrevCursor string           `json:"prev_cursor"`
+		links      map[string]string
+	}
+	linkPattern := regexp.MustCompile(`<([^>]*)>; rel="(\w+)"`)
+	get := func(target string) page {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var p page
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+		p.links = map[string]string{}
+		for _, match := range linkPattern.FindAllStringSubmatch(w.Header().Get("Link"), -1) {
+			p.links[match[2]] = match[1]
+		}
+		return p
+	}
+	contents := func(p page) []string {
+		var out []string
+		for _, m := range p.Messages {
+			out = append(out, m.Content)
+		}
+		return out
+	}
+
+	first := get("/api/v1/messages?cursor=&page_size=2")
+	assert.Equal(t, []string{"five", "four"}, contents(first))
+	assert.Empty(t, first.PrevCursor)
+	assert.NotContains(t, first.links, "prev")
+	require.Contains(t, first.links, "next")
+
+	// A message created while paging must not shift later pages
+	createTestMessage(t, messageService, "six")
+
+	second := get(first.links["next"])
+	assert.Equal(t, []string{"three", "two"}, contents(second))
+	assert.NotEmpty(t, second.PrevCursor)
+
+	last := get("/api/v1/messages?page_size=2&cursor=" + second.NextCursor)
+	assert.Equal(t, []string{"one"}, contents(last))
+	assert.Empty(t, last.NextCursor)
+	assert.NotContains(t, last.links, "next")
+
+	back := get(last.links["prev"])
+	assert.Equal(t, []string{"three", "two"}, contents(back))
+
+	back = get(back.links["prev"])
+	assert.Equal(t, []string{"five", "four"}, contents(back))
+	back = get(back.links["prev"])
+	assert.Equal(t, []string{"six"}, contents(back))
+	assert.Empty(t, back.PrevCursor)
+
+	t.Run("tampered cursor", func(t *testing.T) {
+		cursor := []byte(second.NextCursor)
+		cursor[3] ^= 1
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/messages?cursor="+string(cursor), nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestListMessages_FilterAndSort(t *testing.T) {
+	messageService := newTestService()
+	router := setupTestRouter(messageService)
+	first := createTestMessage(t, messageService, "report: q1")
+	createTestMessage(t, messageService, "note")
+	createTestMessage(t, messageService, "report: q2")
+	createTestMessage(t, messageService, "report: q3")
+
+	list := func(query string) (*httptest.ResponseRecorder, []string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/messages?"+query, nil))
+
+		var response struct {
+			Messages []models.Message `json:"messages"`
+		}
+		var contents []string
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			for _, m := range response.Messages {
+				contents = append(contents, m.Content)
+			}
+		}
+		return w, contents
+	}
+
+	w, contents := list("content_prefix=report&sort=created_at")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"report: q1", "report: q2", "report: q3"}, contents)
+
+	w, contents = list("id=" + first.ID.String() + "&id=" + uuid.New().String())
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"report: q1"}, contents)
+
+	// Links keep the filters and sort
+	w, contents = list("cursor=&page_size=2&content_prefix=report&sort=created_at")
+	assert.Equal(t, []string{"report: q1", "report: q2"}, contents)
+	next := regexp.MustCompile(`<([^>]*)>; rel="next"`).FindStringSubmatch(w.Header().Get("Link"))
+	require.Len(t, next, 2)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, next[1], nil))
+	assert.Contains(t, w.Body.String(), "report: q3")
+	assert.NotContains(t, w.Body.String(), "note")
+
+	// A cursor only works with the sort it was issued for
+	var page struct {
+		NextCursor string `json:"next_cursor"`
+	}
+	w, _ = list("cursor=&page_size=1&sort=created_at")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	w, _ = list("page_size=1&sort=-created_at&cursor=" + page.NextCursor)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	for query, status := range map[string]int{
+		"sort=content":            http.StatusUnprocessableEntity,
+		"id=not-a-uuid":           http.StatusUnprocessableEntity,
+		"created_after=yesterday": http.StatusBadRequest,
+	} {
+		w, _ = list(query)
+		assert.Equal(t, status, w.Code, query)
+	}
+}
+
+func TestMessageLabelsAndMetadata(t *testing.T) {
+	messageService := newTestService()
+	router := setupTestRouter(messageService)
+
+	send := func(method, target, contentType, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(http.MethodPost, "/api/v1/messages", echo.MIMEApplicationJSON,
+		`{"content": "tagged", "labels": ["urgent"], "metadata": {"source": "web", "tags": {"a": 1}}}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var tagged models.Message
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tagged))
+	assert.Equal(t, []string{"urgent"}, tagged.Labels)
+	assert.Equal(t, "web", tagged.Metadata["source"])
+	createTestMessage(t, messageService, "plain")
+
+	// A merge patch edits the labels and metadata like any other field
+	w = send(http.MethodPatch, "/api/v1/messages/"+tagged.ID.String(), MIMEMergePatch,
+		`{"labels": ["urgent", "team:ops"], "metadata": {"tags": null}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var patched models.Message
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &patched))
+	assert.Equal(t, []string{"urgent", "team:ops"}, patched.Labels)
+	assert.Equal(t, map[string]interface{}{"source": "web"}, patched.Metadata)
+
+	for _, query := range []string{"label=urgent&label=team:ops", "metadata_key=source", "label=urgent&metadata_key=source"} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/messages?"+query, nil))
+		require.Equal(t, http.StatusOK, w.Code, query)
+		var response struct {
+			Messages []models.Message `json:"messages"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Messages, 1, query)
+		assert.Equal(t, tagged.ID, response.Messages[0].ID)
+	}
+
+	// Malformed labels are rejected by the service rules
+	w = send(http.MethodPost, "/api/v1/messages", echo.MIMEApplicationJSON, `{"content": "bad", "labels": ["no spaces"]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	w = send(http.MethodPut, "/api/v1/messages/"+tagged.ID.String(), echo.MIMEApplicationJSON, `{"content": "bad", "labels": ["x", "x"]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	w = send(http.MethodPost, "/api/v1/messages:batchCreate", echo.MIMEApplicationJSON,
+		`{"messages": [{"content": "ok", "labels": ["fine"]}, {"content": "bad", "labels": ["not fine"]}]}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var batch struct {
+		Results []BatchResult `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &batch))
+	assert.Equal(t, http.StatusCreated, batch.Results[0].Status)
+	assert.Equal(t, http.StatusUnprocessableEntity, batch.Results[1].Status)
+}
+
+func TestMessageThreads(t *testing.T) {
+	messageService := newTestService()
+	router := setupTestRouter(messageService)
+	root := createTestMessage(t, messageService, "root")
+
+	send := func(method, target, contentType, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(http.MethodPost, "/api/v1/messages", echo.MIMEApplicationJSON,
+		`{"content": "reply", "parent_id": "`+root.ID.String()+`"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var reply models.Message
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &reply))
+	require.NotNil(t, reply.ParentID)
+	assert.Equal(t, root.ID, *reply.ParentID)
+	assert.Equal(t, root.ID, reply.ThreadID)
+
+	w = send(http.MethodPost, "/api/v1/messages", echo.MIMEApplicationJSON,
+		`{"content": "orphan", "parent_id": "`+uuid.NewString()+`"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	w = send(http.MethodPost, "/api/v1/messages", echo.MIMEApplicationJSON, `{"content": "bad", "parent_id": "nope"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	// The parent is immutable
+	w = send(http.MethodPatch, "/api/v1/messages/"+reply.ID.String(), MIMEMergePatch, `{"parent_id": "`+uuid.NewString()+`"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	w = send(http.MethodGet, "/api/v1/messages/"+root.ID.String(), "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var got models.Message
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, int64(1), got.ReplyCount)
+
+	w = send(http.MethodGet, "/api/v1/messages/"+root.ID.String()+"/replies", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var replies struct {
+		Messages []models.Message `json:"messages"`
+		Total    int64            `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &replies))
+	assert.Equal(t, int64(1), replies.Total)
+	require.Len(t, replies.Messages, 1)
+	assert.Equal(t, reply.ID, replies.Messages[0].ID)
+
+	w = send(http.MethodGet, "/api/v1/threads/"+root.ID.String()+"?depth=5", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var thread models.Thread
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &thread))
+	assert.Equal(t, root.ID, thread.Root.ID)
+	require.Len(t, thread.Root.Replies, 1)
+	assert.Equal(t, "reply", thread.Root.Replies[0].Content)
+
+	w = send(http.MethodGet, "/api/v1/threads/"+root.ID.String()+"?depth=51", "", "")
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	w = send(http.MethodGet, "/api/v1/threads/"+uuid.NewString(), "", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestMessageExpiry(t *testing.T) {
+	messageService := newTestService()
+	router := setupTestRouter(messageService)
+
+	send := func(method, target, contentType, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(http.MethodPost, "/api/v1/messages", echo.MIMEApplicationJSON, `{"content": "notice", "ttl": 60}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var notice models.Message
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &notice))
+	require.NotNil(t, notice.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), *notice.ExpiresAt, 5*time.Second)
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	w = send(http.MethodPost, "/api/v1/messages", echo.MIMEApplicationJSON,
+		`{"content": "both", "ttl": 60, "expires_at": "`+expiresAt+`"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	w = send(http.MethodPost, "/api/v1/messages", echo.MIMEApplicationJSON, `{"content": "negative", "ttl": -1}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `{"field":"ttl","reason":"GT","description":"ttl must be greater than 0"}`)
+	w = send(http.MethodPost, "/api/v1/messages", echo.MIMEApplicationJSON,
+		`{"content": "stale", "expires_at": "2000-01-01T00:00:00Z"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	// A patched in ttl replaces the expiry
+	w = send(http.MethodPatch, "/api/v1/messages/"+notice.ID.String(), MIMEMergePatch, `{"ttl": 3600}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var patched models.Message
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &patched))
+	require.NotNil(t, patched.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *patched.ExpiresAt, 5*time.Second)
+
+	// PUT clears an expiry left out
+	w = send(http.MethodPut, "/api/v1/messages/"+notice.ID.String(), echo.MIMEApplicationJSON, `{"content": "permanent"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var updated models.Message
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Nil(t, updated.ExpiresAt)
+
+	soon := time.Now().Add(20 * time.Millisecond)
+	expiring := &models.Message{Content: "expiring", ExpiresAt: &soon}
+	require.NoError(t, messageService.CreateMessage(context.Background(), expiring))
+	time.Sleep(30 * time.Millisecond)
+	w = send(http.MethodGet, "/api/v1/messages/"+expiring.ID.String(), "", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestMessagePublishing(t *testing.T) {
+	const secret = "test-secret"
+	messageService := newTestService()
+	router := setupAuthTestRouter(messageService, secret)
+
+	token := func(userID string) string {
+		t.Helper()
+		access, _, err := auth.GenerateTokenPair(userID, nil, nil, secret)
+		require.NoError(t, err)
+		return access
+	}
+	alice, bob := token("alice"), token("bob")
+
+	send := func(method, target, token, contentType, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, contentType)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	publishAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	w := send(http.MethodPost, "/api/v1/messages", alice, echo.MIMEApplicationJSON,
+		`{"content": "announcement", "publish_at": "`+publishAt+`"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var scheduled models.Message
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &scheduled))
+	assert.Equal(t, models.StatusScheduled, scheduled.Status)
+
+	w = send(http.MethodPost, "/api/v1/messages", alice, echo.MIMEApplicationJSON, `{"content": "draft", "status": "draft"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var draft models.Message
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &draft))
+
+	w = send(http.MethodPost, "/api/v1/messages", alice, echo.MIMEApplicationJSON, `{"content": "archived", "status": "archived"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"INVALID_STATUS"`)
+	w = send(http.MethodPost, "/api/v1/messages", alice, echo.MIMEApplicationJSON, `{"content": "undated", "status": "scheduled"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	// Unpublished messages are hidden from everyone but their author
+	w = send(http.MethodGet, "/api/v1/messages/"+scheduled.ID.String(), bob, "", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = send(http.MethodGet, "/api/v1/messages/"+scheduled.ID.String(), alice, "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = send(http.MethodGet, "/api/v1/messages?status=scheduled", alice, "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var listed struct {
+		Messages []models.Message `json:"messages"`
+		Total    int64            `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	require.Len(t, listed.Messages, 1)
+	assert.Equal(t, scheduled.ID, listed.Messages[0].ID)
+	w = send(http.MethodGet, "/api/v1/messages?status=scheduled", bob, "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	assert.Empty(t, listed.Messages)
+
+	// Patching the status publishes the draft
+	w = send(http.MethodPatch, "/api/v1/messages/"+draft.ID.String(), alice, MIMEMergePatch, `{"status": "published"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = send(http.MethodGet, "/api/v1/messages/"+draft.ID.String(), bob, "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var published models.Message
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &published))
+	assert.Equal(t, models.StatusPublished, published.Status)
+	assert.NotNil(t, published.PublishAt)
+
+	// and it cannot be taken back
+	w = send(http.MethodPatch, "/api/v1/messages/"+draft.ID.String(), alice, MIMEMergePatch, `{"status": "draft"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestMessageRevisions(t *testing.T) {
+	messageService := newTestService()
+	router := setupTestRouter(messageService)
+	message := createTestMessage(t, messageService, "Test message")
+	require.NoError(t, messageService.UpdateMessage(context.Background(), &models.Message{ID: message.ID, Content: "Updated message"}))
+	base := "/api/v1/messages/" + message.ID.String() + "/revisions"
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, base, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Revisions []models.MessageRevision `json:"revisions"`
+		Total     int64                    `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, int64(2), list.Total)
+	require.Len(t, list.Revisions, 2)
+	assert.Equal(t, "Updated message", list.Revisions[0].Content)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, base+"/1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var revision models.MessageRevision
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &revision))
+	assert.Equal(t, "Test message", revision.Content)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, base+"/5", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, base+"/zero", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req := httptest.NewRequest(http.MethodPost, base+"/1/restore", nil)
+	req.Header.Set("If-Match", `"2"`)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+	var restored models.Message
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &restored))
+	assert.Equal(t, "Test message", restored.Content)
+}
+
+func TestMessageAttachments(t *testing.T) {
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	messageService := service.NewMessageService(service.NewMemoryStore(), cache.NewMemoryCache(), service.Options{
+		Blobs:             blobs,
+		MaxAttachmentSize: 1 << 10,
+		AttachmentTypes:   []string{"text/plain"},
+	})
+	router := setupTestRouter(messageService)
+	message := createTestMessage(t, messageService, "Test message")
+	base := "/api/v1/messages/" + message.ID.String() + "/attachments"
+
+	upload := func(filename string, content []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("file", filename)
+		require.NoError(t, err)
+		_, err = part.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, base, &body)
+		req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := upload("notes.txt", []byte("hello, attachments"))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var attachment models.Attachment
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &attachment))
+	assert.Equal(t, "notes.txt", attachment.Filename)
+	assert.Equal(t, int64(18), attachment.Size)
+
+	w = upload("big.txt", bytes.Repeat([]byte("a"), 2<<10))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	w = upload("image.gif", []byte("GIF89a\x01\x00\x01\x00"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, base, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Attachments []models.Attachment `json:"attachments"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Attachments, 1)
+	assert.Equal(t, attachment.ID, list.Attachments[0].ID)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, base+"/"+attachment.ID.String(), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hello, attachments", w.Body.String())
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, `attachment; filename=notes.txt`, w.Header().Get(echo.HeaderContentDisposition))
+	assert.Equal(t, `"`+attachment.Checksum+`"`, w.Header().Get("ETag"))
+
+	// Ranges are served for resumed downloads
+	req := httptest.NewRequest(http.MethodGet, base+"/"+attachment.ID.String(), nil)
+	req.Header.Set("Range", "bytes=7-17")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "attachments", w.Body.String())
+	assert.Equal(t, "bytes 7-17/18", w.Header().Get("Content-Range"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, base+"/"+uuid.New().String(), nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestMessageModeration(t *testing.T) {
+	messageService := service.NewMessageService(service.NewMemoryStore(), cache.NewMemoryCache(), service.Options{
+		Moderation: moderation.NewChain(moderation.NewMetrics(prometheus.NewRegistry()),
+			moderation.NewWordFilter([]string{"darn"}, moderation.ActionMask),
+			moderation.NewLinkFilter(0, moderation.ActionReject),
+		),
+	})
+	router := setupTestRouter(messageService)
+
+	create := func(content string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(CreateMessageRequest{Content: content})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/messages", bytes.NewBuffer(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := create("darn")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var message models.Message
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &message))
+	assert.Equal(t, "****", message.Content)
+
+	// Rejections list the violations
+	w = create("see https://spam.example")
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, apperr.MIMEProblem, w.Header().Get(echo.HeaderContentType))
+	var rejection apperr.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rejection))
+	assert.Equal(t, "CONTENT_REJECTED", rejection.Code)
+	assert.Equal(t, []apperr.Violation{{
+		Field:       "content",
+		Reason:      "links",
+		Description: "content has 1 links, at most 0 are allowed",
+	}}, rejection.Errors)
+
+	body, _ := json.Marshal(BatchUpdateMessagesRequest{Messages: []BatchUpdateMessage{{ID: message.ID.String(), Content: "www.spam.example"}}})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/messages:batchUpdate", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var batch struct {
+		Results []BatchResult `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &batch))
+	require.Len(t, batch.Results, 1)
+	assert.Equal(t, http.StatusUnprocessableEntity, batch.Results[0].Status)
+	assert.Equal(t, "content rejected: content has 1 links, at most 0 are allowed", batch.Results[0].Error)
+}
+
+func TestSearchMessages(t *testing.T) {
+	messageService := newTestService()
+	router := setupTestRouter(messageService)
+	createTestMessage(t, messageService, "Release notes for the deploy")
+	createTestMessage(t, messageService, "Notes on the release")
+	createTestMessage(t, messageService, "Deploying on Friday")
+	deleted := createTestMessage(t, messageService, "Old release notes")
+	require.NoError(t, messageService.DeleteMessage(context.Background(), deleted.ID))
+
+	search := func(query string) (int, []models.SearchResult, int64) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/messages/search?"+query, nil))
 
-	mockService.AssertExpectations(t)
+		var response struct {
+			Results []models.SearchResult `json:"results"`
+			Total   int64                 `json:"total"`
+		}
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		}
+		return w.Code, response.Results, response.Total
+	}
+
+	code, results, total := search(`q=%22release+notes%22`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, results, 1)
+	assert.Equal(t, "<mark>Release</mark> <mark>notes</mark> for the deploy", results[0].Snippet)
+
+	code, results, total = search("q=deploy*&page_size=1")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, results, 1)
+
+	code, results, _ = search("q=notes+release")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, results, 2)
+
+	code, _, _ = search("q=%22%22")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, _, _ = search("")
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+}
+
+func TestTrash(t *testing.T) {
+	messageService := newTestService()
+	router := setupTestRouter(messageService)
+	message := createTestMessage(t, messageService, "Test message")
+	require.NoError(t, messageService.DeleteMessage(context.Background(), message.ID))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/messages/trash", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var trash struct {
+		Messages []models.Message `json:"messages"`
+		Total    int64            `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &trash))
+	assert.Equal(t, int64(1), trash.Total)
+	require.Len(t, trash.Messages, 1)
+	assert.Equal(t, message.ID, trash.Messages[0].ID)
+	assert.NotNil(t, trash.Messages[0].DeletedAt)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/messages/"+message.ID.String()+"/restore", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Only messages in the trash can be restored
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/messages/"+message.ID.String()+"/restore", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/messages/"+message.ID.String(), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestPurgeMessage(t *testing.T) {
+	const secret = "test-secret"
+	messageService := newTestService()
+	router := setupAuthTestRouter(messageService, secret)
+	message := createTestMessage(t, messageService, "Test message")
+
+	purge := func(perms ...string) int {
+		t.Helper()
+		access, err := jwt.NewWithClaims(jwt.SigningMethodHS256, auth.Claims{
+			UserID:      "admin",
+			Permissions: perms,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		}).SignedString([]byte(secret))
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/admin/messages/"+message.ID.String(), nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+access)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Purging requires the purge permission
+	assert.Equal(t, http.StatusForbidden, purge())
+	assert.Equal(t, http.StatusNoContent, purge("messages:purge"))
+
+	_, err := messageService.GetMessage(context.Background(), message.ID)
+	assert.ErrorIs(t, err, service.ErrMessageNotFound)
+
+	assert.Equal(t, http.StatusNotFound, purge("messages:purge"))
+}
+
+func TestBatchMessages(t *testing.T) {
+	messageService := newTestService()
+	router := setupTestRouter(messageService)
+
+	batch := func(method string, body interface{}) []BatchResult {
+		t.Helper()
+		payload, err := json.Marshal(body)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/messages:"+method, bytes.NewReader(payload))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp struct {
+			Results []BatchResult `json:"results"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Results
+	}
+	statuses := func(results []BatchResult) []int {
+		codes := make([]int, len(results))
+		for i, result := range results {
+			codes[i] = result.Status
+		}
+		return codes
+	}
+
+	// A failed item fails on its own unless the batch is atomic
+	results := batch("batchCreate", BatchCreateMessagesRequest{
+		Messages: []CreateMessageRequest{
+			{Content: "first"},
+			{Content: ""},
+			{Content: "second"},
+			{Content: "orphan", ParentID: uuid.New().String()},
+			{Content: "orphan", ParentID: "not-a-uuid"},
+		},
+	})
+	assert.Equal(t, []int{http.StatusCreated, http.StatusUnprocessableEntity, http.StatusCreated, http.StatusUnprocessableEntity, http.StatusUnprocessableEntity}, statuses(results))
+	assert.Equal(t, "INVALID_CONTENT", results[1].Code)
+	assert.Equal(t, "PARENT_NOT_FOUND", results[3].Code)
+	assert.Equal(t, "INVALID_REQUEST", results[4].Code)
+	first, second := results[0].Message, results[2].Message
+	assert.Equal(t, "second", second.Content)
+
+	results = batch("batchCreate", BatchCreateMessagesRequest{
+		Messages: []CreateMessageRequest{{Content: "third"}, {Content: ""}},
+		Atomic:   true,
+	})
+	assert.Equal(t, []int{http.StatusFailedDependency, http.StatusUnprocessableEntity}, statuses(results))
+
+	_, total, err := messageService.ListMessagesPaginated(context.Background(), service.MessageFilter{}, service.Sort{}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+
+	// A failed atomic update rolls back the items before it
+	results = batch("batchUpdate", BatchUpdateMessagesRequest{
+		Messages: []BatchUpdateMessage{
+			{ID: first.ID.String(), Content: "first, edited"},
+			{ID: second.ID.String(), Content: "second, edited", Version: 2},
+		},
+		Atomic: true,
+	})
+	assert.Equal(t, []int{http.StatusFailedDependency, http.StatusPreconditionFailed}, statuses(results))
+
+	results = batch("batchUpdate", BatchUpdateMessagesRequest{
+		Messages: []BatchUpdateMessage{
+			{ID: first.ID.String(), Content: "first, edited", Version: 1},
+			{ID: uuid.New().String(), Content: "missing"},
+		},
+	})
+	assert.Equal(t, []int{http.StatusOK, http.StatusNotFound}, statuses(results))
+	assert.Equal(t, int64(2), results[0].Message.Version)
+
+	results = batch("batchDelete", BatchDeleteMessagesRequest{
+		IDs:    []string{second.ID.String(), uuid.New().String()},
+		Atomic: true,
+	})
+	assert.Equal(t, []int{http.StatusFailedDependency, http.StatusNotFound}, statuses(results))
+
+	results = batch("batchDelete", BatchDeleteMessagesRequest{
+		IDs: []string{first.ID.String(), second.ID.String(), "not-a-uuid"},
+	})
+	assert.Equal(t, []int{http.StatusNoContent, http.StatusNoContent, http.StatusBadRequest}, statuses(results))
+
+	_, total, err = messageService.ListDeletedMessages(context.Background(), 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+}
+
+func TestMessageOwnership(t *testing.T) {
+	const secret = "test-secret"
+	messageService := newTestService()
+	router := setupAuthTestRouter(messageService, secret)
+
+	token := func(userID string, perms ...string) string {
+		t.Helper()
+		access, _, err := auth.GenerateTokenPair(userID, nil, perms, secret)
+		require.NoError(t, err)
+		return access
+	}
+	alice, bob, moderator := token("alice"), token("bob"), token("carol", service.PermissionModerate)
+
+	send := func(method, target, token string, body interface{}) *httptest.ResponseRecorder {
+		t.Helper()
+		var payload []byte
+		if body != nil {
+			var err error
+			payload, err = json.Marshal(body)
+			require.NoError(t, err)
+		}
+		req := httptest.NewRequest(method, target, bytes.NewReader(payload))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(http.MethodPost, "/api/v1/messages", "", CreateMessageRequest{Content: "anonymous"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = send(http.MethodGet, "/api/v1/messages", "not-a-token", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = send(http.MethodPost, "/api/v1/messages", alice, CreateMessageRequest{Content: "by alice"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var message models.Message
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &message))
+	assert.Equal(t, "alice", message.AuthorID)
+	target := "/api/v1/messages/" + message.ID.String()
+
+	// Only the author or a moderator may change the message
+	w = send(http.MethodPut, target, bob, UpdateMessageRequest{Content: "by bob"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = send(http.MethodDelete, target, bob, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = send(http.MethodPost, "/api/v1/messages:batchDelete", bob, BatchDeleteMessagesRequest{IDs: []string{message.ID.String()}})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":403`)
+
+	w = send(http.MethodPut, target, moderator, UpdateMessageRequest{Content: "moderated"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &message))
+	assert.Equal(t, "alice", message.AuthorID, "editing keeps the author")
+
+	w = send(http.MethodPost, "/api/v1/messages", bob, CreateMessageRequest{Content: "by bob"})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	// mine lists the caller's own messages
+	var list struct {
+		Messages []models.Message `json:"messages"`
+		Total    int64            `json:"total"`
+	}
+	w = send(http.MethodGet, "/api/v1/messages?mine=true", alice, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Messages, 1)
+	assert.Equal(t, "moderated", list.Messages[0].Content)
+	assert.Equal(t, int64(1), list.Total)
+
+	w = send(http.MethodGet, "/api/v1/messages", alice, nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, int64(2), list.Total)
+
+	w = send(http.MethodDelete, target, alice, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestMessageTenants(t *testing.T) {
+	const secret = "test-secret"
+	messageService := newTestService()
+	router := setupAuthTestRouter(messageService, secret)
+
+	token := func(tenantID string) string {
+		t.Helper()
+		access, err := jwt.NewWithClaims(jwt.SigningMethodHS256, auth.Claims{
+			UserID:   "alice",
+			TenantID: tenantID,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		}).SignedString([]byte(secret))
+		require.NoError(t, err)
+		return access
+	}
+	acme, globex, untenanted := token("acme"), token("globex"), token("")
+
+	send := func(method, target, token, tenantHeader string, body interface{}) *httptest.ResponseRecorder {
+		t.Helper()
+		payload, err := json.Marshal(body)
+		require.NoError(t, err)
+		req := httptest.NewRequest(method, target, bytes.NewReader(payload))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		if tenantHeader != "" {
+			req.Header.Set("X-Tenant-ID", tenantHeader)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(http.MethodPost, "/api/v1/messages", acme, "", CreateMessageRequest{Content: "acme only"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var message models.Message
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &message))
+	target := "/api/v1/messages/" + message.ID.String()
+
+	// The token's tenant wins; the header may only repeat it
+	w = send(http.MethodGet, target, acme, "acme", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = send(http.MethodGet, target, acme, "globex", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Other tenants get 404, as if the message did not exist
+	w = send(http.MethodGet, target, globex, "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = send(http.MethodPut, target, untenanted, "", UpdateMessageRequest{Content: "default tenant"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// A token without a tenant acts for the default one; the header cannot
+	// pick another
+	w = send(http.MethodGet, target, untenanted, "acme", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = send(http.MethodGet, "/api/v1/messages", untenanted, "default", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
 }
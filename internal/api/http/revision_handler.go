@@ -0,0 +1,139 @@
+package http
+
+import (
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go-boilerplate/internal/middleware"
+	"net/http"
+	"strconv"
+)
+
+type ListRevisionsRequest struct {
+	Page     uint32 `query:"page" validate:"gte=0"`
+	PageSize uint32 `query:"page_size" validate:"gt=0,lte=100"`
+}
+
+// ListRevisions godoc
+// @Summary List the revisions of a message
+// @Description Get the revision history of a message, newest first
+// @Tags messages
+// @Produce json
+// @Param id path string true "Message ID"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {array} models.MessageRevision
+// @Failure 404 {object} apperr.Problem
+// @Router /api/v1/messages/{id}/revisions [get]
+func (h *MessageHandler) ListRevisions(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid UUID format")
+	}
+
+	req := &ListRevisionsRequest{}
+	if err := c.Bind(req); err != nil {
+		return middleware.InvalidRequest(err)
+	}
+
+	// Set defaults if not provided
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	if err := c.Validate(req); err != nil {
+		return middleware.InvalidRequest(err)
+	}
+
+	revisions, total, err := h.messageService.ListRevisions(c.Request().Context(), id, req.Page, req.PageSize)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"revisions": revisions,
+		"total":     total,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+	})
+}
+
+// GetRevision godoc
+// @Summary Get a revision of a message
+// @Description Get one revision of a message by its revision number
+// @Tags messages
+// @Produce json
+// @Param id path string true "Message ID"
+// @Param rev path int true "Revision number"
+// @Success 200 {object} models.MessageRevision
+// @Failure 404 {object} apperr.Problem
+// @Router /api/v1/messages/{id}/revisions/{rev} [get]
+func (h *MessageHandler) GetRevision(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid UUID format")
+	}
+
+	rev, err := parseRevision(c.Param("rev"))
+	if err != nil {
+		return err
+	}
+
+	revision, err := h.messageService.GetRevision(c.Request().Context(), id, rev)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, revision)
+}
+
+// RestoreRevision godoc
+// @Summary Restore a revision of a message
+// @Description Make the content of an earlier revision current again. This
+// @Description records a new revision; the history is never rewritten.
+// @Tags messages
+// @Produce json
+// @Param id path string true "Message ID"
+// @Param rev path int true "Revision number"
+// @Param If-Match header string false "Expected entity tag"
+// @Success 200 {object} models.Message
+// @Header 200 {string} ETag "Entity tag of the new message version"
+// @Failure 403 {object} apperr.Problem
+// @Failure 404 {object} apperr.Problem
+// @Failure 412 {object} apperr.Problem
+// @Router /api/v1/messages/{id}/revisions/{rev}/restore [post]
+func (h *MessageHandler) RestoreRevision(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid UUID format")
+	}
+
+	rev, err := parseRevision(c.Param("rev"))
+	if err != nil {
+		return err
+	}
+
+	version, err := parseIfMatch(c.Request().Header.Get("If-Match"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	message, err := h.messageService.RestoreRevision(c.Request().Context(), id, rev, version)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set("ETag", etag(message.Version))
+	return c.JSON(http.StatusOK, message)
+}
+
+// parseRevision parses a revision number path parameter
+func parseRevision(param string) (int64, error) {
+	rev, err := strconv.ParseInt(param, 10, 64)
+	if err != nil || rev <= 0 {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "revision must be a positive integer")
+	}
+	return rev, nil
+}
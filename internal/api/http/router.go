@@ -2,29 +2,38 @@ package http
 
 import (
 	"github.com/labstack/echo/v4"
-	echomiddleware "github.com/labstack/echo/v4/middleware"
-	"go-boilerplate/internal/service"
+	"go-boilerplate/internal/middleware"
 )
 
-// SetupRouter initializes the HTTP router and registers all routes
-func SetupRouter(messageService *service.MessageService) *echo.Echo {
-	e := echo.New()
-
-	// Add global middleware
-	e.Use(echomiddleware.Logger())
-	e.Use(echomiddleware.Recover())
-	e.Use(echomiddleware.CORS())
-
-	// API v1 routes
-	handler := NewMessageHandler(messageService)
-	v1 := e.Group("/api/v1")
+// RegisterRoutes registers the routes of the REST API under /api/v1, behind
+// authentication by tokens signed with jwtSecret and tenant resolution from
+// tenantHeader. It is the only route table of the API: the server and the
+// handler tests both use it.
+func RegisterRoutes(e *echo.Echo, handler *MessageHandler, jwtSecret, tenantHeader string) {
+	v1 := e.Group("/api/v1", middleware.Authenticate(jwtSecret), middleware.Tenant(tenantHeader))
 	messages := v1.Group("/messages")
 
 	messages.POST("", handler.CreateMessage)
 	messages.GET("", handler.ListMessages)
+	messages.POST("\\:batchCreate", handler.BatchCreateMessages)
+	messages.POST("\\:batchUpdate", handler.BatchUpdateMessages)
+	messages.POST("\\:batchDelete", handler.BatchDeleteMessages)
+	messages.GET("/trash", handler.ListDeletedMessages)
+	messages.GET("/search", handler.SearchMessages)
 	messages.GET("/:id", handler.GetMessage)
 	messages.PUT("/:id", handler.UpdateMessage)
+	messages.PATCH("/:id", handler.PatchMessage)
 	messages.DELETE("/:id", handler.DeleteMessage)
+	messages.GET("/:id/replies", handler.ListReplies)
+	messages.GET("/:id/revisions", handler.ListRevisions)
+	messages.GET("/:id/revisions/:rev", handler.GetRevision)
+	messages.POST("/:id/revisions/:rev/restore", handler.RestoreRevision)
+	messages.POST("/:id/attachments", handler.UploadAttachment)
+	messages.GET("/:id/attachments", handler.ListAttachments)
+	messages.GET("/:id/attachments/:attachmentId", handler.DownloadAttachment)
+	messages.POST("/:id/restore", handler.RestoreMessage)
+	v1.GET("/threads/:id", handler.GetThread)
 
-	return e
+	admin := v1.Group("/admin", middleware.RBAC("messages", "purge"))
+	admin.DELETE("/messages/:id", handler.PurgeMessage)
 }
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"github.com/labstack/echo/v4"
+	"go-boilerplate/internal/middleware"
+	"net/http"
+)
+
+type SearchMessagesRequest struct {
+	Query    string `query:"q" validate:"required,max=256"`
+	Page     uint32 `query:"page" validate:"gte=0"`
+	PageSize uint32 `query:"page_size" validate:"gt=0,lte=100"`
+}
+
+// SearchMessages godoc
+// @Summary Search messages
+// @Description Full-text search over the content of live messages, best
+// @Description match first. All terms must match; quote words to match them
+// @Description as a phrase and end a word with * to match it as a prefix,
+// @Description as in: "release notes" deploy*. Each result carries a snippet
+// @Description with the matches wrapped in <mark> tags; snippets are not
+// @Description HTML escaped.
+// @Tags messages
+// @Produce json
+// @Param q query string true "Search query"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {array} models.SearchResult
+// @Failure 400 {object} apperr.Problem
+// @Failure 422 {object} apperr.Problem
+// @Router /api/v1/messages/search [get]
+func (h *MessageHandler) SearchMessages(c echo.Context) error {
+	req := &SearchMessagesRequest{}
+
+	if err := c.Bind(req); err != nil {
+		return middleware.InvalidRequest(err)
+	}
+
+	// Set defaults if not provided
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	if err := c.Validate(req); err != nil {
+		return middleware.InvalidRequest(err)
+	}
+
+	results, total, err := h.messageService.SearchMessages(c.Request().Context(), req.Query, req.Page, req.PageSize)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"results":   results,
+		"total":     total,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+	})
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go-boilerplate/internal/middleware"
+	"net/http"
+)
+
+type ListRepliesRequest struct {
+	Page     uint32 `query:"page" validate:"gte=0"`
+	PageSize uint32 `query:"page_size" validate:"gt=0,lte=100"`
+}
+
+// GetThreadRequest limits how deep a thread is returned. Zero returns it
+// as deep as the server allows.
+type GetThreadRequest struct {
+	Depth int `query:"depth" validate:"gte=0,lte=50"`
+}
+
+// ListReplies godoc
+// @Summary List the replies to a message
+// @Description Get the live direct replies to a message, oldest first
+// @Tags threads
+// @Produce json
+// @Param id path string true "Message ID"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {array} models.Message
+// @Failure 404 {object} apperr.Problem
+// @Router /api/v1/messages/{id}/replies [get]
+func (h *MessageHandler) ListReplies(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid UUID format")
+	}
+
+	req := &ListRepliesRequest{}
+	if err := c.Bind(req); err != nil {
+		return middleware.InvalidRequest(err)
+	}
+
+	// Set defaults if not provided
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	if err := c.Validate(req); err != nil {
+		return middleware.InvalidRequest(err)
+	}
+
+	replies, total, err := h.messageService.ListReplies(c.Request().Context(), id, req.Page, req.PageSize)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"messages":  replies,
+		"total":     total,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+	})
+}
+
+// GetThread godoc
+// @Summary Get a thread
+// @Description Get the thread started by a message as a tree of replies,
+// @Description oldest first at every level. Deleted, expired and unpublished
+// @Description messages with live replies below them are kept as tombstones
+// @Description without content.
+// @Description truncated is set when replies were cut off by the depth or
+// @Description by the size limit of 1000 messages.
+// @Tags threads
+// @Produce json
+// @Param id path string true "Thread ID, the ID of its first message"
+// @Param depth query int false "Levels of replies to return, 1 to 50 (default 50)"
+// @Success 200 {object} models.Thread
+// @Failure 400 {object} apperr.Problem
+// @Failure 404 {object} apperr.Problem
+// @Failure 422 {object} apperr.Problem
+// @Router /api/v1/threads/{id} [get]
+func (h *MessageHandler) GetThread(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid UUID format")
+	}
+
+	req := &GetThreadRequest{}
+	if err := c.Bind(req); err != nil {
+		return middleware.InvalidRequest(err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return middleware.InvalidRequest(err)
+	}
+
+	thread, err := h.messageService.GetThread(c.Request().Context(), id, req.Depth)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, thread)
+}
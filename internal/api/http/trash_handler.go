@@ -0,0 +1,105 @@
+package http
+
+import (
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go-boilerplate/internal/middleware"
+	"net/http"
+)
+
+type ListDeletedMessagesRequest struct {
+	Page     uint32 `query:"page" validate:"gte=0"`
+	PageSize uint32 `query:"page_size" validate:"gt=0,lte=100"`
+}
+
+// ListDeletedMessages godoc
+// @Summary List deleted messages
+// @Description Get the messages in the trash, most recently deleted first.
+// @Description Callers only see the messages they wrote, unless they hold
+// @Description messages:moderate. Deleted messages can be restored until
+// @Description the retention job purges them.
+// @Tags messages
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {array} models.Message
+// @Router /api/v1/messages/trash [get]
+func (h *MessageHandler) ListDeletedMessages(c echo.Context) error {
+	req := &ListDeletedMessagesRequest{}
+
+	if err := c.Bind(req); err != nil {
+		return middleware.InvalidRequest(err)
+	}
+
+	// Set defaults if not provided
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	if err := c.Validate(req); err != nil {
+		return middleware.InvalidRequest(err)
+	}
+
+	messages, total, err := h.messageService.ListDeletedMessages(c.Request().Context(), req.Page, req.PageSize)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"messages":  messages,
+		"total":     total,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+	})
+}
+
+// RestoreMessage godoc
+// @Summary Restore a deleted message
+// @Description Take a message out of the caller's trash
+// @Tags messages
+// @Produce json
+// @Param id path string true "Message ID"
+// @Success 200 {object} models.Message
+// @Header 200 {string} ETag "Entity tag of the message version"
+// @Failure 404 {object} apperr.Problem
+// @Router /api/v1/messages/{id}/restore [post]
+func (h *MessageHandler) RestoreMessage(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid UUID format")
+	}
+
+	message, err := h.messageService.RestoreMessage(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set("ETag", etag(message.Version))
+	return c.JSON(http.StatusOK, message)
+}
+
+// PurgeMessage godoc
+// @Summary Permanently delete a message
+// @Description Remove a message and its revisions for good, whether or not
+// @Description it is in the trash. Requires the messages:purge permission.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Message ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} apperr.Problem
+// @Router /api/v1/admin/messages/{id} [delete]
+func (h *MessageHandler) PurgeMessage(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid UUID format")
+	}
+
+	if err := h.messageService.PurgeMessage(c.Request().Context(), id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
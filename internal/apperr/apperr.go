@@ -0,0 +1,144 @@
+// Package apperr is the error model shared by the services and the APIs.
+//
+// The services report the failures a client can act on as *Error values,
+// usually sentinels wrapped with fmt.Errorf("%w: ...") to say what exactly
+// was wrong. Each has a Kind, which says what the client can do about it and
+// decides the HTTP status and gRPC code, and a Code that clients can match
+// on and that never changes once published. Errors that are not *Error are
+// internal: their text is logged, never sent to clients.
+//
+// ToProblem and ToStatus map any error to what the REST and gRPC APIs send,
+// RFC 9457 problem details and a status with error details, so that both
+// APIs report a failure the same way.
+package apperr
+
+import (
+	"errors"
+	"time"
+)
+
+// Kind is the class of a failure, named after the gRPC code it maps to.
+type Kind int
+
+const (
+	// Internal is a failure of the server. Errors that are not *Error are
+	// Internal.
+	Internal Kind = iota
+	// InvalidArgument is a request that can never succeed as it is.
+	InvalidArgument
+	// NotFound is a request for something that does not exist, or that the
+	// caller may not see.
+	NotFound
+	// Conflict is a request that clashes with another one, and may succeed
+	// if sent again.
+	Conflict
+	// FailedPrecondition is a request made on a state that has changed,
+	// such as a stale version.
+	FailedPrecondition
+	// PermissionDenied is a request the caller is not allowed to make.
+	PermissionDenied
+	// Unavailable is a request the server cannot serve for now; RetryAfter
+	// says when to try again.
+	Unavailable
+)
+
+// Domain is the ErrorInfo domain of the errors of the service.
+const Domain = "go-boilerplate"
+
+// Error is a failure the client can act on.
+type Error struct {
+	Kind Kind
+	// Code identifies the error, in UPPER_SNAKE_CASE, e.g. MESSAGE_NOT_FOUND.
+	Code string
+	// Message describes the error for developers.
+	Message string
+	// Field is the request field at fault, if there is one.
+	Field string
+	// RetryAfter is how long to wait before retrying, if that may help.
+	RetryAfter time.Duration
+	// HTTPStatus overrides the HTTP status of Kind, for the errors that
+	// HTTP tells apart more finely than gRPC, e.g. 413 for an upload that
+	// is too large.
+	HTTPStatus int
+}
+
+// New returns an Error of kind with code and message. Errors are matched
+// with errors.Is by identity, so sentinels set their field and retry delay
+// when declared rather than copying with the With methods later.
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// WithField returns a copy of e blaming field.
+func (e *Error) WithField(field string) *Error {
+	copy := *e
+	copy.Field = field
+	return &copy
+}
+
+// WithRetryAfter returns a copy of e advising to retry after delay.
+func (e *Error) WithRetryAfter(delay time.Duration) *Error {
+	copy := *e
+	copy.RetryAfter = delay
+	return &copy
+}
+
+// WithHTTPStatus returns a copy of e reported over HTTP with status.
+func (e *Error) WithHTTPStatus(status int) *Error {
+	copy := *e
+	copy.HTTPStatus = status
+	return &copy
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Violation is a problem with one field of a request.
+type Violation struct {
+	Field string `json:"field"`
+	// Reason identifies the problem, in the terms of whatever found it.
+	Reason string `json:"reason,omitempty"`
+	// Description says what is wrong, for the author of the request.
+	Description string `json:"description"`
+}
+
+// Violator is implemented by errors that find several problems with a
+// request. ToProblem and ToStatus report the violations it returns instead
+// of the Field of the *Error it wraps.
+type Violator interface {
+	FieldViolations() []Violation
+}
+
+// From returns the *Error in err's chain, if there is one.
+func From(err error) (*Error, bool) {
+	var e *Error
+	ok := errors.As(err, &e)
+	return e, ok
+}
+
+// detail describes err, whose *Error is e, to clients. The causes of
+// internal errors and outages are left out: they are for the logs.
+func detail(err error, e *Error) string {
+	switch e.Kind {
+	case Internal:
+		return "internal error"
+	case Unavailable:
+		return e.Message
+	default:
+		return err.Error()
+	}
+}
+
+// violations returns the field violations reported for err, whose *Error
+// is e
+func violations(err error, e *Error) []Violation {
+	var violator Violator
+	if errors.As(err, &violator) {
+		return violator.FieldViolations()
+	}
+	if e.Field != "" {
+		return []Violation{{Field: e.Field, Reason: e.Code, Description: err.Error()}}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	errNotFound = New(NotFound, "THING_NOT_FOUND", "thing not found")
+	errInvalid  = New(InvalidArgument, "INVALID_NAME", "invalid name").WithField("name")
+	errTooLarge = New(InvalidArgument, "TOO_LARGE", "too large").WithHTTPStatus(http.StatusRequestEntityTooLarge)
+	errDown     = New(Unavailable, "DOWN", "store unavailable").WithRetryAfter(1500 * time.Millisecond)
+)
+
+// rejection reports several violations of its own
+type rejection struct{}
+
+func (rejection) Error() string { return "rejected" }
+
+func (rejection) Unwrap() error { return errInvalid }
+
+func (rejection) FieldViolations() []Violation {
+	return []Violation{{Field: "a", Reason: "A", Description: "bad a"}, {Field: "b", Reason: "B", Description: "bad b"}}
+}
+
+func TestToProblem(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want *Problem
+	}{
+		{
+			name: "wrapped",
+			err:  fmt.Errorf("%w: %s", errNotFound, "42"),
+			want: &Problem{Type: "about:blank", Title: "Not Found", Status: http.StatusNotFound, Detail: "thing not found: 42", Code: "THING_NOT_FOUND"},
+		},
+		{
+			name: "field",
+			err:  fmt.Errorf("%w: must not be empty", errInvalid),
+			want: &Problem{
+				Type: "about:blank", Title: "Unprocessable Entity", Status: http.StatusUnprocessableEntity,
+				Detail: "invalid name: must not be empty", Code: "INVALID_NAME",
+				Errors: []Violation{{Field: "name", Reason: "INVALID_NAME", Description: "invalid name: must not be empty"}},
+			},
+		},
+		{
+			name: "violator",
+			err:  rejection{},
+			want: &Problem{
+				Type: "about:blank", Title: "Unprocessable Entity", Status: http.StatusUnprocessableEntity,
+				Detail: "rejected", Code: "INVALID_NAME",
+				Errors: []Violation{{Field: "a", Reason: "A", Description: "bad a"}, {Field: "b", Reason: "B", Description: "bad b"}},
+			},
+		},
+		{
+			name: "HTTP status",
+			err:  errTooLarge,
+			want: &Problem{Type: "about:blank", Title: "Request Entity Too Large", Status: http.StatusRequestEntityTooLarge, Detail: "too large", Code: "TOO_LARGE"},
+		},
+		{
+			name: "unavailable",
+			err:  fmt.Errorf("%w: dial tcp: connection refused", errDown),
+			want: &Problem{Type: "about:blank", Title: "Service Unavailable", Status: http.StatusServiceUnavailable, Detail: "store unavailable", Code: "DOWN", RetryAfter: 2},
+		},
+		{
+			name: "internal",
+			err:  errors.New("pq: relation does not exist"),
+			want: &Problem{Type: "about:blank", Title: "Internal Server Error", Status: http.StatusInternalServerError, Detail: "internal error", Code: "INTERNAL_SERVER_ERROR"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ToProblem(tt.err))
+		})
+	}
+}
+
+func TestToStatus(t *testing.T) {
+	st := ToStatus(fmt.Errorf("%w: must not be empty", errInvalid))
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Equal(t, "invalid name: must not be empty", st.Message())
+	require.Len(t, st.Details(), 2)
+	assert.Equal(t, "INVALID_NAME", st.Details()[0].(*errdetails.ErrorInfo).Reason)
+	assert.Equal(t, Domain, st.Details()[0].(*errdetails.ErrorInfo).Domain)
+	assert.Equal(t, "name", st.Details()[1].(*errdetails.BadRequest).FieldViolations[0].Field)
+
+	st = ToStatus(fmt.Errorf("%w: dial tcp: connection refused", errDown))
+	assert.Equal(t, codes.Unavailable, st.Code())
+	assert.Equal(t, "store unavailable", st.Message())
+	require.Len(t, st.Details(), 2)
+	assert.Equal(t, 1500*time.Millisecond, st.Details()[1].(*errdetails.RetryInfo).RetryDelay.AsDuration())
+
+	st = ToStatus(rejection{})
+	require.Len(t, st.Details(), 2)
+	assert.Len(t, st.Details()[1].(*errdetails.BadRequest).FieldViolations, 2)
+
+	// Statuses are kept, other errors are hidden
+	assert.Equal(t, codes.OutOfRange, ToStatus(status.Error(codes.OutOfRange, "too far")).Code())
+	st = ToStatus(errors.New("pq: relation does not exist"))
+	assert.Equal(t, codes.Internal, st.Code())
+	assert.Equal(t, "internal error", st.Message())
+}
+
+func TestToGRPC(t *testing.T) {
+	assert.NoError(t, ToGRPC(nil))
+
+	// The cause is logged but not sent
+	err := ToGRPC(errors.New("pq: relation does not exist"))
+	assert.Equal(t, "pq: relation does not exist", err.Error())
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "internal error", status.Convert(err).Message())
+
+	err = ToGRPC(fmt.Errorf("%w: 42", errNotFound))
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.ErrorIs(t, err, errNotFound)
+}
@@ -0,0 +1,94 @@
+package apperr
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// grpcCodes maps each Kind to the gRPC code reporting it.
+var grpcCodes = map[Kind]codes.Code{
+	Internal:           codes.Internal,
+	InvalidArgument:    codes.InvalidArgument,
+	NotFound:           codes.NotFound,
+	Conflict:           codes.Aborted,
+	FailedPrecondition: codes.FailedPrecondition,
+	PermissionDenied:   codes.PermissionDenied,
+	Unavailable:        codes.Unavailable,
+}
+
+// ToStatus returns the gRPC status reporting err. An *Error is reported
+// with an ErrorInfo carrying its code, a BadRequest listing its field
+// violations and a RetryInfo if it has a retry delay. A status error is
+// returned as it is. Other errors are reported as an Internal status that
+// does not tell what went wrong.
+func ToStatus(err error) *status.Status {
+	e, ok := From(err)
+	if !ok {
+		if st, ok := status.FromError(err); ok {
+			return st
+		}
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			return status.FromContextError(err)
+		}
+		return status.New(codes.Internal, "internal error")
+	}
+
+	details := []protoadapt.MessageV1{
+		&errdetails.ErrorInfo{Reason: e.Code, Domain: Domain},
+	}
+	if e.Kind != Internal {
+		if fields := violations(err, e); len(fields) > 0 {
+			badRequest := &errdetails.BadRequest{}
+			for _, field := range fields {
+				badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+					Field:       field.Field,
+					Reason:      field.Reason,
+					Description: field.Description,
+				})
+			}
+			details = append(details, badRequest)
+		}
+		if e.RetryAfter > 0 {
+			details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(e.RetryAfter)})
+		}
+	}
+
+	st := status.New(grpcCodes[e.Kind], detail(err, e))
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st
+}
+
+// ToGRPC returns an error reporting err to gRPC clients as ToStatus does,
+// whose Error still describes err so that it can be logged.
+func ToGRPC(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &grpcError{err: err, status: ToStatus(err)}
+}
+
+// grpcError is an error with the status it is reported with
+type grpcError struct {
+	err    error
+	status *status.Status
+}
+
+func (e *grpcError) Error() string {
+	return e.err.Error()
+}
+
+func (e *grpcError) GRPCStatus() *status.Status {
+	return e.status
+}
+
+func (e *grpcError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,79 @@
+package apperr
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+)
+
+// MIMEProblem is the media type of problem details.
+const MIMEProblem = "application/problem+json"
+
+// Problem is an RFC 9457 problem details object, extended with the code of
+// the error and the field violations.
+type Problem struct {
+	Type     string      `json:"type"`
+	Title    string      `json:"title"`
+	Status   int         `json:"status"`
+	Detail   string      `json:"detail,omitempty"`
+	Instance string      `json:"instance,omitempty"`
+	Code     string      `json:"code"`
+	Errors   []Violation `json:"errors,omitempty"`
+	// RetryAfter is sent as the Retry-After header, in seconds.
+	RetryAfter int `json:"-"`
+}
+
+// httpStatuses maps each Kind to the HTTP status reporting it.
+var httpStatuses = map[Kind]int{
+	Internal:           http.StatusInternalServerError,
+	InvalidArgument:    http.StatusUnprocessableEntity,
+	NotFound:           http.StatusNotFound,
+	Conflict:           http.StatusConflict,
+	FailedPrecondition: http.StatusPreconditionFailed,
+	PermissionDenied:   http.StatusForbidden,
+	Unavailable:        http.StatusServiceUnavailable,
+}
+
+// ToProblem returns the problem details reporting err. Errors that are not
+// *Error are reported as a 500 that does not tell what went wrong.
+func ToProblem(err error) *Problem {
+	e, ok := From(err)
+	if !ok {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return NewProblem(http.StatusGatewayTimeout, "request timed out")
+		}
+		return NewProblem(http.StatusInternalServerError, "internal error")
+	}
+
+	status := httpStatuses[e.Kind]
+	if e.HTTPStatus != 0 {
+		status = e.HTTPStatus
+	}
+	problem := NewProblem(status, detail(err, e))
+	if e.Code != "" {
+		problem.Code = e.Code
+	}
+	if e.Kind != Internal {
+		problem.Errors = violations(err, e)
+	}
+	if e.RetryAfter > 0 {
+		problem.RetryAfter = int(math.Ceil(e.RetryAfter.Seconds()))
+	}
+	return problem
+}
+
+// NewProblem returns the problem details of a failure with status that has
+// no *Error, such as a malformed request rejected by a handler. Its code is
+// derived from the status, e.g. BAD_REQUEST.
+func NewProblem(status int, detail string) *Problem {
+	title := http.StatusText(status)
+	return &Problem{
+		Type:   "about:blank",
+		Title:  title,
+		Status: status,
+		Detail: detail,
+		Code:   strings.ToUpper(strings.ReplaceAll(title, " ", "_")),
+	}
+}
@@ -0,0 +1,17 @@
+package auth
+
+import "context"
+
+type claimsKey struct{}
+
+// NewContext returns a copy of ctx that carries the claims of the
+// authenticated caller.
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// FromContext returns the claims stored in ctx by NewContext, if any.
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok && claims != nil
+}
@@ -32,6 +32,7 @@
 package auth
 
 import (
+	"strings"
 	"time"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -40,9 +41,22 @@ type Claims struct {
 	UserID     string   `json:"uid"`
 	Roles      []string `json:"roles"`
 	Permissions []string `json:"perms"`
+	// TenantID is the tenant the caller belongs to, if any
+	TenantID    string   `json:"tid,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// HasPermission reports whether the claims grant permission, written
+// "service:permission".
+func (c *Claims) HasPermission(permission string) bool {
+	for _, granted := range c.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
 func GenerateTokenPair(userID string, roles, perms []string, secret string) (string, string, error) {
 	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
 		UserID:      userID,
@@ -67,9 +81,22 @@ func GenerateTokenPair(userID string, roles, perms []string, secret string) (str
 func ValidateToken(tokenString, secret string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(t *jwt.Token) (interface{}, error) {
 		return []byte(secret), nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
 		return claims, nil
 	}
-	return nil, err
+	return nil, jwt.ErrTokenInvalidClaims
+}
+
+// BearerToken returns the token of an Authorization header value using the
+// Bearer scheme.
+func BearerToken(header string) (string, bool) {
+	const prefix = "Bearer "
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", false
+	}
+	return strings.TrimSpace(header[len(prefix):]), true
 }
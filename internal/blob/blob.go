@@ -0,0 +1,29 @@
+// Package blob stores the contents of message attachments.
+//
+// A blob is an opaque sequence of bytes kept under a slash-separated key,
+// such as "acme/<message ID>/<attachment ID>". The service only ever sees
+// the BlobStore port; LocalStore keeps blobs on the local filesystem and
+// S3Store in a bucket of any S3-compatible object store, such as AWS S3 or
+// MinIO. Both report a missing blob with an error wrapping fs.ErrNotExist,
+// and deleting a missing blob succeeds.
+package blob
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// checkKey rejects keys that are empty, absolute or not in canonical form,
+// so that no key can escape the root of a store.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.Contains(key, "\\") {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	for _, element := range strings.Split(key, "/") {
+		if element == ".." {
+			return fmt.Errorf("invalid blob key %q", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+// MemoryCache is an in-process replacement for RedisCache. Values are stored
+// JSON-encoded, exactly as they would be in Redis, so callers always get a
+// fresh copy back. Keys are prefixed with the tenant like in RedisCache.
+type MemoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]memoryEntry
+}
+
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]memoryEntry)}
+}
+
+func (c *MemoryCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal value: %w", err)
+	}
+
+	entry := memoryEntry{data: data}
+	if expiration > 0 {
+		entry.expiresAt = time.Now().Add(expiration)
+	}
+
+	c.mu.Lock()
+	c.entries[tenantKey(ctx, key)] = entry
+	c.mu.Unlock()
+
+	return nil
+}
+
+func (c *MemoryCache) Get(ctx context.Context, key string, dest interface{}) error {
+	c.mu.RLock()
+	entry, ok := c.entries[tenantKey(ctx, key)]
+	c.mu.RUnlock()
+
+	if !ok || (!entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt)) {
+		return fmt.Errorf("key not found: %s", key)
+	}
+
+	if err := json.Unmarshal(entry.data, dest); err != nil {
+		return fmt.Errorf("failed to unmarshal value: %w", err)
+	}
+
+	return nil
+}
+
+func (c *MemoryCache) Del(ctx context.Context, key string) error {
+	c.mu.Lock()
+	delete(c.entries, tenantKey(ctx, key))
+	c.mu.Unlock()
+
+	return nil
+}
+
+func (c *MemoryCache) Ping(ctx context.Context) error {
+	return nil
+}
@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"github.com/go-redis/redis/v8"
 	"go-boilerplate/config"
+	"go-boilerplate/internal/tenant"
 	"time"
 )
 
+// RedisCache stores values in Redis. Keys are prefixed with the tenant in
+// the context, so tenants never see each other's entries.
 type RedisCache struct {
 	client *redis.Client
 }
@@ -37,11 +40,11 @@ func (c *RedisCache) Set(ctx context.Context, key string, value interface{}, exp
 		return fmt.Errorf("failed to marshal value: %w", err)
 	}
 
-	return c.client.Set(ctx, key, data, expiration).Err()
+	return c.client.Set(ctx, tenantKey(ctx, key), data, expiration).Err()
 }
 
 func (c *RedisCache) Get(ctx context.Context, key string, dest interface{}) error {
-	data, err := c.client.Get(ctx, key).Bytes()
+	data, err := c.client.Get(ctx, tenantKey(ctx, key)).Bytes()
 	if err != nil {
 		if err == redis.Nil {
 			return fmt.Errorf("key not found: %s", key)
@@ -62,5 +65,15 @@ func (c *RedisCache) Client() *redis.Client {
 }
 
 func (c *RedisCache) Del(ctx context.Context, key string) error {
-	return c.client.Del(ctx, key).Err()
+	return c.client.Del(ctx, tenantKey(ctx, key)).Err()
+}
+
+// Ping checks that Redis is reachable
+func (c *RedisCache) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
+
+// tenantKey qualifies key with the tenant in ctx.
+func tenantKey(ctx context.Context, key string) string {
+	return "tenant:" + tenant.FromContext(ctx) + ":" + key
 }
@@ -0,0 +1,210 @@
+package db
+
+// Listing messages combines optional filters with a choice of sort order,
+// which sqlc cannot express without defeating the indexes. These queries are
+// built by hand instead; every value is passed as a parameter and only
+// whitelisted column names are ever spliced into the SQL.
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// messageColumns lists the columns of messages in the order Message scans
+// them.
+const messageColumns = "id, content, created_at, updated_at, version, deleted_at, search_vector, author_id, tenant_id, labels, metadata, parent_id, thread_id, reply_count, expires_at, status, publish_at"
+
+// messageSortColumns are the columns messages can be sorted by.
+var messageSortColumns = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+}
+
+// MessageFilter narrows a listing of live messages, those neither deleted
+// nor expired, that the viewer may see. Zero fields do not filter.
+type MessageFilter struct {
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+	IDs           []uuid.UUID
+	ContentPrefix string
+	AuthorID      string
+	// Labels and MetadataKeys keep the messages having all of them.
+	Labels       []string
+	MetadataKeys []string
+	// ParentID keeps the replies to one message.
+	ParentID *uuid.UUID
+	// Status keeps the messages in one status.
+	Status string
+}
+
+// MessagePosition is a position in a sort order: the value of the sort
+// column and the ID breaking ties.
+type MessagePosition struct {
+	Value time.Time
+	ID    uuid.UUID
+}
+
+type FilterMessagesParams struct {
+	TenantID string
+	// Viewer sees their own unpublished messages besides the published
+	// ones. An empty Viewer is anonymous and sees those written anonymously.
+	Viewer string
+	Filter MessageFilter
+	// SortColumn is created_at or updated_at. Ties are broken by id in the
+	// same direction.
+	SortColumn string
+	Descending bool
+	// After skips everything up to and including a position; it replaces
+	// Offset for keyset pagination.
+	After  *MessagePosition
+	Limit  int32
+	Offset int32
+}
+
+func (q *Queries) FilterMessages(ctx context.Context, arg FilterMessagesParams) ([]Message, error) {
+	if !messageSortColumns[arg.SortColumn] {
+		return nil, fmt.Errorf("unsupported sort column %q", arg.SortColumn)
+	}
+
+	var b filterBuilder
+	b.where(arg.TenantID, arg.Viewer, arg.Filter)
+
+	direction, comparison := "ASC", ">"
+	if arg.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	if arg.After != nil {
+		b.conds = append(b.conds, fmt.Sprintf("(%s, id) %s (%s, %s)",
+			arg.SortColumn, comparison, b.arg(arg.After.Value), b.arg(arg.After.ID)))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM messages WHERE %s ORDER BY %s %s, id %s LIMIT %s OFFSET %s",
+		messageColumns, strings.Join(b.conds, " AND "),
+		arg.SortColumn, direction, direction, b.arg(arg.Limit), b.arg(arg.Offset))
+
+	rows, err := q.db.Query(ctx, query, b.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Message{}
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.SearchVector,
+			&i.AuthorID,
+			&i.TenantID,
+			&i.Labels,
+			&i.Metadata,
+			&i.ParentID,
+			&i.ThreadID,
+			&i.ReplyCount,
+			&i.ExpiresAt,
+			&i.Status,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (q *Queries) CountFilteredMessages(ctx context.Context, tenantID, viewer string, filter MessageFilter) (int64, error) {
+	var b filterBuilder
+	b.where(tenantID, viewer, filter)
+
+	row := q.db.QueryRow(ctx, "SELECT COUNT(*) FROM messages WHERE "+strings.Join(b.conds, " AND "), b.args...)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+// filterBuilder collects WHERE conditions and their numbered parameters.
+type filterBuilder struct {
+	conds []string
+	args  []interface{}
+}
+
+// arg adds a parameter and returns its placeholder.
+func (b *filterBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+func (b *filterBuilder) where(tenantID, viewer string, filter MessageFilter) {
+	b.conds = append(b.conds, "tenant_id = "+b.arg(tenantID), "deleted_at IS NULL",
+		"(expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)")
+	if viewer == "" {
+		b.conds = append(b.conds, "(status = 'published' OR author_id IS NULL)")
+	} else {
+		b.conds = append(b.conds, "(status = 'published' OR author_id = "+b.arg(viewer)+")")
+	}
+
+	if !filter.CreatedAfter.IsZero() {
+		b.conds = append(b.conds, "created_at > "+b.arg(filter.CreatedAfter))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		b.conds = append(b.conds, "created_at < "+b.arg(filter.CreatedBefore))
+	}
+	if !filter.UpdatedAfter.IsZero() {
+		b.conds = append(b.conds, "updated_at > "+b.arg(filter.UpdatedAfter))
+	}
+	if !filter.UpdatedBefore.IsZero() {
+		b.conds = append(b.conds, "updated_at < "+b.arg(filter.UpdatedBefore))
+	}
+	if len(filter.IDs) > 0 {
+		b.conds = append(b.conds, "id = ANY("+b.arg(filter.IDs)+"::uuid[])")
+	}
+	if filter.ParentID != nil {
+		b.conds = append(b.conds, "parent_id = "+b.arg(*filter.ParentID))
+	}
+	if filter.AuthorID != "" {
+		b.conds = append(b.conds, "author_id = "+b.arg(filter.AuthorID))
+	}
+	if filter.Status != "" {
+		b.conds = append(b.conds, "status = "+b.arg(filter.Status))
+	}
+	if len(filter.Labels) > 0 {
+		b.conds = append(b.conds, "labels @> "+b.arg(filter.Labels)+"::text[]")
+	}
+	if len(filter.MetadataKeys) > 0 {
+		b.conds = append(b.conds, "metadata ?& "+b.arg(filter.MetadataKeys)+"::text[]")
+	}
+	if filter.ContentPrefix != "" {
+		// The indexed left(content, 64) narrows the scan, content LIKE
+		// checks the whole prefix
+		pattern := escapeLike(filter.ContentPrefix) + "%"
+		short := pattern
+		if prefix := []rune(filter.ContentPrefix); len(prefix) > contentPrefixIndexLength {
+			short = escapeLike(string(prefix[:contentPrefixIndexLength])) + "%"
+		}
+		b.conds = append(b.conds,
+			fmt.Sprintf("left(content, %d) LIKE %s", contentPrefixIndexLength, b.arg(short)),
+			"content LIKE "+b.arg(pattern))
+	}
+}
+
+// contentPrefixIndexLength is the length of the content prefix indexed by
+// messages_tenant_content_prefix_idx.
+const contentPrefixIndexLength = 64
+
+// escapeLike escapes the LIKE wildcards in s, using the default \ escape.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
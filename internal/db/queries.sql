@@ -1,29 +1,285 @@
 -- name: CreateMessage :one
-INSERT INTO messages (content)
-VALUES ($1)
+INSERT INTO messages (content, author_id, tenant_id, labels, metadata, parent_id, thread_id, expires_at, status, publish_at)
+VALUES ($1, $2, $3, $4, $5, $6, sqlc.narg('thread_id'), sqlc.narg('expires_at'), sqlc.arg('status'), sqlc.narg('publish_at'))
 RETURNING *;
 
 -- name: GetMessage :one
+-- Unpublished messages are only found by their author, the viewer
 SELECT * FROM messages
-WHERE id = $1 AND deleted_at IS NULL;
+WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+  AND (status = 'published' OR author_id IS NOT DISTINCT FROM sqlc.narg('viewer'));
+
+-- name: GetMessageForUpdate :one
+SELECT * FROM messages
+WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+  AND (status = 'published' OR author_id IS NOT DISTINCT FROM sqlc.narg('viewer'))
+FOR UPDATE;
 
 -- name: UpdateMessage :one
 UPDATE messages
-SET content = $2, updated_at = CURRENT_TIMESTAMP
-WHERE id = $1 AND deleted_at IS NULL
+SET content = $2, labels = $4, metadata = $5, expires_at = sqlc.narg('expires_at'),
+    status = sqlc.arg('status'), publish_at = sqlc.narg('publish_at'),
+    version = version + 1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND tenant_id = $3 AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+  AND (sqlc.narg('expected_version')::bigint IS NULL OR version = sqlc.narg('expected_version'))
+RETURNING *;
+
+-- name: CreateMessages :batchone
+INSERT INTO messages (content, author_id, tenant_id, labels, metadata, parent_id, thread_id, expires_at, status, publish_at)
+VALUES ($1, $2, $3, $4, $5, $6, sqlc.narg('thread_id'), sqlc.narg('expires_at'), sqlc.arg('status'), sqlc.narg('publish_at'))
 RETURNING *;
 
 -- name: DeleteMessage :exec
 UPDATE messages
 SET deleted_at = CURRENT_TIMESTAMP
-WHERE id = $1 AND deleted_at IS NULL;
+WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL;
+
+-- name: DeleteMessages :many
+UPDATE messages
+SET deleted_at = CURRENT_TIMESTAMP
+WHERE id = ANY(sqlc.arg('ids')::uuid[]) AND tenant_id = sqlc.arg('tenant_id') AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+  AND (status = 'published' OR author_id IS NOT DISTINCT FROM sqlc.narg('viewer'))
+RETURNING *;
 
--- name: ListMessages :many
+-- name: DeleteReplies :many
+-- Deletes the live replies of the given messages, and their replies in
+-- turn, however deep. The walk goes through replies that are already
+-- deleted or expired.
+WITH RECURSIVE subtree AS (
+    SELECT m.id FROM messages m
+    WHERE m.parent_id = ANY(sqlc.arg('ids')::uuid[]) AND m.tenant_id = sqlc.arg('tenant_id')
+    UNION
+    SELECT m.id FROM messages m
+    JOIN subtree s ON m.parent_id = s.id
+    WHERE m.tenant_id = sqlc.arg('tenant_id')
+)
+UPDATE messages
+SET deleted_at = CURRENT_TIMESTAMP
+WHERE id IN (SELECT id FROM subtree) AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+RETURNING *;
+
+-- name: ListThreadMessages :many
+-- Every message of a thread, deleted or not, oldest first
 SELECT * FROM messages
-WHERE deleted_at IS NULL
-ORDER BY created_at DESC
-LIMIT $1 OFFSET $2;
+WHERE thread_id = $1 AND tenant_id = $2
+ORDER BY created_at, id
+LIMIT $3;
 
--- name: GetTotalMessages :one
+-- name: SearchMessages :many
+-- Live messages matching a to_tsquery query, best match first, with a
+-- highlighted snippet of each
+SELECT id, content, created_at, updated_at, version, deleted_at, author_id, tenant_id, labels, metadata,
+    parent_id, thread_id, reply_count, expires_at, status, publish_at,
+    ts_rank_cd(search_vector, to_tsquery(messages_search_config(), sqlc.arg('query')::text))::real AS rank,
+    ts_headline(messages_search_config(), content, to_tsquery(messages_search_config(), sqlc.arg('query')::text),
+        'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15')::text AS snippet
+FROM messages
+WHERE tenant_id = sqlc.arg('tenant_id') AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+  AND (status = 'published' OR author_id IS NOT DISTINCT FROM sqlc.narg('viewer'))
+  AND search_vector @@ to_tsquery(messages_search_config(), sqlc.arg('query')::text)
+ORDER BY rank DESC, created_at DESC, id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountSearchMessages :one
 SELECT COUNT(*) FROM messages
-WHERE deleted_at IS NULL;
+WHERE tenant_id = sqlc.arg('tenant_id') AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+  AND (status = 'published' OR author_id IS NOT DISTINCT FROM sqlc.narg('viewer'))
+  AND search_vector @@ to_tsquery(messages_search_config(), sqlc.arg('query')::text);
+
+-- name: InsertOutboxEvent :one
+INSERT INTO outbox_events (aggregate_id, event_type, payload)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: InsertOutboxEvents :copyfrom
+INSERT INTO outbox_events (aggregate_id, event_type, payload)
+VALUES ($1, $2, $3);
+
+-- name: TryLockOutbox :one
+SELECT pg_try_advisory_xact_lock(hashtext('outbox_relay'));
+
+-- name: ListPendingOutboxEvents :many
+SELECT * FROM outbox_events
+WHERE dispatched_at IS NULL
+ORDER BY id
+LIMIT $1;
+
+-- name: MarkOutboxEventDispatched :exec
+UPDATE outbox_events
+SET dispatched_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: RecordOutboxEventFailure :exec
+UPDATE outbox_events
+SET attempts = attempts + 1, last_error = $2
+WHERE id = $1;
+
+-- name: PurgeDispatchedOutboxEvents :execrows
+DELETE FROM outbox_events
+WHERE dispatched_at IS NOT NULL AND dispatched_at < $1;
+
+-- name: GetOutboxBacklog :one
+SELECT COUNT(*) AS pending,
+       COALESCE(MIN(created_at), CURRENT_TIMESTAMP)::timestamptz AS oldest_created_at
+FROM outbox_events
+WHERE dispatched_at IS NULL;
+
+-- name: InsertMessageRevision :one
+INSERT INTO message_revisions (message_id, revision, content, editor, tenant_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: InsertMessageRevisions :copyfrom
+INSERT INTO message_revisions (message_id, revision, content, editor, tenant_id)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetMessageRevision :one
+SELECT * FROM message_revisions
+WHERE message_id = $1 AND revision = $2 AND tenant_id = $3;
+
+-- name: ListMessageRevisions :many
+SELECT * FROM message_revisions
+WHERE message_id = $1 AND tenant_id = $2
+ORDER BY revision DESC
+LIMIT $3 OFFSET $4;
+
+-- name: CountMessageRevisions :one
+SELECT COUNT(*) FROM message_revisions
+WHERE message_id = $1 AND tenant_id = $2;
+
+-- name: CreateAttachment :one
+INSERT INTO attachments (id, message_id, tenant_id, filename, content_type, size, checksum, storage_key, uploader_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetAttachment :one
+SELECT * FROM attachments
+WHERE id = $1 AND message_id = $2 AND tenant_id = $3;
+
+-- name: ListAttachments :many
+SELECT * FROM attachments
+WHERE message_id = $1 AND tenant_id = $2
+ORDER BY created_at, id;
+
+-- name: PurgeAttachments :many
+-- Removes the attachments of purged messages, returning them so their blobs
+-- can be deleted. Maintenance jobs purge across tenants
+DELETE FROM attachments
+WHERE message_id = ANY(sqlc.arg('ids')::uuid[])
+  AND (tenant_id = sqlc.arg('tenant_id') OR sqlc.arg('tenant_id') = '*')
+RETURNING *;
+
+-- name: ListDeletedMessages :many
+-- The viewer sees the messages they deleted, and with every_author the
+-- published messages of everyone else
+SELECT * FROM messages
+WHERE tenant_id = $1 AND deleted_at IS NOT NULL
+  AND (author_id IS NOT DISTINCT FROM sqlc.narg('viewer')
+       OR (sqlc.arg('every_author')::boolean AND status = 'published'))
+ORDER BY deleted_at DESC, id
+LIMIT $2 OFFSET $3;
+
+-- name: CountDeletedMessages :one
+SELECT COUNT(*) FROM messages
+WHERE tenant_id = $1 AND deleted_at IS NOT NULL
+  AND (author_id IS NOT DISTINCT FROM sqlc.narg('viewer')
+       OR (sqlc.arg('every_author')::boolean AND status = 'published'));
+
+-- name: RestoreMessage :one
+-- An expiry that has passed is cleared, or the message would vanish again
+UPDATE messages
+SET deleted_at = NULL,
+    expires_at = CASE WHEN expires_at <= CURRENT_TIMESTAMP THEN NULL ELSE expires_at END
+WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: PurgeMessage :one
+DELETE FROM messages
+WHERE id = $1 AND tenant_id = $2
+RETURNING *;
+
+-- name: PurgeDeletedMessages :many
+-- Runs across all tenants, for the retention job. SKIP LOCKED lets several
+-- replicas purge concurrently without waiting on or double-purging each
+-- other's rows
+DELETE FROM messages
+WHERE id IN (
+    SELECT id FROM messages
+    WHERE deleted_at IS NOT NULL AND deleted_at < $1
+    ORDER BY deleted_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ExpireMessages :many
+-- Moves live messages of every tenant that expired by the given time to
+-- the trash, for the expiry sweeper. Like PurgeDeletedMessages it skips
+-- rows locked by another replica
+UPDATE messages
+SET deleted_at = CURRENT_TIMESTAMP
+WHERE id IN (
+    SELECT id FROM messages
+    WHERE deleted_at IS NULL AND expires_at <= $1
+    ORDER BY expires_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: PublishMessages :many
+-- Publishes the live scheduled messages of every tenant that are due by the
+-- given time, for the scheduler. Like ExpireMessages it skips rows locked
+-- by another replica, and a published message is never picked again, so
+-- each message is published exactly once
+UPDATE messages
+SET status = 'published',
+    version = version + 1
+WHERE id IN (
+    SELECT id FROM messages
+    WHERE status = 'scheduled' AND deleted_at IS NULL AND publish_at <= $1
+      AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+    ORDER BY publish_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE key = $1 AND expires_at > CURRENT_TIMESTAMP;
+
+-- name: PutIdempotencyKey :exec
+INSERT INTO idempotency_keys (key, fingerprint, status, header, body, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (key) DO UPDATE
+SET fingerprint = EXCLUDED.fingerprint,
+    status = EXCLUDED.status,
+    header = EXCLUDED.header,
+    body = EXCLUDED.body,
+    created_at = CURRENT_TIMESTAMP,
+    expires_at = EXCLUDED.expires_at;
+
+-- name: PurgeIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE key IN (
+    SELECT key FROM idempotency_keys
+    WHERE expires_at < CURRENT_TIMESTAMP
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+);
+
+-- name: LockIdempotencyKey :exec
+-- Session-level, in the two-key space so it cannot collide with the outbox
+-- lock
+SELECT pg_advisory_lock(hashtext('idempotency_keys'), hashtext(sqlc.arg('key')::text));
+
+-- name: UnlockIdempotencyKey :exec
+SELECT pg_advisory_unlock(hashtext('idempotency_keys'), hashtext(sqlc.arg('key')::text));
@@ -7,25 +7,167 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countDeletedMessages = `-- name: CountDeletedMessages :one
+SELECT COUNT(*) FROM messages
+WHERE tenant_id = $1 AND deleted_at IS NOT NULL
+  AND (author_id IS NOT DISTINCT FROM $2
+       OR ($3::boolean AND status = 'published'))
+`
+
+type CountDeletedMessagesParams struct {
+	TenantID    string      `json:"tenant_id"`
+	Viewer      pgtype.Text `json:"viewer"`
+	EveryAuthor bool        `json:"every_author"`
+}
+
+func (q *Queries) CountDeletedMessages(ctx context.Context, arg CountDeletedMessagesParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countDeletedMessages, arg.TenantID, arg.Viewer, arg.EveryAuthor)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countMessageRevisions = `-- name: CountMessageRevisions :one
+SELECT COUNT(*) FROM message_revisions
+WHERE message_id = $1 AND tenant_id = $2
+`
+
+type CountMessageRevisionsParams struct {
+	MessageID uuid.UUID `json:"message_id"`
+	TenantID  string    `json:"tenant_id"`
+}
+
+func (q *Queries) CountMessageRevisions(ctx context.Context, arg CountMessageRevisionsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countMessageRevisions, arg.MessageID, arg.TenantID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countSearchMessages = `-- name: CountSearchMessages :one
+SELECT COUNT(*) FROM messages
+WHERE tenant_id = $1 AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+  AND (status = 'published' OR author_id IS NOT DISTINCT FROM $2)
+  AND search_vector @@ to_tsquery(messages_search_config(), $3::text)
+`
+
+type CountSearchMessagesParams struct {
+	TenantID string      `json:"tenant_id"`
+	Viewer   pgtype.Text `json:"viewer"`
+	Query    string      `json:"query"`
+}
+
+func (q *Queries) CountSearchMessages(ctx context.Context, arg CountSearchMessagesParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countSearchMessages, arg.TenantID, arg.Viewer, arg.Query)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAttachment = `-- name: CreateAttachment :one
+INSERT INTO attachments (id, message_id, tenant_id, filename, content_type, size, checksum, storage_key, uploader_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, message_id, tenant_id, filename, content_type, size, checksum, storage_key, uploader_id, created_at
+`
+
+type CreateAttachmentParams struct {
+	ID          uuid.UUID   `json:"id"`
+	MessageID   uuid.UUID   `json:"message_id"`
+	TenantID    string      `json:"tenant_id"`
+	Filename    string      `json:"filename"`
+	ContentType string      `json:"content_type"`
+	Size        int64       `json:"size"`
+	Checksum    string      `json:"checksum"`
+	StorageKey  string      `json:"storage_key"`
+	UploaderID  pgtype.Text `json:"uploader_id"`
+}
+
+func (q *Queries) CreateAttachment(ctx context.Context, arg CreateAttachmentParams) (Attachment, error) {
+	row := q.db.QueryRow(ctx, createAttachment,
+		arg.ID,
+		arg.MessageID,
+		arg.TenantID,
+		arg.Filename,
+		arg.ContentType,
+		arg.Size,
+		arg.Checksum,
+		arg.StorageKey,
+		arg.UploaderID,
+	)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.MessageID,
+		&i.TenantID,
+		&i.Filename,
+		&i.ContentType,
+		&i.Size,
+		&i.Checksum,
+		&i.StorageKey,
+		&i.UploaderID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const createMessage = `-- name: CreateMessage :one
-INSERT INTO messages (content)
-VALUES ($1)
-RETURNING id, content, created_at, updated_at, deleted_at
+INSERT INTO messages (content, author_id, tenant_id, labels, metadata, parent_id, thread_id, expires_at, status, publish_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, content, created_at, updated_at, version, deleted_at, search_vector, author_id, tenant_id, labels, metadata, parent_id, thread_id, reply_count, expires_at, status, publish_at
 `
 
-func (q *Queries) CreateMessage(ctx context.Context, content string) (Message, error) {
-	row := q.db.QueryRow(ctx, createMessage, content)
+type CreateMessageParams struct {
+	Content   string             `json:"content"`
+	AuthorID  pgtype.Text        `json:"author_id"`
+	TenantID  string             `json:"tenant_id"`
+	Labels    []string           `json:"labels"`
+	Metadata  []byte             `json:"metadata"`
+	ParentID  pgtype.UUID        `json:"parent_id"`
+	ThreadID  pgtype.UUID        `json:"thread_id"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	Status    string             `json:"status"`
+	PublishAt pgtype.Timestamptz `json:"publish_at"`
+}
+
+func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error) {
+	row := q.db.QueryRow(ctx, createMessage,
+		arg.Content,
+		arg.AuthorID,
+		arg.TenantID,
+		arg.Labels,
+		arg.Metadata,
+		arg.ParentID,
+		arg.ThreadID,
+		arg.ExpiresAt,
+		arg.Status,
+		arg.PublishAt,
+	)
 	var i Message
 	err := row.Scan(
 		&i.ID,
 		&i.Content,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
 		&i.DeletedAt,
+		&i.SearchVector,
+		&i.AuthorID,
+		&i.TenantID,
+		&i.Labels,
+		&i.Metadata,
+		&i.ParentID,
+		&i.ThreadID,
+		&i.ReplyCount,
+		&i.ExpiresAt,
+		&i.Status,
+		&i.PublishAt,
 	)
 	return i, err
 }
@@ -33,58 +175,510 @@ func (q *Queries) CreateMessage(ctx context.Context, content string) (Message, e
 const deleteMessage = `-- name: DeleteMessage :exec
 UPDATE messages
 SET deleted_at = CURRENT_TIMESTAMP
-WHERE id = $1 AND deleted_at IS NULL
+WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
 `
 
-func (q *Queries) DeleteMessage(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.Exec(ctx, deleteMessage, id)
+type DeleteMessageParams struct {
+	ID       uuid.UUID `json:"id"`
+	TenantID string    `json:"tenant_id"`
+}
+
+func (q *Queries) DeleteMessage(ctx context.Context, arg DeleteMessageParams) error {
+	_, err := q.db.Exec(ctx, deleteMessage, arg.ID, arg.TenantID)
 	return err
 }
 
+const deleteMessages = `-- name: DeleteMessages :many
+UPDATE messages
+SET deleted_at = CURRENT_TIMESTAMP
+WHERE id = ANY($1::uuid[]) AND tenant_id = $2 AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+  AND (status = 'published' OR author_id IS NOT DISTINCT FROM $3)
+RETURNING id, content, created_at, updated_at, version, deleted_at, search_vector, author_id, tenant_id, labels, metadata, parent_id, thread_id, reply_count, expires_at, status, publish_at
+`
+
+type DeleteMessagesParams struct {
+	Ids      []uuid.UUID `json:"ids"`
+	TenantID string      `json:"tenant_id"`
+	Viewer   pgtype.Text `json:"viewer"`
+}
+
+func (q *Queries) DeleteMessages(ctx context.Context, arg DeleteMessagesParams) ([]Message, error) {
+	rows, err := q.db.Query(ctx, deleteMessages, arg.Ids, arg.TenantID, arg.Viewer)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Message{}
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.SearchVector,
+			&i.AuthorID,
+			&i.TenantID,
+			&i.Labels,
+			&i.Metadata,
+			&i.ParentID,
+			&i.ThreadID,
+			&i.ReplyCount,
+			&i.ExpiresAt,
+			&i.Status,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteReplies = `-- name: DeleteReplies :many
+WITH RECURSIVE subtree AS (
+    SELECT m.id FROM messages m
+    WHERE m.parent_id = ANY($1::uuid[]) AND m.tenant_id = $2
+    UNION
+    SELECT m.id FROM messages m
+    JOIN subtree s ON m.parent_id = s.id
+    WHERE m.tenant_id = $2
+)
+UPDATE messages
+SET deleted_at = CURRENT_TIMESTAMP
+WHERE id IN (SELECT id FROM subtree) AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+RETURNING id, content, created_at, updated_at, version, deleted_at, search_vector, author_id, tenant_id, labels, metadata, parent_id, thread_id, reply_count, expires_at, status, publish_at
+`
+
+type DeleteRepliesParams struct {
+	Ids      []uuid.UUID `json:"ids"`
+	TenantID string      `json:"tenant_id"`
+}
+
+// Deletes the live replies of the given messages, and their replies in
+// turn, however deep. The walk goes through replies that are already
+// deleted or expired.
+func (q *Queries) DeleteReplies(ctx context.Context, arg DeleteRepliesParams) ([]Message, error) {
+	rows, err := q.db.Query(ctx, deleteReplies, arg.Ids, arg.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Message{}
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.SearchVector,
+			&i.AuthorID,
+			&i.TenantID,
+			&i.Labels,
+			&i.Metadata,
+			&i.ParentID,
+			&i.ThreadID,
+			&i.ReplyCount,
+			&i.ExpiresAt,
+			&i.Status,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const expireMessages = `-- name: ExpireMessages :many
+UPDATE messages
+SET deleted_at = CURRENT_TIMESTAMP
+WHERE id IN (
+    SELECT id FROM messages
+    WHERE deleted_at IS NULL AND expires_at <= $1
+    ORDER BY expires_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, content, created_at, updated_at, version, deleted_at, search_vector, author_id, tenant_id, labels, metadata, parent_id, thread_id, reply_count, expires_at, status, publish_at
+`
+
+type ExpireMessagesParams struct {
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	Limit     int32              `json:"limit"`
+}
+
+// Moves live messages of every tenant that expired by the given time to
+// the trash, for the expiry sweeper. Like PurgeDeletedMessages it skips
+// rows locked by another replica
+func (q *Queries) ExpireMessages(ctx context.Context, arg ExpireMessagesParams) ([]Message, error) {
+	rows, err := q.db.Query(ctx, expireMessages, arg.ExpiresAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Message{}
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.SearchVector,
+			&i.AuthorID,
+			&i.TenantID,
+			&i.Labels,
+			&i.Metadata,
+			&i.ParentID,
+			&i.ThreadID,
+			&i.ReplyCount,
+			&i.ExpiresAt,
+			&i.Status,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAttachment = `-- name: GetAttachment :one
+SELECT id, message_id, tenant_id, filename, content_type, size, checksum, storage_key, uploader_id, created_at FROM attachments
+WHERE id = $1 AND message_id = $2 AND tenant_id = $3
+`
+
+type GetAttachmentParams struct {
+	ID        uuid.UUID `json:"id"`
+	MessageID uuid.UUID `json:"message_id"`
+	TenantID  string    `json:"tenant_id"`
+}
+
+func (q *Queries) GetAttachment(ctx context.Context, arg GetAttachmentParams) (Attachment, error) {
+	row := q.db.QueryRow(ctx, getAttachment, arg.ID, arg.MessageID, arg.TenantID)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.MessageID,
+		&i.TenantID,
+		&i.Filename,
+		&i.ContentType,
+		&i.Size,
+		&i.Checksum,
+		&i.StorageKey,
+		&i.UploaderID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+SELECT key, fingerprint, status, header, body, created_at, expires_at FROM idempotency_keys
+WHERE key = $1 AND expires_at > CURRENT_TIMESTAMP
+`
+
+func (q *Queries) GetIdempotencyKey(ctx context.Context, key string) (IdempotencyKey, error) {
+	row := q.db.QueryRow(ctx, getIdempotencyKey, key)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.Key,
+		&i.Fingerprint,
+		&i.Status,
+		&i.Header,
+		&i.Body,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
 const getMessage = `-- name: GetMessage :one
-SELECT id, content, created_at, updated_at, deleted_at FROM messages
-WHERE id = $1 AND deleted_at IS NULL
+SELECT id, content, created_at, updated_at, version, deleted_at, search_vector, author_id, tenant_id, labels, metadata, parent_id, thread_id, reply_count, expires_at, status, publish_at FROM messages
+WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+  AND (status = 'published' OR author_id IS NOT DISTINCT FROM $3)
 `
 
-func (q *Queries) GetMessage(ctx context.Context, id uuid.UUID) (Message, error) {
-	row := q.db.QueryRow(ctx, getMessage, id)
+type GetMessageParams struct {
+	ID       uuid.UUID   `json:"id"`
+	TenantID string      `json:"tenant_id"`
+	Viewer   pgtype.Text `json:"viewer"`
+}
+
+// Unpublished messages are only found by their author, the viewer
+func (q *Queries) GetMessage(ctx context.Context, arg GetMessageParams) (Message, error) {
+	row := q.db.QueryRow(ctx, getMessage, arg.ID, arg.TenantID, arg.Viewer)
 	var i Message
 	err := row.Scan(
 		&i.ID,
 		&i.Content,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
 		&i.DeletedAt,
+		&i.SearchVector,
+		&i.AuthorID,
+		&i.TenantID,
+		&i.Labels,
+		&i.Metadata,
+		&i.ParentID,
+		&i.ThreadID,
+		&i.ReplyCount,
+		&i.ExpiresAt,
+		&i.Status,
+		&i.PublishAt,
 	)
 	return i, err
 }
 
-const getTotalMessages = `-- name: GetTotalMessages :one
-SELECT COUNT(*) FROM messages
-WHERE deleted_at IS NULL
+const getMessageForUpdate = `-- name: GetMessageForUpdate :one
+SELECT id, content, created_at, updated_at, version, deleted_at, search_vector, author_id, tenant_id, labels, metadata, parent_id, thread_id, reply_count, expires_at, status, publish_at FROM messages
+WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+  AND (status = 'published' OR author_id IS NOT DISTINCT FROM $3)
+FOR UPDATE
 `
 
-func (q *Queries) GetTotalMessages(ctx context.Context) (int64, error) {
-	row := q.db.QueryRow(ctx, getTotalMessages)
-	var count int64
-	err := row.Scan(&count)
-	return count, err
+type GetMessageForUpdateParams struct {
+	ID       uuid.UUID   `json:"id"`
+	TenantID string      `json:"tenant_id"`
+	Viewer   pgtype.Text `json:"viewer"`
+}
+
+func (q *Queries) GetMessageForUpdate(ctx context.Context, arg GetMessageForUpdateParams) (Message, error) {
+	row := q.db.QueryRow(ctx, getMessageForUpdate, arg.ID, arg.TenantID, arg.Viewer)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.Content,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.SearchVector,
+		&i.AuthorID,
+		&i.TenantID,
+		&i.Labels,
+		&i.Metadata,
+		&i.ParentID,
+		&i.ThreadID,
+		&i.ReplyCount,
+		&i.ExpiresAt,
+		&i.Status,
+		&i.PublishAt,
+	)
+	return i, err
+}
+
+const getMessageRevision = `-- name: GetMessageRevision :one
+SELECT message_id, revision, content, editor, created_at, tenant_id FROM message_revisions
+WHERE message_id = $1 AND revision = $2 AND tenant_id = $3
+`
+
+type GetMessageRevisionParams struct {
+	MessageID uuid.UUID `json:"message_id"`
+	Revision  int64     `json:"revision"`
+	TenantID  string    `json:"tenant_id"`
+}
+
+func (q *Queries) GetMessageRevision(ctx context.Context, arg GetMessageRevisionParams) (MessageRevision, error) {
+	row := q.db.QueryRow(ctx, getMessageRevision, arg.MessageID, arg.Revision, arg.TenantID)
+	var i MessageRevision
+	err := row.Scan(
+		&i.MessageID,
+		&i.Revision,
+		&i.Content,
+		&i.Editor,
+		&i.CreatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const getOutboxBacklog = `-- name: GetOutboxBacklog :one
+SELECT COUNT(*) AS pending,
+       COALESCE(MIN(created_at), CURRENT_TIMESTAMP)::timestamptz AS oldest_created_at
+FROM outbox_events
+WHERE dispatched_at IS NULL
+`
+
+type GetOutboxBacklogRow struct {
+	Pending         int64     `json:"pending"`
+	OldestCreatedAt time.Time `json:"oldest_created_at"`
+}
+
+func (q *Queries) GetOutboxBacklog(ctx context.Context) (GetOutboxBacklogRow, error) {
+	row := q.db.QueryRow(ctx, getOutboxBacklog)
+	var i GetOutboxBacklogRow
+	err := row.Scan(&i.Pending, &i.OldestCreatedAt)
+	return i, err
+}
+
+const insertMessageRevision = `-- name: InsertMessageRevision :one
+INSERT INTO message_revisions (message_id, revision, content, editor, tenant_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING message_id, revision, content, editor, created_at, tenant_id
+`
+
+type InsertMessageRevisionParams struct {
+	MessageID uuid.UUID   `json:"message_id"`
+	Revision  int64       `json:"revision"`
+	Content   string      `json:"content"`
+	Editor    pgtype.Text `json:"editor"`
+	TenantID  string      `json:"tenant_id"`
+}
+
+type InsertMessageRevisionsParams struct {
+	MessageID uuid.UUID   `json:"message_id"`
+	Revision  int64       `json:"revision"`
+	Content   string      `json:"content"`
+	Editor    pgtype.Text `json:"editor"`
+	TenantID  string      `json:"tenant_id"`
+}
+
+func (q *Queries) InsertMessageRevision(ctx context.Context, arg InsertMessageRevisionParams) (MessageRevision, error) {
+	row := q.db.QueryRow(ctx, insertMessageRevision,
+		arg.MessageID,
+		arg.Revision,
+		arg.Content,
+		arg.Editor,
+		arg.TenantID,
+	)
+	var i MessageRevision
+	err := row.Scan(
+		&i.MessageID,
+		&i.Revision,
+		&i.Content,
+		&i.Editor,
+		&i.CreatedAt,
+		&i.TenantID,
+	)
+	return i, err
 }
 
-const listMessages = `-- name: ListMessages :many
-SELECT id, content, created_at, updated_at, deleted_at FROM messages
-WHERE deleted_at IS NULL
-ORDER BY created_at DESC
-LIMIT $1 OFFSET $2
+const insertOutboxEvent = `-- name: InsertOutboxEvent :one
+INSERT INTO outbox_events (aggregate_id, event_type, payload)
+VALUES ($1, $2, $3)
+RETURNING id, aggregate_id, event_type, payload, attempts, last_error, created_at, dispatched_at
 `
 
-type ListMessagesParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+type InsertOutboxEventParams struct {
+	AggregateID uuid.UUID `json:"aggregate_id"`
+	EventType   string    `json:"event_type"`
+	Payload     []byte    `json:"payload"`
 }
 
-func (q *Queries) ListMessages(ctx context.Context, arg ListMessagesParams) ([]Message, error) {
-	rows, err := q.db.Query(ctx, listMessages, arg.Limit, arg.Offset)
+type InsertOutboxEventsParams struct {
+	AggregateID uuid.UUID `json:"aggregate_id"`
+	EventType   string    `json:"event_type"`
+	Payload     []byte    `json:"payload"`
+}
+
+func (q *Queries) InsertOutboxEvent(ctx context.Context, arg InsertOutboxEventParams) (OutboxEvent, error) {
+	row := q.db.QueryRow(ctx, insertOutboxEvent, arg.AggregateID, arg.EventType, arg.Payload)
+	var i OutboxEvent
+	err := row.Scan(
+		&i.ID,
+		&i.AggregateID,
+		&i.EventType,
+		&i.Payload,
+		&i.Attempts,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.DispatchedAt,
+	)
+	return i, err
+}
+
+const listAttachments = `-- name: ListAttachments :many
+SELECT id, message_id, tenant_id, filename, content_type, size, checksum, storage_key, uploader_id, created_at FROM attachments
+WHERE message_id = $1 AND tenant_id = $2
+ORDER BY created_at, id
+`
+
+type ListAttachmentsParams struct {
+	MessageID uuid.UUID `json:"message_id"`
+	TenantID  string    `json:"tenant_id"`
+}
+
+func (q *Queries) ListAttachments(ctx context.Context, arg ListAttachmentsParams) ([]Attachment, error) {
+	rows, err := q.db.Query(ctx, listAttachments, arg.MessageID, arg.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Attachment{}
+	for rows.Next() {
+		var i Attachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.MessageID,
+			&i.TenantID,
+			&i.Filename,
+			&i.ContentType,
+			&i.Size,
+			&i.Checksum,
+			&i.StorageKey,
+			&i.UploaderID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeletedMessages = `-- name: ListDeletedMessages :many
+SELECT id, content, created_at, updated_at, version, deleted_at, search_vector, author_id, tenant_id, labels, metadata, parent_id, thread_id, reply_count, expires_at, status, publish_at FROM messages
+WHERE tenant_id = $1 AND deleted_at IS NOT NULL
+  AND (author_id IS NOT DISTINCT FROM $4
+       OR ($5::boolean AND status = 'published'))
+ORDER BY deleted_at DESC, id
+LIMIT $2 OFFSET $3
+`
+
+type ListDeletedMessagesParams struct {
+	TenantID    string      `json:"tenant_id"`
+	Limit       int32       `json:"limit"`
+	Offset      int32       `json:"offset"`
+	Viewer      pgtype.Text `json:"viewer"`
+	EveryAuthor bool        `json:"every_author"`
+}
+
+// The viewer sees the messages they deleted, and with every_author the
+// published messages of everyone else
+func (q *Queries) ListDeletedMessages(ctx context.Context, arg ListDeletedMessagesParams) ([]Message, error) {
+	rows, err := q.db.Query(ctx, listDeletedMessages,
+		arg.TenantID,
+		arg.Limit,
+		arg.Offset,
+		arg.Viewer,
+		arg.EveryAuthor,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -97,7 +691,19 @@ func (q *Queries) ListMessages(ctx context.Context, arg ListMessagesParams) ([]M
 			&i.Content,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Version,
 			&i.DeletedAt,
+			&i.SearchVector,
+			&i.AuthorID,
+			&i.TenantID,
+			&i.Labels,
+			&i.Metadata,
+			&i.ParentID,
+			&i.ThreadID,
+			&i.ReplyCount,
+			&i.ExpiresAt,
+			&i.Status,
+			&i.PublishAt,
 		); err != nil {
 			return nil, err
 		}
@@ -109,27 +715,642 @@ func (q *Queries) ListMessages(ctx context.Context, arg ListMessagesParams) ([]M
 	return items, nil
 }
 
+const listMessageRevisions = `-- name: ListMessageRevisions :many
+SELECT message_id, revision, content, editor, created_at, tenant_id FROM message_revisions
+WHERE message_id = $1 AND tenant_id = $2
+ORDER BY revision DESC
+LIMIT $3 OFFSET $4
+`
+
+type ListMessageRevisionsParams struct {
+	MessageID uuid.UUID `json:"message_id"`
+	TenantID  string    `json:"tenant_id"`
+	Limit     int32     `json:"limit"`
+	Offset    int32     `json:"offset"`
+}
+
+func (q *Queries) ListMessageRevisions(ctx context.Context, arg ListMessageRevisionsParams) ([]MessageRevision, error) {
+	rows, err := q.db.Query(ctx, listMessageRevisions, arg.MessageID, arg.TenantID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []MessageRevision{}
+	for rows.Next() {
+		var i MessageRevision
+		if err := rows.Scan(
+			&i.MessageID,
+			&i.Revision,
+			&i.Content,
+			&i.Editor,
+			&i.CreatedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingOutboxEvents = `-- name: ListPendingOutboxEvents :many
+SELECT id, aggregate_id, event_type, payload, attempts, last_error, created_at, dispatched_at FROM outbox_events
+WHERE dispatched_at IS NULL
+ORDER BY id
+LIMIT $1
+`
+
+func (q *Queries) ListPendingOutboxEvents(ctx context.Context, limit int32) ([]OutboxEvent, error) {
+	rows, err := q.db.Query(ctx, listPendingOutboxEvents, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OutboxEvent{}
+	for rows.Next() {
+		var i OutboxEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.AggregateID,
+			&i.EventType,
+			&i.Payload,
+			&i.Attempts,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.DispatchedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listThreadMessages = `-- name: ListThreadMessages :many
+SELECT id, content, created_at, updated_at, version, deleted_at, search_vector, author_id, tenant_id, labels, metadata, parent_id, thread_id, reply_count, expires_at, status, publish_at FROM messages
+WHERE thread_id = $1 AND tenant_id = $2
+ORDER BY created_at, id
+LIMIT $3
+`
+
+type ListThreadMessagesParams struct {
+	ThreadID uuid.UUID `json:"thread_id"`
+	TenantID string    `json:"tenant_id"`
+	Limit    int32     `json:"limit"`
+}
+
+// Every message of a thread, deleted or not, oldest first
+func (q *Queries) ListThreadMessages(ctx context.Context, arg ListThreadMessagesParams) ([]Message, error) {
+	rows, err := q.db.Query(ctx, listThreadMessages, arg.ThreadID, arg.TenantID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Message{}
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.SearchVector,
+			&i.AuthorID,
+			&i.TenantID,
+			&i.Labels,
+			&i.Metadata,
+			&i.ParentID,
+			&i.ThreadID,
+			&i.ReplyCount,
+			&i.ExpiresAt,
+			&i.Status,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockIdempotencyKey = `-- name: LockIdempotencyKey :exec
+SELECT pg_advisory_lock(hashtext('idempotency_keys'), hashtext($1::text))
+`
+
+// Session-level, in the two-key space so it cannot collide with the outbox
+// lock
+func (q *Queries) LockIdempotencyKey(ctx context.Context, key string) error {
+	_, err := q.db.Exec(ctx, lockIdempotencyKey, key)
+	return err
+}
+
+const markOutboxEventDispatched = `-- name: MarkOutboxEventDispatched :exec
+UPDATE outbox_events
+SET dispatched_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+func (q *Queries) MarkOutboxEventDispatched(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, markOutboxEventDispatched, id)
+	return err
+}
+
+const publishMessages = `-- name: PublishMessages :many
+UPDATE messages
+SET status = 'published',
+    version = version + 1
+WHERE id IN (
+    SELECT id FROM messages
+    WHERE status = 'scheduled' AND deleted_at IS NULL AND publish_at <= $1
+      AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+    ORDER BY publish_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, content, created_at, updated_at, version, deleted_at, search_vector, author_id, tenant_id, labels, metadata, parent_id, thread_id, reply_count, expires_at, status, publish_at
+`
+
+type PublishMessagesParams struct {
+	PublishAt pgtype.Timestamptz `json:"publish_at"`
+	Limit     int32              `json:"limit"`
+}
+
+// Publishes the live scheduled messages of every tenant that are due by the
+// given time, for the scheduler. Like ExpireMessages it skips rows locked
+// by another replica, and a published message is never picked again, so
+// each message is published exactly once
+func (q *Queries) PublishMessages(ctx context.Context, arg PublishMessagesParams) ([]Message, error) {
+	rows, err := q.db.Query(ctx, publishMessages, arg.PublishAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Message{}
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.SearchVector,
+			&i.AuthorID,
+			&i.TenantID,
+			&i.Labels,
+			&i.Metadata,
+			&i.ParentID,
+			&i.ThreadID,
+			&i.ReplyCount,
+			&i.ExpiresAt,
+			&i.Status,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeAttachments = `-- name: PurgeAttachments :many
+DELETE FROM attachments
+WHERE message_id = ANY($1::uuid[])
+  AND (tenant_id = $2 OR $2 = '*')
+RETURNING id, message_id, tenant_id, filename, content_type, size, checksum, storage_key, uploader_id, created_at
+`
+
+type PurgeAttachmentsParams struct {
+	Ids      []uuid.UUID `json:"ids"`
+	TenantID string      `json:"tenant_id"`
+}
+
+// Removes the attachments of purged messages, returning them so their blobs
+// can be deleted. Maintenance jobs purge across tenants
+func (q *Queries) PurgeAttachments(ctx context.Context, arg PurgeAttachmentsParams) ([]Attachment, error) {
+	rows, err := q.db.Query(ctx, purgeAttachments, arg.Ids, arg.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Attachment{}
+	for rows.Next() {
+		var i Attachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.MessageID,
+			&i.TenantID,
+			&i.Filename,
+			&i.ContentType,
+			&i.Size,
+			&i.Checksum,
+			&i.StorageKey,
+			&i.UploaderID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeDeletedMessages = `-- name: PurgeDeletedMessages :many
+DELETE FROM messages
+WHERE id IN (
+    SELECT id FROM messages
+    WHERE deleted_at IS NOT NULL AND deleted_at < $1
+    ORDER BY deleted_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, content, created_at, updated_at, version, deleted_at, search_vector, author_id, tenant_id, labels, metadata, parent_id, thread_id, reply_count, expires_at, status, publish_at
+`
+
+type PurgeDeletedMessagesParams struct {
+	DeletedAt pgtype.Timestamptz `json:"deleted_at"`
+	Limit     int32              `json:"limit"`
+}
+
+// Runs across all tenants, for the retention job. SKIP LOCKED lets several
+// replicas purge concurrently without waiting on or double-purging each
+// other's rows
+func (q *Queries) PurgeDeletedMessages(ctx context.Context, arg PurgeDeletedMessagesParams) ([]Message, error) {
+	rows, err := q.db.Query(ctx, purgeDeletedMessages, arg.DeletedAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Message{}
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.SearchVector,
+			&i.AuthorID,
+			&i.TenantID,
+			&i.Labels,
+			&i.Metadata,
+			&i.ParentID,
+			&i.ThreadID,
+			&i.ReplyCount,
+			&i.ExpiresAt,
+			&i.Status,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeDispatchedOutboxEvents = `-- name: PurgeDispatchedOutboxEvents :execrows
+DELETE FROM outbox_events
+WHERE dispatched_at IS NOT NULL AND dispatched_at < $1
+`
+
+func (q *Queries) PurgeDispatchedOutboxEvents(ctx context.Context, dispatchedAt pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeDispatchedOutboxEvents, dispatchedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const purgeIdempotencyKeys = `-- name: PurgeIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE key IN (
+    SELECT key FROM idempotency_keys
+    WHERE expires_at < CURRENT_TIMESTAMP
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+`
+
+func (q *Queries) PurgeIdempotencyKeys(ctx context.Context, limit int32) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeIdempotencyKeys, limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const purgeMessage = `-- name: PurgeMessage :one
+DELETE FROM messages
+WHERE id = $1 AND tenant_id = $2
+RETURNING id, content, created_at, updated_at, version, deleted_at, search_vector, author_id, tenant_id, labels, metadata, parent_id, thread_id, reply_count, expires_at, status, publish_at
+`
+
+type PurgeMessageParams struct {
+	ID       uuid.UUID `json:"id"`
+	TenantID string    `json:"tenant_id"`
+}
+
+func (q *Queries) PurgeMessage(ctx context.Context, arg PurgeMessageParams) (Message, error) {
+	row := q.db.QueryRow(ctx, purgeMessage, arg.ID, arg.TenantID)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.Content,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.SearchVector,
+		&i.AuthorID,
+		&i.TenantID,
+		&i.Labels,
+		&i.Metadata,
+		&i.ParentID,
+		&i.ThreadID,
+		&i.ReplyCount,
+		&i.ExpiresAt,
+		&i.Status,
+		&i.PublishAt,
+	)
+	return i, err
+}
+
+const putIdempotencyKey = `-- name: PutIdempotencyKey :exec
+INSERT INTO idempotency_keys (key, fingerprint, status, header, body, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (key) DO UPDATE
+SET fingerprint = EXCLUDED.fingerprint,
+    status = EXCLUDED.status,
+    header = EXCLUDED.header,
+    body = EXCLUDED.body,
+    created_at = CURRENT_TIMESTAMP,
+    expires_at = EXCLUDED.expires_at
+`
+
+type PutIdempotencyKeyParams struct {
+	Key         string    `json:"key"`
+	Fingerprint string    `json:"fingerprint"`
+	Status      int32     `json:"status"`
+	Header      []byte    `json:"header"`
+	Body        []byte    `json:"body"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (q *Queries) PutIdempotencyKey(ctx context.Context, arg PutIdempotencyKeyParams) error {
+	_, err := q.db.Exec(ctx, putIdempotencyKey,
+		arg.Key,
+		arg.Fingerprint,
+		arg.Status,
+		arg.Header,
+		arg.Body,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const recordOutboxEventFailure = `-- name: RecordOutboxEventFailure :exec
+UPDATE outbox_events
+SET attempts = attempts + 1, last_error = $2
+WHERE id = $1
+`
+
+type RecordOutboxEventFailureParams struct {
+	ID        int64       `json:"id"`
+	LastError pgtype.Text `json:"last_error"`
+}
+
+func (q *Queries) RecordOutboxEventFailure(ctx context.Context, arg RecordOutboxEventFailureParams) error {
+	_, err := q.db.Exec(ctx, recordOutboxEventFailure, arg.ID, arg.LastError)
+	return err
+}
+
+const restoreMessage = `-- name: RestoreMessage :one
+UPDATE messages
+SET deleted_at = NULL,
+    expires_at = CASE WHEN expires_at <= CURRENT_TIMESTAMP THEN NULL ELSE expires_at END
+WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NOT NULL
+RETURNING id, content, created_at, updated_at, version, deleted_at, search_vector, author_id, tenant_id, labels, metadata, parent_id, thread_id, reply_count, expires_at, status, publish_at
+`
+
+type RestoreMessageParams struct {
+	ID       uuid.UUID `json:"id"`
+	TenantID string    `json:"tenant_id"`
+}
+
+// An expiry that has passed is cleared, or the message would vanish again
+func (q *Queries) RestoreMessage(ctx context.Context, arg RestoreMessageParams) (Message, error) {
+	row := q.db.QueryRow(ctx, restoreMessage, arg.ID, arg.TenantID)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.Content,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.SearchVector,
+		&i.AuthorID,
+		&i.TenantID,
+		&i.Labels,
+		&i.Metadata,
+		&i.ParentID,
+		&i.ThreadID,
+		&i.ReplyCount,
+		&i.ExpiresAt,
+		&i.Status,
+		&i.PublishAt,
+	)
+	return i, err
+}
+
+const searchMessages = `-- name: SearchMessages :many
+SELECT id, content, created_at, updated_at, version, deleted_at, author_id, tenant_id, labels, metadata,
+    parent_id, thread_id, reply_count, expires_at, status, publish_at,
+    ts_rank_cd(search_vector, to_tsquery(messages_search_config(), $1::text))::real AS rank,
+    ts_headline(messages_search_config(), content, to_tsquery(messages_search_config(), $1::text),
+        'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15')::text AS snippet
+FROM messages
+WHERE tenant_id = $2 AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+  AND (status = 'published' OR author_id IS NOT DISTINCT FROM $3)
+  AND search_vector @@ to_tsquery(messages_search_config(), $1::text)
+ORDER BY rank DESC, created_at DESC, id DESC
+LIMIT $4 OFFSET $5
+`
+
+type SearchMessagesParams struct {
+	Query    string      `json:"query"`
+	TenantID string      `json:"tenant_id"`
+	Viewer   pgtype.Text `json:"viewer"`
+	Limit    int32       `json:"limit"`
+	Offset   int32       `json:"offset"`
+}
+
+type SearchMessagesRow struct {
+	ID         uuid.UUID          `json:"id"`
+	Content    string             `json:"content"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
+	Version    int64              `json:"version"`
+	DeletedAt  pgtype.Timestamptz `json:"deleted_at"`
+	AuthorID   pgtype.Text        `json:"author_id"`
+	TenantID   string             `json:"tenant_id"`
+	Labels     []string           `json:"labels"`
+	Metadata   []byte             `json:"metadata"`
+	ParentID   pgtype.UUID        `json:"parent_id"`
+	ThreadID   uuid.UUID          `json:"thread_id"`
+	ReplyCount int64              `json:"reply_count"`
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
+	Status     string             `json:"status"`
+	PublishAt  pgtype.Timestamptz `json:"publish_at"`
+	Rank       float32            `json:"rank"`
+	Snippet    string             `json:"snippet"`
+}
+
+// Live messages matching a to_tsquery query, best match first, with a
+// highlighted snippet of each
+func (q *Queries) SearchMessages(ctx context.Context, arg SearchMessagesParams) ([]SearchMessagesRow, error) {
+	rows, err := q.db.Query(ctx, searchMessages,
+		arg.Query,
+		arg.TenantID,
+		arg.Viewer,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchMessagesRow{}
+	for rows.Next() {
+		var i SearchMessagesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.AuthorID,
+			&i.TenantID,
+			&i.Labels,
+			&i.Metadata,
+			&i.ParentID,
+			&i.ThreadID,
+			&i.ReplyCount,
+			&i.ExpiresAt,
+			&i.Status,
+			&i.PublishAt,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const tryLockOutbox = `-- name: TryLockOutbox :one
+SELECT pg_try_advisory_xact_lock(hashtext('outbox_relay'))
+`
+
+func (q *Queries) TryLockOutbox(ctx context.Context) (bool, error) {
+	row := q.db.QueryRow(ctx, tryLockOutbox)
+	var pg_try_advisory_xact_lock bool
+	err := row.Scan(&pg_try_advisory_xact_lock)
+	return pg_try_advisory_xact_lock, err
+}
+
+const unlockIdempotencyKey = `-- name: UnlockIdempotencyKey :exec
+SELECT pg_advisory_unlock(hashtext('idempotency_keys'), hashtext($1::text))
+`
+
+func (q *Queries) UnlockIdempotencyKey(ctx context.Context, key string) error {
+	_, err := q.db.Exec(ctx, unlockIdempotencyKey, key)
+	return err
+}
+
 const updateMessage = `-- name: UpdateMessage :one
 UPDATE messages
-SET content = $2, updated_at = CURRENT_TIMESTAMP
-WHERE id = $1 AND deleted_at IS NULL
-RETURNING id, content, created_at, updated_at, deleted_at
+SET content = $2, labels = $4, metadata = $5, expires_at = $6,
+    status = $7, publish_at = $8,
+    version = version + 1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND tenant_id = $3 AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+  AND ($9::bigint IS NULL OR version = $9)
+RETURNING id, content, created_at, updated_at, version, deleted_at, search_vector, author_id, tenant_id, labels, metadata, parent_id, thread_id, reply_count, expires_at, status, publish_at
 `
 
 type UpdateMessageParams struct {
-	ID      uuid.UUID `json:"id"`
-	Content string    `json:"content"`
+	ID              uuid.UUID          `json:"id"`
+	Content         string             `json:"content"`
+	TenantID        string             `json:"tenant_id"`
+	Labels          []string           `json:"labels"`
+	Metadata        []byte             `json:"metadata"`
+	ExpiresAt       pgtype.Timestamptz `json:"expires_at"`
+	Status          string             `json:"status"`
+	PublishAt       pgtype.Timestamptz `json:"publish_at"`
+	ExpectedVersion pgtype.Int8        `json:"expected_version"`
 }
 
 func (q *Queries) UpdateMessage(ctx context.Context, arg UpdateMessageParams) (Message, error) {
-	row := q.db.QueryRow(ctx, updateMessage, arg.ID, arg.Content)
+	row := q.db.QueryRow(ctx, updateMessage,
+		arg.ID,
+		arg.Content,
+		arg.TenantID,
+		arg.Labels,
+		arg.Metadata,
+		arg.ExpiresAt,
+		arg.Status,
+		arg.PublishAt,
+		arg.ExpectedVersion,
+	)
 	var i Message
 	err := row.Scan(
 		&i.ID,
 		&i.Content,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
 		&i.DeletedAt,
+		&i.SearchVector,
+		&i.AuthorID,
+		&i.TenantID,
+		&i.Labels,
+		&i.Metadata,
+		&i.ParentID,
+		&i.ThreadID,
+		&i.ReplyCount,
+		&i.ExpiresAt,
+		&i.Status,
+		&i.PublishAt,
 	)
 	return i, err
 }
@@ -0,0 +1,49 @@
+package db
+
+// The row-level security policies on messages and message_revisions only
+// let a session see the rows of the tenant in its app.tenant_id setting.
+// Pooled connections are shared between tenants, so the setting is brought
+// up to date every time a connection is acquired.
+
+import (
+	"context"
+	"sync"
+
+	"go-boilerplate/internal/tenant"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ConfigureTenantSession installs hooks on config that set app.tenant_id on
+// every connection to the tenant of the context acquiring it. The setting
+// is only sent when it changes.
+func ConfigureTenantSession(config *pgxpool.Config) {
+	var current sync.Map // *pgx.Conn to the tenant it is set to
+
+	beforeAcquire := config.BeforeAcquire
+	config.BeforeAcquire = func(ctx context.Context, conn *pgx.Conn) bool {
+		if beforeAcquire != nil && !beforeAcquire(ctx, conn) {
+			return false
+		}
+		id := tenant.FromContext(ctx)
+		if set, ok := current.Load(conn); ok && set.(string) == id {
+			return true
+		}
+		if _, err := conn.Exec(ctx, "SELECT set_config('app.tenant_id', $1, false)", id); err != nil {
+			// The pool destroys the connection and tries another
+			current.Delete(conn)
+			return false
+		}
+		current.Store(conn, id)
+		return true
+	}
+
+	beforeClose := config.BeforeClose
+	config.BeforeClose = func(conn *pgx.Conn) {
+		current.Delete(conn)
+		if beforeClose != nil {
+			beforeClose(conn)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+// Package events defines the message lifecycle events published to Kafka.
+//
+// Every event travels in a CloudEvents 1.0 compatible JSON envelope
+// (structured content mode). The envelope carries the event metadata and a
+// type-specific data payload:
+//
+//	{
+//	    "specversion": "1.0",
+//	    "id": "5f0e2c1a-...",
+//	    "type": "message.updated",
+//	    "source": "/go-boilerplate/message-service",
+//	    "subject": "<message id>",
+//	    "time": "2024-01-01T00:00:00Z",
+//	    "datacontenttype": "application/json",
+//	    "schemaversion": "1",
+//	    "tenantid": "acme",
+//	    "data": {"before": {...}, "after": {...}}
+//	}
+//
+// The same attributes are also set as ce_* Kafka headers so consumers can
+// route on them without parsing the value. Decode turns an envelope back
+// into one of the typed events below.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go-boilerplate/internal/models"
+	"go-boilerplate/internal/moderation"
+)
+
+// Message lifecycle event types
+const (
+	TypeMessageCreated   = "message.created"
+	TypeMessageUpdated   = "message.updated"
+	TypeMessageDeleted   = "message.deleted"
+	TypeMessageRestored  = "message.restored"
+	TypeMessagePurged    = "message.purged"
+	TypeMessageExpired   = "message.expired"
+	TypeMessagePublished = "message.published"
+	TypeMessageFlagged   = "message.flagged"
+)
+
+const (
+	// SpecVersion is the CloudEvents specification version of the envelope
+	SpecVersion = "1.0"
+	// SchemaVersion is the version of the data payload schemas below. Bump it
+	// on incompatible payload changes.
+	SchemaVersion = "1"
+	// Source identifies this service as the producer of the events
+	Source = "/go-boilerplate/message-service"
+	// ContentType is the media type of the serialized envelope
+	ContentType = "application/cloudevents+json"
+)
+
+// Envelope is the CloudEvents envelope wrapping every event. TenantID is a
+// CloudEvents extension attribute naming the tenant of the message.
+type Envelope struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Type            string          `json:"type"`
+	Source          string          `json:"source"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	SchemaVersion   string          `json:"schemaversion"`
+	TenantID        string          `json:"tenantid,omitempty"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// MessageCreatedData is the payload of a message.created event
+type MessageCreatedData struct {
+	Message models.Message `json:"message"`
+}
+
+// MessageUpdatedData is the payload of a message.updated event
+type MessageUpdatedData struct {
+	Before models.Message `json:"before"`
+	After  models.Message `json:"after"`
+}
+
+// MessageDeletedData is the payload of a message.deleted event. Message is
+// the last state before the deletion.
+type MessageDeletedData struct {
+	Message models.Message `json:"message"`
+}
+
+// MessageRestoredData is the payload of a message.restored event. Message is
+// the state after it was taken out of the trash.
+type MessageRestoredData struct {
+	Message models.Message `json:"message"`
+}
+
+// MessagePurgedData is the payload of a message.purged event, emitted when a
+// message is permanently removed. Message is its last stored state.
+type MessagePurgedData struct {
+	Message models.Message `json:"message"`
+}
+
+// MessageExpiredData is the payload of a message.expired event, emitted when
+// a message is moved to the trash because its expiry has passed. Message is
+// the last state before that.
+type MessageExpiredData struct {
+	Message models.Message `json:"message"`
+}
+
+// MessagePublishedData is the payload of a message.published event, emitted
+// when a draft or scheduled message becomes visible to everyone: when its
+// scheduled publish time comes or an update publishes it. Messages created
+// as published only emit message.created. Message is the state after
+// publishing.
+type MessagePublishedData struct {
+	Message models.Message `json:"message"`
+}
+
+// MessageFlaggedData is the payload of a message.flagged event, emitted
+// after message.created or message.updated when the content filters let a
+// write through but flag it for review. Message is the state written, and
+// Violations are what the filters found, masked parts included.
+type MessageFlaggedData struct {
+	Message    models.Message         `json:"message"`
+	Violations []moderation.Violation `json:"violations"`
+}
+
+// Event is a decoded, typed event
+type Event interface {
+	// Meta returns the envelope the event was decoded from. Its Data field
+	// holds the raw payload.
+	Meta() Envelope
+}
+
+// MessageCreated is a decoded message.created event
+type MessageCreated struct {
+	Envelope
+	MessageCreatedData
+}
+
+// MessageUpdated is a decoded message.updated event
+type MessageUpdated struct {
+	Envelope
+	MessageUpdatedData
+}
+
+// MessageDeleted is a decoded message.deleted event
+type MessageDeleted struct {
+	Envelope
+	MessageDeletedData
+}
+
+// MessageRestored is a decoded message.restored event
+type MessageRestored struct {
+	Envelope
+	MessageRestoredData
+}
+
+// MessagePurged is a decoded message.purged event
+type MessagePurged struct {
+	Envelope
+	MessagePurgedData
+}
+
+// MessageExpired is a decoded message.expired event
+type MessageExpired struct {
+	Envelope
+	MessageExpiredData
+}
+
+// MessagePublished is a decoded message.published event
+type MessagePublished struct {
+	Envelope
+	MessagePublishedData
+}
+
+// MessageFlagged is a decoded message.flagged event
+type MessageFlagged struct {
+	Envelope
+	MessageFlaggedData
+}
+
+func (e *MessageCreated) Meta() Envelope   { return e.Envelope }
+func (e *MessageUpdated) Meta() Envelope   { return e.Envelope }
+func (e *MessageDeleted) Meta() Envelope   { return e.Envelope }
+func (e *MessageRestored) Meta() Envelope  { return e.Envelope }
+func (e *MessagePurged) Meta() Envelope    { return e.Envelope }
+func (e *MessageExpired) Meta() Envelope   { return e.Envelope }
+func (e *MessagePublished) Meta() Envelope { return e.Envelope }
+func (e *MessageFlagged) Meta() Envelope   { return e.Envelope }
+
+// NewEnvelope wraps data in an envelope of the given type about subject
+func NewEnvelope(eventType, subject string, data interface{}) (*Envelope, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s data: %w", eventType, err)
+	}
+
+	return &Envelope{
+		SpecVersion:     SpecVersion,
+		ID:              uuid.NewString(),
+		Type:            eventType,
+		Source:          Source,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		SchemaVersion:   SchemaVersion,
+		Data:            payload,
+	}, nil
+}
+
+// Parse decodes the envelope of a serialized event without decoding its data
+func Parse(data []byte) (*Envelope, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event envelope: %w", err)
+	}
+	if envelope.SpecVersion != SpecVersion {
+		return nil, fmt.Errorf("unsupported event spec version %q", envelope.SpecVersion)
+	}
+	if envelope.SchemaVersion != SchemaVersion {
+		return nil, fmt.Errorf("unsupported %s schema version %q", envelope.Type, envelope.SchemaVersion)
+	}
+	return &envelope, nil
+}
+
+// Decode decodes a serialized envelope into its typed event
+func Decode(data []byte) (Event, error) {
+	envelope, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var event Event
+	var payload interface{}
+	switch envelope.Type {
+	case TypeMessageCreated:
+		e := &MessageCreated{Envelope: *envelope}
+		event, payload = e, &e.MessageCreatedData
+	case TypeMessageUpdated:
+		e := &MessageUpdated{Envelope: *envelope}
+		event, payload = e, &e.MessageUpdatedData
+	case TypeMessageDeleted:
+		e := &MessageDeleted{Envelope: *envelope}
+		event, payload = e, &e.MessageDeletedData
+	case TypeMessageRestored:
+		e := &MessageRestored{Envelope: *envelope}
+		event, payload = e, &e.MessageRestoredData
+	case TypeMessagePurged:
+		e := &MessagePurged{Envelope: *envelope}
+		event, payload = e, &e.MessagePurgedData
+	case TypeMessageExpired:
+		e := &MessageExpired{Envelope: *envelope}
+		event, payload = e, &e.MessageExpiredData
+	case TypeMessagePublished:
+		e := &MessagePublished{Envelope: *envelope}
+		event, payload = e, &e.MessagePublishedData
+	case TypeMessageFlagged:
+		e := &MessageFlagged{Envelope: *envelope}
+		event, payload = e, &e.MessageFlaggedData
+	default:
+		return nil, fmt.Errorf("unknown event type %q", envelope.Type)
+	}
+
+	if err := json.Unmarshal(envelope.Data, payload); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s data: %w", envelope.Type, err)
+	}
+
+	return event, nil
+}
+
+// Headers returns the CloudEvents Kafka protocol binding headers for the
+// envelope
+func Headers(envelope *Envelope) map[string]string {
+	headers := map[string]string{
+		"content-type":     ContentType,
+		"ce_specversion":   envelope.SpecVersion,
+		"ce_id":            envelope.ID,
+		"ce_type":          envelope.Type,
+		"ce_source":        envelope.Source,
+		"ce_time":          envelope.Time.Format(time.RFC3339Nano),
+		"ce_schemaversion": envelope.SchemaVersion,
+	}
+	if envelope.Subject != "" {
+		headers["ce_subject"] = envelope.Subject
+	}
+	if envelope.TenantID != "" {
+		headers["ce_tenantid"] = envelope.TenantID
+	}
+	return headers
+}
@@ -0,0 +1,73 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-boilerplate/internal/models"
+)
+
+func TestDecode_RoundTrip(t *testing.T) {
+	before := models.Message{ID: uuid.New(), Content: "before", CreatedAt: time.Now().UTC()}
+	after := before
+	after.Content = "after"
+
+	envelope, err := NewEnvelope(TypeMessageUpdated, before.ID.String(), MessageUpdatedData{Before: before, After: after})
+	require.NoError(t, err)
+	data, err := json.Marshal(envelope)
+	require.NoError(t, err)
+
+	event, err := Decode(data)
+
+	require.NoError(t, err)
+	updated, ok := event.(*MessageUpdated)
+	require.True(t, ok)
+	assert.Equal(t, envelope.ID, updated.Meta().ID)
+	assert.Equal(t, Source, updated.Source)
+	assert.Equal(t, before.ID.String(), updated.Subject)
+	assert.Equal(t, "before", updated.Before.Content)
+	assert.Equal(t, "after", updated.After.Content)
+}
+
+func TestDecode_Errors(t *testing.T) {
+	envelope, err := NewEnvelope(TypeMessageCreated, "id", MessageCreatedData{})
+	require.NoError(t, err)
+
+	unknownType := *envelope
+	unknownType.Type = "message.exploded"
+
+	newerSchema := *envelope
+	newerSchema.SchemaVersion = "2"
+
+	for name, e := range map[string]Envelope{"unknown type": unknownType, "newer schema": newerSchema} {
+		t.Run(name, func(t *testing.T) {
+			data, err := json.Marshal(e)
+			require.NoError(t, err)
+
+			_, err = Decode(data)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err = Decode([]byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestHeaders(t *testing.T) {
+	envelope, err := NewEnvelope(TypeMessageDeleted, "subject-id", MessageDeletedData{})
+	require.NoError(t, err)
+	envelope.TenantID = "acme"
+
+	headers := Headers(envelope)
+
+	assert.Equal(t, ContentType, headers["content-type"])
+	assert.Equal(t, TypeMessageDeleted, headers["ce_type"])
+	assert.Equal(t, envelope.ID, headers["ce_id"])
+	assert.Equal(t, "subject-id", headers["ce_subject"])
+	assert.Equal(t, SchemaVersion, headers["ce_schemaversion"])
+	assert.Equal(t, "acme", headers["ce_tenantid"])
+}
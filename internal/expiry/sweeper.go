@@ -0,0 +1,107 @@
+// Package expiry moves messages whose expiry has passed to the trash.
+//
+// Expired messages disappear from reads and listings the moment they
+// expire. The Sweeper then periodically soft-deletes them, in batches of
+// EXPIRY_SWEEP_BATCH_SIZE every EXPIRY_SWEEP_INTERVAL, and the service emits
+// a message.expired event for each. From the trash they are restorable and
+// purged like any deleted message. Several replicas can run a Sweeper at the
+// same time: rows being swept by one are skipped by the others.
+//
+// Metrics:
+//   - expiry_expired_messages_total: messages moved to the trash
+//   - expiry_sweep_failures_total: failed sweep batches
+package expiry
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go-boilerplate/config"
+	"go-boilerplate/internal/service"
+	"go.uber.org/zap"
+)
+
+// Metrics holds the Prometheus collectors reported by the sweeper.
+type Metrics struct {
+	expired  prometheus.Counter
+	failures prometheus.Counter
+}
+
+// NewMetrics creates the sweeper metrics and registers them with reg.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		expired: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "expiry_expired_messages_total",
+			Help: "Number of expired messages moved to the trash.",
+		}),
+		failures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "expiry_sweep_failures_total",
+			Help: "Number of failed attempts to move a batch of expired messages to the trash.",
+		}),
+	}
+
+	reg.MustRegister(m.expired, m.failures)
+	return m
+}
+
+// Sweeper moves expired messages to the trash until its context is
+// cancelled.
+type Sweeper struct {
+	messageService *service.MessageService
+	cfg            config.ExpiryConfig
+	metrics        *Metrics
+	logger         *zap.Logger
+}
+
+func NewSweeper(messageService *service.MessageService, cfg config.ExpiryConfig, metrics *Metrics, logger *zap.Logger) *Sweeper {
+	return &Sweeper{
+		messageService: messageService,
+		cfg:            cfg,
+		metrics:        metrics,
+		logger:         logger,
+	}
+}
+
+// Run sweeps every SweepInterval until ctx is cancelled.
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.SweepOnce(ctx); err != nil {
+			s.logger.Error("Failed to sweep expired messages", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SweepOnce moves every message that has expired by now to the trash, one
+// batch at a time, and reports how many were moved.
+func (s *Sweeper) SweepOnce(ctx context.Context) (int, error) {
+	total := 0
+	for ctx.Err() == nil {
+		expired, err := s.messageService.ExpireMessages(ctx, s.cfg.SweepBatchSize)
+		if err != nil {
+			s.metrics.failures.Inc()
+			return total, err
+		}
+
+		s.metrics.expired.Add(float64(expired))
+		total += expired
+
+		if expired < int(s.cfg.SweepBatchSize) {
+			break
+		}
+	}
+
+	if total > 0 {
+		s.logger.Info("Moved expired messages to the trash", zap.Int("count", total))
+	}
+	return total, ctx.Err()
+}
@@ -0,0 +1,82 @@
+package expiry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-boilerplate/config"
+	"go-boilerplate/internal/cache"
+	"go-boilerplate/internal/events"
+	"go-boilerplate/internal/models"
+	"go-boilerplate/internal/service"
+	"go-boilerplate/internal/tenant"
+	"go.uber.org/zap"
+)
+
+func TestSweeper_SweepOnce(t *testing.T) {
+	ctx := context.Background()
+	store := service.NewMemoryStore()
+	messageService := service.NewMessageService(store, cache.NewMemoryCache(), service.Options{})
+
+	soon := time.Now().Add(20 * time.Millisecond)
+	later := time.Now().Add(time.Hour)
+
+	var messages []*models.Message
+	for _, expiresAt := range []*time.Time{nil, &soon, &soon, &later} {
+		message := &models.Message{Content: "notice", ExpiresAt: expiresAt}
+		require.NoError(t, messageService.CreateMessage(ctx, message))
+		messages = append(messages, message)
+	}
+
+	// A message of another tenant is swept all the same
+	other := &models.Message{Content: "elsewhere", ExpiresAt: &soon}
+	require.NoError(t, messageService.CreateMessage(tenant.NewContext(ctx, "acme"), other))
+
+	// Skip the events recorded so far
+	_, err := store.DispatchOutbox(ctx, 100, func(*models.OutboxEvent) error { return nil })
+	require.NoError(t, err)
+
+	time.Sleep(30 * time.Millisecond)
+
+	// Expired messages are hidden before the sweeper runs
+	_, err = messageService.GetMessage(ctx, messages[1].ID)
+	assert.ErrorIs(t, err, service.ErrMessageNotFound)
+
+	// A batch size of one exercises sweeping in several batches
+	sweeper := NewSweeper(messageService, config.ExpiryConfig{
+		SweepInterval:  time.Hour,
+		SweepBatchSize: 1,
+	}, NewMetrics(prometheus.NewRegistry()), zap.NewNop())
+
+	expired, err := sweeper.SweepOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, expired)
+
+	for _, message := range []*models.Message{messages[0], messages[3]} {
+		_, err := messageService.GetMessage(ctx, message.ID)
+		assert.NoError(t, err)
+	}
+
+	trashed, total, err := messageService.ListDeletedMessages(ctx, 1, 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+	assert.Len(t, trashed, 2)
+
+	var expiredIDs []string
+	_, err = store.DispatchOutbox(ctx, 100, func(event *models.OutboxEvent) error {
+		assert.Equal(t, events.TypeMessageExpired, event.EventType)
+		expiredIDs = append(expiredIDs, event.AggregateID.String())
+		return nil
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{messages[1].ID.String(), messages[2].ID.String(), other.ID.String()}, expiredIDs)
+
+	// Nothing is left to sweep
+	expired, err = sweeper.SweepOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, expired)
+}
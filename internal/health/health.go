@@ -0,0 +1,82 @@
+// Package health checks whether the dependencies of the service are
+// reachable.
+//
+// A Checker pings each dependency, such as the database, the cache and
+// Kafka, bounding every ping by HEALTH_CHECK_TIMEOUT. The HTTP health
+// endpoints run it on every request, and the gRPC health service runs it
+// every HEALTH_CHECK_INTERVAL, so that both report the same thing.
+package health
+
+import (
+	"context"
+	"time"
+)
+
+// Pinger is implemented by dependencies that can report whether they are
+// reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Check is a dependency checked by a Checker.
+type Check struct {
+	// Name identifies the dependency in health reports, e.g. "database"
+	Name   string
+	Pinger Pinger
+}
+
+// Result is the outcome of a Check; Err is nil if the dependency is
+// reachable.
+type Result struct {
+	Name string
+	Err  error
+}
+
+// Checker runs a fixed list of checks.
+type Checker struct {
+	checks  []Check
+	timeout time.Duration
+}
+
+// NewChecker returns a Checker running checks, in order, each bounded by
+// timeout.
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+// Names returns the names of the checks, in order.
+func (c *Checker) Names() []string {
+	names := make([]string, 0, len(c.checks))
+	for _, check := range c.checks {
+		names = append(names, check.Name)
+	}
+	return names
+}
+
+// Run runs every check and returns their results, in order.
+func (c *Checker) Run(ctx context.Context) []Result {
+	results := make([]Result, 0, len(c.checks))
+	for _, check := range c.checks {
+		results = append(results, Result{Name: check.Name, Err: c.ping(ctx, check.Pinger)})
+	}
+	return results
+}
+
+func (c *Checker) ping(ctx context.Context, pinger Pinger) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return pinger.Ping(ctx)
+}
+
+// Healthy reports whether every result is a success.
+func Healthy(results []Result) bool {
+	for _, result := range results {
+		if result.Err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pingFunc func(ctx context.Context) error
+
+func (f pingFunc) Ping(ctx context.Context) error {
+	return f(ctx)
+}
+
+func TestChecker_Run(t *testing.T) {
+	down := errors.New("connection refused")
+	checker := NewChecker(10*time.Millisecond,
+		Check{Name: "database", Pinger: pingFunc(func(ctx context.Context) error { return nil })},
+		Check{Name: "cache", Pinger: pingFunc(func(ctx context.Context) error { return down })},
+		Check{Name: "kafka", Pinger: pingFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})},
+	)
+	assert.Equal(t, []string{"database", "cache", "kafka"}, checker.Names())
+
+	results := checker.Run(context.Background())
+	require.Len(t, results, 3)
+	assert.Equal(t, Result{Name: "database"}, results[0])
+	assert.ErrorIs(t, results[1].Err, down)
+	assert.ErrorIs(t, results[2].Err, context.DeadlineExceeded, "a hanging check gives up after the timeout")
+	assert.False(t, Healthy(results))
+	assert.True(t, Healthy(results[:1]))
+}
@@ -0,0 +1,59 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// FallbackStore uses a primary Store and switches to a secondary one for
+// every operation the primary fails, such as while Redis is unavailable.
+// Lookups that miss in the primary also check the secondary, so responses
+// stored during an outage are still replayed once the primary is back.
+type FallbackStore struct {
+	primary   Store
+	secondary Store
+	logger    *zap.Logger
+}
+
+func NewFallbackStore(primary, secondary Store, logger *zap.Logger) *FallbackStore {
+	return &FallbackStore{
+		primary:   primary,
+		secondary: secondary,
+		logger:    logger,
+	}
+}
+
+func (s *FallbackStore) Lock(ctx context.Context, key string, ttl time.Duration) (func(), error) {
+	unlock, err := s.primary.Lock(ctx, key, ttl)
+	if err == nil || ctx.Err() != nil {
+		return unlock, err
+	}
+
+	s.logger.Warn("Idempotency store unavailable, locking in fallback", zap.Error(err))
+	return s.secondary.Lock(ctx, key, ttl)
+}
+
+func (s *FallbackStore) Get(ctx context.Context, key string) (*Response, error) {
+	response, err := s.primary.Get(ctx, key)
+	if err == nil {
+		return response, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		s.logger.Warn("Idempotency store unavailable, reading from fallback", zap.Error(err))
+	}
+
+	return s.secondary.Get(ctx, key)
+}
+
+func (s *FallbackStore) Put(ctx context.Context, key string, response *Response, ttl time.Duration) error {
+	err := s.primary.Put(ctx, key, response, ttl)
+	if err == nil {
+		return nil
+	}
+
+	s.logger.Warn("Idempotency store unavailable, writing to fallback", zap.Error(err))
+	return s.secondary.Put(ctx, key, response, ttl)
+}
@@ -0,0 +1,135 @@
+// Package idempotency makes retried requests safe to repeat.
+//
+// A client that sends a request with an idempotency key gets the same
+// response for every retry with that key, while the request itself runs only
+// once. The first successful response is stored for IDEMPOTENCY_TTL together
+// with a fingerprint of the request; a retry whose request differs from the
+// original fails with ErrKeyReused. Requests with the same key are
+// serialized, so a retry that arrives while the original is still running
+// waits for it and then replays its response. Failed requests are not
+// stored and may be retried.
+//
+// Keys are scoped to the operation, the tenant and the authenticated caller,
+// so two clients cannot collide by choosing the same key.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-boilerplate/config"
+	"go-boilerplate/internal/apperr"
+	"go-boilerplate/internal/auth"
+	"go-boilerplate/internal/tenant"
+	"go.uber.org/zap"
+)
+
+// ErrKeyReused is returned when an idempotency key is sent again with a
+// different request.
+var ErrKeyReused = apperr.New(apperr.InvalidArgument, "IDEMPOTENCY_KEY_REUSED", "idempotency key was already used for a different request")
+
+// ErrNotFound is returned by Store.Get when no response is stored for a key.
+var ErrNotFound = errors.New("idempotency key not found")
+
+// MaxKeyLength bounds the length of client-chosen keys.
+const MaxKeyLength = 255
+
+// Response is a stored response, replayed to retries. Status, Header and
+// Body are up to the transport that stored it.
+type Response struct {
+	Fingerprint string            `json:"fingerprint"`
+	Status      int               `json:"status"`
+	Header      map[string]string `json:"header,omitempty"`
+	Body        []byte            `json:"body"`
+}
+
+// Store keeps responses and serializes the requests made with a key.
+type Store interface {
+	// Lock blocks until it holds the lock on key or ctx is done. The lock
+	// is released by calling unlock, or after ttl should the holder never
+	// do so.
+	Lock(ctx context.Context, key string, ttl time.Duration) (unlock func(), err error)
+	// Get returns the response stored for key, or ErrNotFound.
+	Get(ctx context.Context, key string) (*Response, error)
+	// Put stores the response for key for ttl.
+	Put(ctx context.Context, key string, response *Response, ttl time.Duration) error
+}
+
+// Keeper runs requests at most once per idempotency key.
+type Keeper struct {
+	store  Store
+	cfg    config.IdempotencyConfig
+	logger *zap.Logger
+}
+
+func NewKeeper(store Store, cfg config.IdempotencyConfig, logger *zap.Logger) *Keeper {
+	return &Keeper{
+		store:  store,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// Do returns the response stored for key if there is one, and otherwise
+// runs fn and stores the response it returns. operation names what fn does,
+// such as "http:CreateMessage", and fingerprint identifies the request; see
+// Fingerprint. replayed reports whether the response is a stored one. Errors
+// from fn are returned unchanged and nothing is stored for them.
+func (k *Keeper) Do(ctx context.Context, operation, key, fingerprint string, fn func() (*Response, error)) (response *Response, replayed bool, err error) {
+	if key == "" || len(key) > MaxKeyLength {
+		return nil, false, fmt.Errorf("idempotency key must be 1 to %d characters", MaxKeyLength)
+	}
+	key = scopedKey(ctx, operation, key)
+
+	unlock, err := k.store.Lock(ctx, key, k.cfg.LockTimeout)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to lock idempotency key: %w", err)
+	}
+	defer unlock()
+
+	stored, err := k.store.Get(ctx, key)
+	switch {
+	case err == nil:
+		if stored.Fingerprint != fingerprint {
+			return nil, false, ErrKeyReused
+		}
+		return stored, true, nil
+	case !errors.Is(err, ErrNotFound):
+		return nil, false, fmt.Errorf("failed to look up idempotency key: %w", err)
+	}
+
+	response, err = fn()
+	if err != nil {
+		return nil, false, err
+	}
+
+	response.Fingerprint = fingerprint
+	if err := k.store.Put(ctx, key, response, k.cfg.TTL); err != nil {
+		// The request went through; failing it now would only invite the
+		// duplicate the key is meant to prevent
+		k.logger.Error("Failed to store idempotent response", zap.String("operation", operation), zap.Error(err))
+	}
+
+	return response, false, nil
+}
+
+// Fingerprint identifies a request by the bytes that make it up, typically
+// its canonical encoding.
+func Fingerprint(request []byte) string {
+	sum := sha256.Sum256(request)
+	return hex.EncodeToString(sum[:])
+}
+
+// scopedKey qualifies a client key with the operation, the tenant and the
+// caller.
+func scopedKey(ctx context.Context, operation, key string) string {
+	subject := "-"
+	if claims, ok := auth.FromContext(ctx); ok && claims.UserID != "" {
+		subject = claims.UserID
+	}
+	return fmt.Sprintf("%s:%s:%s:%s", operation, tenant.FromContext(ctx), subject, key)
+}
@@ -0,0 +1,127 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-boilerplate/config"
+	"go-boilerplate/internal/auth"
+	"go.uber.org/zap"
+)
+
+func newTestKeeper(store Store) *Keeper {
+	return NewKeeper(store, config.IdempotencyConfig{TTL: time.Hour, LockTimeout: time.Minute}, zap.NewNop())
+}
+
+func TestKeeper_Replay(t *testing.T) {
+	keeper := newTestKeeper(NewMemoryStore())
+	ctx := context.Background()
+
+	var calls int
+	create := func() (*Response, error) {
+		calls++
+		return &Response{Status: 201, Body: []byte(`{"id":1}`)}, nil
+	}
+
+	resp, replayed, err := keeper.Do(ctx, "create", "key", Fingerprint([]byte("a")), create)
+	require.NoError(t, err)
+	assert.False(t, replayed)
+	assert.Equal(t, 201, resp.Status)
+
+	resp, replayed, err = keeper.Do(ctx, "create", "key", Fingerprint([]byte("a")), create)
+	require.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, `{"id":1}`, string(resp.Body))
+	assert.Equal(t, 1, calls)
+
+	_, _, err = keeper.Do(ctx, "create", "key", Fingerprint([]byte("b")), create)
+	assert.ErrorIs(t, err, ErrKeyReused)
+
+	// Keys are scoped to the operation and the caller
+	_, replayed, err = keeper.Do(ctx, "update", "key", Fingerprint([]byte("b")), create)
+	require.NoError(t, err)
+	assert.False(t, replayed)
+
+	other := auth.NewContext(ctx, &auth.Claims{UserID: "other"})
+	_, replayed, err = keeper.Do(other, "create", "key", Fingerprint([]byte("b")), create)
+	require.NoError(t, err)
+	assert.False(t, replayed)
+	assert.Equal(t, 3, calls)
+}
+
+func TestKeeper_FailuresAreNotStored(t *testing.T) {
+	keeper := newTestKeeper(NewMemoryStore())
+	ctx := context.Background()
+	failure := errors.New("boom")
+
+	_, _, err := keeper.Do(ctx, "create", "key", "fp", func() (*Response, error) { return nil, failure })
+	assert.ErrorIs(t, err, failure)
+
+	_, replayed, err := keeper.Do(ctx, "create", "key", "fp", func() (*Response, error) { return &Response{Status: 201}, nil })
+	require.NoError(t, err)
+	assert.False(t, replayed)
+}
+
+func TestKeeper_ConcurrentRequestsAreSerialized(t *testing.T) {
+	keeper := newTestKeeper(NewMemoryStore())
+	ctx := context.Background()
+
+	var calls, running int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := keeper.Do(ctx, "create", "key", "fp", func() (*Response, error) {
+				assert.Equal(t, int32(1), atomic.AddInt32(&running, 1))
+				defer atomic.AddInt32(&running, -1)
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(5 * time.Millisecond)
+				return &Response{Status: 201}, nil
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls)
+}
+
+// failingStore is a Store that is always unavailable.
+type failingStore struct{}
+
+var errUnavailable = errors.New("unavailable")
+
+func (failingStore) Lock(context.Context, string, time.Duration) (func(), error) {
+	return nil, errUnavailable
+}
+
+func (failingStore) Get(context.Context, string) (*Response, error) {
+	return nil, errUnavailable
+}
+
+func (failingStore) Put(context.Context, string, *Response, time.Duration) error {
+	return errUnavailable
+}
+
+func TestFallbackStore(t *testing.T) {
+	secondary := NewMemoryStore()
+	keeper := newTestKeeper(NewFallbackStore(failingStore{}, secondary, zap.NewNop()))
+	ctx := context.Background()
+
+	create := func() (*Response, error) { return &Response{Status: 201}, nil }
+	_, _, err := keeper.Do(ctx, "create", "key", "fp", create)
+	require.NoError(t, err)
+
+	// Responses stored during the outage are found once the primary is back
+	keeper = newTestKeeper(NewFallbackStore(NewMemoryStore(), secondary, zap.NewNop()))
+	_, replayed, err := keeper.Do(ctx, "create", "key", "fp", create)
+	require.NoError(t, err)
+	assert.True(t, replayed)
+}
@@ -0,0 +1,76 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	response  Response
+	expiresAt time.Time
+}
+
+// MemoryStore is a Store that keeps responses in process memory. Its locks
+// never expire; a holder cannot die without taking the store with it.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	// locks holds a channel per locked key, closed on unlock
+	locks map[string]chan struct{}
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]memoryEntry),
+		locks:   make(map[string]chan struct{}),
+	}
+}
+
+func (s *MemoryStore) Lock(ctx context.Context, key string, ttl time.Duration) (func(), error) {
+	for {
+		s.mu.Lock()
+		held, ok := s.locks[key]
+		if !ok {
+			released := make(chan struct{})
+			s.locks[key] = released
+			s.mu.Unlock()
+
+			return func() {
+				s.mu.Lock()
+				delete(s.locks, key)
+				s.mu.Unlock()
+				close(released)
+			}, nil
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-held:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		delete(s.entries, key)
+		return nil, ErrNotFound
+	}
+
+	response := entry.response
+	return &response, nil
+}
+
+func (s *MemoryStore) Put(ctx context.Context, key string, response *Response, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = memoryEntry{response: *response, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
@@ -0,0 +1,97 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go-boilerplate/internal/db"
+)
+
+// purgeBatchSize is how many expired keys each Put removes on the side.
+const purgeBatchSize = 100
+
+// PostgresStore is a Store backed by the idempotency_keys table. Locks are
+// session-level advisory locks, so each held lock pins a pool connection
+// until it is released; they are never older than the connection holding
+// them and ttl is not needed.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{pool: pool}
+}
+
+func (s *PostgresStore) Lock(ctx context.Context, key string, ttl time.Duration) (func(), error) {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	queries := db.New(conn)
+	if err := queries.LockIdempotencyKey(ctx, key); err != nil {
+		conn.Release()
+		return nil, err
+	}
+
+	return func() {
+		if err := queries.UnlockIdempotencyKey(context.Background(), key); err != nil {
+			// Closing the connection ends the session and its locks
+			_ = conn.Hijack().Close(context.Background())
+			return
+		}
+		conn.Release()
+	}, nil
+}
+
+func (s *PostgresStore) Get(ctx context.Context, key string) (*Response, error) {
+	result, err := db.New(s.pool).GetIdempotencyKey(ctx, key)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response := &Response{
+		Fingerprint: result.Fingerprint,
+		Status:      int(result.Status),
+		Body:        result.Body,
+	}
+	if err := json.Unmarshal(result.Header, &response.Header); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response header: %w", err)
+	}
+
+	return response, nil
+}
+
+func (s *PostgresStore) Put(ctx context.Context, key string, response *Response, ttl time.Duration) error {
+	header, err := json.Marshal(response.Header)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response header: %w", err)
+	}
+	if response.Header == nil {
+		header = []byte("{}")
+	}
+
+	queries := db.New(s.pool)
+	if err := queries.PutIdempotencyKey(ctx, db.PutIdempotencyKeyParams{
+		Key:         key,
+		Fingerprint: response.Fingerprint,
+		Status:      int32(response.Status),
+		Header:      header,
+		Body:        response.Body,
+		ExpiresAt:   time.Now().Add(ttl),
+	}); err != nil {
+		return err
+	}
+
+	// Expired keys are only ever read past, so clear a few out on the way
+	_, err = queries.PurgeIdempotencyKeys(ctx, purgeBatchSize)
+	return err
+}
@@ -0,0 +1,89 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// lockPollInterval is how often a waiting Lock retries a held key.
+const lockPollInterval = 25 * time.Millisecond
+
+// unlockScript deletes a lock only if it is still held by the caller's
+// token, so an expired lock taken over by another request stays intact.
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisStore is a Store backed by Redis. Responses expire through the Redis
+// TTL, and locks are keys set with SET NX.
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Lock(ctx context.Context, key string, ttl time.Duration) (func(), error) {
+	lockKey := redisKey(key) + ":lock"
+	token := uuid.NewString()
+
+	for {
+		ok, err := s.client.SetNX(ctx, lockKey, token, ttl).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return func() {
+				// Unlock even if the request context is gone; the lock
+				// expires anyway should this fail
+				_ = unlockScript.Run(context.Background(), s.client, []string{lockKey}, token).Err()
+			}, nil
+		}
+
+		select {
+		case <-time.After(lockPollInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) (*Response, error) {
+	data, err := s.client.Get(ctx, redisKey(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var response Response
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &response, nil
+}
+
+func (s *RedisStore) Put(ctx context.Context, key string, response *Response, ttl time.Duration) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %w", err)
+	}
+
+	return s.client.Set(ctx, redisKey(key), data, ttl).Err()
+}
+
+func redisKey(key string) string {
+	return "idempotency:" + key
+}
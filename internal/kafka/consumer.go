@@ -2,11 +2,10 @@ package kafka
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
 	"github.com/Shopify/sarama"
-	"go-boilerplate/internal/models"
+	"go-boilerplate/internal/events"
 	"go.uber.org/zap"
 )
 
@@ -14,6 +13,7 @@ type Consumer struct {
 	consumer sarama.Consumer
 	topic    string
 	logger   *zap.Logger
+	handlers []func(events.Event)
 }
 
 func NewConsumer(brokers []string, topic string, logger *zap.Logger) (*Consumer, error) {
@@ -58,6 +58,13 @@ func NewConsumer(brokers []string, topic string, logger *zap.Logger) (*Consumer,
 	}, nil
 }
 
+// Handle registers handler to be called with every event consumed. Handlers
+// must be registered before Start, and are called from one goroutine per
+// partition.
+func (c *Consumer) Handle(handler func(events.Event)) {
+	c.handlers = append(c.handlers, handler)
+}
+
 func (c *Consumer) Start(ctx context.Context) error {
 	partitions, err := c.consumer.Partitions(c.topic)
 	if err != nil {
@@ -76,16 +83,16 @@ func (c *Consumer) Start(ctx context.Context) error {
 			for {
 				select {
 				case msg := <-pc.Messages():
-					var message models.Message
-					if err := json.Unmarshal(msg.Value, &message); err != nil {
-						c.logger.Error("Failed to unmarshal message", zap.Error(err))
+					event, err := events.Decode(msg.Value)
+					if err != nil {
+						c.logger.Error("Failed to decode event", zap.Error(err))
 						continue
 					}
 
-					c.logger.Info("Received message",
-						zap.String("id", message.ID.String()),
-						zap.String("content", message.Content),
-					)
+					c.logEvent(event)
+					for _, handler := range c.handlers {
+						handler(event)
+					}
 
 				case <-ctx.Done():
 					return
@@ -97,6 +104,35 @@ func (c *Consumer) Start(ctx context.Context) error {
 	return nil
 }
 
+func (c *Consumer) logEvent(event events.Event) {
+	meta := event.Meta()
+	fields := []zap.Field{
+		zap.String("event_id", meta.ID),
+		zap.String("type", meta.Type),
+		zap.String("subject", meta.Subject),
+		zap.String("tenant", meta.TenantID),
+	}
+
+	switch e := event.(type) {
+	case *events.MessageCreated:
+		fields = append(fields, zap.String("content", e.Message.Content))
+	case *events.MessageUpdated:
+		fields = append(fields,
+			zap.String("before", e.Before.Content),
+			zap.String("after", e.After.Content),
+		)
+	case *events.MessageDeleted:
+		fields = append(fields, zap.String("content", e.Message.Content))
+	case *events.MessageFlagged:
+		fields = append(fields,
+			zap.String("content", e.Message.Content),
+			zap.Any("violations", e.Violations),
+		)
+	}
+
+	c.logger.Info("Received event", fields...)
+}
+
 func (c *Consumer) Close() error {
 	return c.consumer.Close()
 }
@@ -0,0 +1,67 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+
+	"go-boilerplate/internal/events"
+	"go-boilerplate/internal/models"
+)
+
+// MemoryProducer is an in-process stand-in for Producer that records every
+// published event instead of sending it to Kafka. It also stands in for the
+// Consumer, handing every event published to its handlers.
+type MemoryProducer struct {
+	mu       sync.Mutex
+	events   []models.OutboxEvent
+	handlers []func(events.Event)
+}
+
+func NewMemoryProducer() *MemoryProducer {
+	return &MemoryProducer{}
+}
+
+func (p *MemoryProducer) PublishEvent(event *models.OutboxEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, *event)
+	if len(p.handlers) == 0 {
+		return nil
+	}
+
+	decoded, err := events.Decode(event.Payload)
+	if err != nil {
+		return err
+	}
+	for _, handler := range p.handlers {
+		handler(decoded)
+	}
+	return nil
+}
+
+// Handle registers handler to be called with every event published, as
+// Consumer.Handle does.
+func (p *MemoryProducer) Handle(handler func(events.Event)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.handlers = append(p.handlers, handler)
+}
+
+// Events returns a copy of everything published so far, oldest first.
+func (p *MemoryProducer) Events() []models.OutboxEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]models.OutboxEvent(nil), p.events...)
+}
+
+// Ping always succeeds.
+func (p *MemoryProducer) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (p *MemoryProducer) Close() error {
+	return nil
+}
@@ -1,13 +1,15 @@
 package kafka
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"github.com/Shopify/sarama"
+	"go-boilerplate/internal/events"
 	"go-boilerplate/internal/models"
 )
 
 type Producer struct {
+	client   sarama.Client
 	producer sarama.SyncProducer
 	topic    string
 }
@@ -19,33 +21,70 @@ func NewProducer(brokers []string, topic string) (*Producer, error) {
 	config.Producer.Return.Successes = true
 	config.Version = sarama.V2_8_0_0 // Match consumer version
 
-	producer, err := sarama.NewSyncProducer(brokers, config)
+	// Keep the client, so that Ping can reach the brokers
+	client, err := sarama.NewClient(brokers, config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create producer: %w", err)
 	}
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to create producer: %w", err)
+	}
 
 	return &Producer{
+		client:   client,
 		producer: producer,
 		topic:    topic,
 	}, nil
 }
 
-func (p *Producer) PublishMessage(message *models.Message) error {
-	data, err := json.Marshal(message)
+// PublishEvent sends an outbox event, keyed by its aggregate ID so that all
+// events for one message land on the same partition in order. The payload is
+// a serialized events.Envelope whose attributes are mirrored into ce_*
+// headers.
+func (p *Producer) PublishEvent(event *models.OutboxEvent) error {
+	envelope, err := events.Parse(event.Payload)
 	if err != nil {
 		return err
 	}
 
+	var headers []sarama.RecordHeader
+	for key, value := range events.Headers(envelope) {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+	}
+
 	msg := &sarama.ProducerMessage{
-		Topic: p.topic,
-		Key:   sarama.StringEncoder(message.ID.String()),
-		Value: sarama.ByteEncoder(data),
+		Topic:   p.topic,
+		Key:     sarama.StringEncoder(event.AggregateID.String()),
+		Value:   sarama.ByteEncoder(event.Payload),
+		Headers: headers,
 	}
 
 	_, _, err = p.producer.SendMessage(msg)
 	return err
 }
 
+// Ping reports whether the brokers can be reached, by refreshing the
+// metadata of the topic.
+func (p *Producer) Ping(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- p.client.RefreshMetadata(p.topic)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (p *Producer) Close() error {
-	return p.producer.Close()
+	if err := p.producer.Close(); err != nil {
+		_ = p.client.Close()
+		return err
+	}
+	return p.client.Close()
 }
@@ -0,0 +1,91 @@
+// Package metadata validates the free-form metadata attached to messages.
+//
+// Metadata is a JSON object. A deployment may constrain its shape with a
+// JSON Schema, loaded from METADATA_SCHEMA_FILE; every metadata object
+// written afterwards must conform to it. Drafts 4 through 2020-12 are
+// supported, and the schema's $schema keyword picks the draft.
+package metadata
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// ErrInvalid is returned for metadata that does not conform to the schema.
+var ErrInvalid = errors.New("metadata does not match the schema")
+
+// Schema is a compiled JSON Schema for message metadata. A nil *Schema
+// accepts any metadata.
+type Schema struct {
+	schema *jsonschema.Schema
+}
+
+// LoadSchema compiles the JSON Schema in the file at path.
+func LoadSchema(path string) (*Schema, error) {
+	schema, err := jsonschema.Compile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile metadata schema %s: %w", path, err)
+	}
+	return &Schema{schema: schema}, nil
+}
+
+// Validate checks metadata against the schema. metadata holds decoded JSON,
+// as encoding/json produces it. The error wraps ErrInvalid and describes
+// the first violations found.
+func (s *Schema) Validate(metadata map[string]interface{}) error {
+	if s == nil {
+		return nil
+	}
+
+	// A nil map is the empty object
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	if err := s.schema.Validate(metadata); err != nil {
+		var validationErr *jsonschema.ValidationError
+		if errors.As(err, &validationErr) {
+			return fmt.Errorf("%w: %s", ErrInvalid, describe(validationErr))
+		}
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return nil
+}
+
+// maxViolations bounds how many violations an error lists.
+const maxViolations = 5
+
+// describe lists the innermost violations of err, which name the offending
+// values rather than the schema branches that led to them.
+func describe(err *jsonschema.ValidationError) string {
+	var causes []*jsonschema.ValidationError
+	var walk func(*jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			causes = append(causes, e)
+			return
+		}
+		for _, cause := range e.Causes {
+			walk(cause)
+		}
+	}
+	walk(err)
+
+	description := ""
+	for i, cause := range causes {
+		if i == maxViolations {
+			description += fmt.Sprintf("; and %d more", len(causes)-i)
+			break
+		}
+		if i > 0 {
+			description += "; "
+		}
+		location := cause.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		description += fmt.Sprintf("%s: %s", location, cause.Message)
+	}
+	return description
+}
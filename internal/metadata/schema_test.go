@@ -0,0 +1,80 @@
+package metadata
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"properties": {
+		"priority": {"type": "integer", "minimum": 1, "maximum": 5},
+		"source": {"type": "string"}
+	},
+	"required": ["source"],
+	"additionalProperties": false
+}`
+
+func loadTestSchema(t *testing.T) *Schema {
+	path := filepath.Join(t.TempDir(), "metadata.schema.json")
+	require.NoError(t, os.WriteFile(path, []byte(testSchema), 0o600))
+
+	schema, err := LoadSchema(path)
+	require.NoError(t, err)
+	return schema
+}
+
+func TestSchema_Validate(t *testing.T) {
+	schema := loadTestSchema(t)
+
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		valid    bool
+	}{
+		{"conforming", map[string]interface{}{"source": "web", "priority": float64(3)}, true},
+		{"missing required", map[string]interface{}{"priority": float64(3)}, false},
+		{"nil is the empty object", nil, false},
+		{"wrong type", map[string]interface{}{"source": "web", "priority": "high"}, false},
+		{"out of range", map[string]interface{}{"source": "web", "priority": float64(9)}, false},
+		{"unknown key", map[string]interface{}{"source": "web", "color": "red"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.Validate(tt.metadata)
+			if tt.valid {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, ErrInvalid), "got %v", err)
+		})
+	}
+}
+
+func TestSchema_ValidateNamesTheViolation(t *testing.T) {
+	schema := loadTestSchema(t)
+
+	err := schema.Validate(map[string]interface{}{"source": "web", "priority": "high"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "/priority")
+}
+
+func TestSchema_NilAcceptsAnything(t *testing.T) {
+	var schema *Schema
+	assert.NoError(t, schema.Validate(map[string]interface{}{"anything": []interface{}{1, "two"}}))
+}
+
+func TestLoadSchema_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"type": 42}`), 0o600))
+
+	_, err := LoadSchema(path)
+	assert.Error(t, err)
+}
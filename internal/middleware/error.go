@@ -1,99 +1,95 @@
 // Package middleware provides HTTP middleware components for the application.
 //
-// The error middleware implements centralized error handling for the HTTP server.
-// It catches all errors thrown during request processing and converts them into
-// appropriate HTTP responses with consistent error formatting.
+// The error handler implements centralized error handling for the HTTP server.
+// Handlers return errors instead of writing error responses, and the handler
+// set as echo.Echo.HTTPErrorHandler turns them into RFC 9457 problem details
+// through the shared error model of package apperr, so that the REST API and
+// the gRPC API report a failure the same way.
 //
-// Key features:
-// - Centralized error handling
-// - Custom error types support
-// - Consistent error response format
-// - HTTP status code mapping
-// - Detailed error logging
+// Error Response Format (application/problem+json):
 //
-// Error Response Format:
-//  {
-//      "error": {
-//          "code": "INVALID_REQUEST",
-//          "message": "Invalid request parameters",
-//          "details": {...}
-//      }
-//  }
+//	{
+//	    "type": "about:blank",
+//	    "title": "Unprocessable Entity",
+//	    "status": 422,
+//	    "detail": "invalid content: content must not be empty",
+//	    "instance": "/api/v1/messages",
+//	    "code": "INVALID_CONTENT",
+//	    "errors": [{"field": "content", "reason": "INVALID_CONTENT", "description": "..."}]
+//	}
 //
 // Usage:
-//  e := echo.New()
-//  e.Use(middleware.ErrorHandler())
+//
+//	e := echo.New()
+//	e.HTTPErrorHandler = middleware.ErrorHandler(logger)
 package middleware
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/labstack/echo/v4"
 	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"go-boilerplate/internal/apperr"
+	"go.uber.org/zap"
 )
 
-// AppError represents a structured error response
-type AppError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Details string `json:"details,omitempty"`
-}
+// ErrorHandler returns an echo.HTTPErrorHandler writing err as problem
+// details. An *echo.HTTPError keeps its status and message and any other
+// error, *RequestError included, is mapped by apperr.ToProblem. Server
+// errors are logged with their cause, which is never sent to the client.
+func ErrorHandler(logger *zap.Logger) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
 
-// NotFoundError represents a resource not found error
-type NotFoundError struct {
-	Resource string
-	ID       string
-}
+		problem := toProblem(err)
+		problem.Instance = c.Request().URL.Path
+		if problem.Status >= http.StatusInternalServerError {
+			logger.Error("request failed",
+				zap.String("method", c.Request().Method),
+				zap.String("path", c.Request().URL.Path),
+				zap.Int("status", problem.Status),
+				zap.Error(err),
+			)
+		}
 
-func (e *NotFoundError) Error() string {
-	return fmt.Sprintf("%s with ID %s not found", e.Resource, e.ID)
+		if err := writeProblem(c, problem); err != nil {
+			logger.Warn("failed to write error response", zap.Error(err))
+		}
+	}
 }
 
-// ErrorResponse wraps the AppError for JSON response
-type ErrorResponse struct {
-	Error AppError `json:"error"`
+// toProblem converts err into the problem details reporting it
+func toProblem(err error) *apperr.Problem {
+	var httpErr *echo.HTTPError
+	if !errors.As(err, &httpErr) {
+		return apperr.ToProblem(err)
+	}
+	if httpErr.Code >= http.StatusInternalServerError {
+		return apperr.NewProblem(httpErr.Code, http.StatusText(httpErr.Code))
+	}
+	detail, ok := httpErr.Message.(string)
+	if !ok {
+		detail = fmt.Sprint(httpErr.Message)
+	}
+	return apperr.NewProblem(httpErr.Code, detail)
 }
 
-// ErrorHandler middleware handles application errors and converts them to structured responses
-func ErrorHandler(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		err := next(c)
-		if err == nil {
-			return nil
-		}
-
-		var response ErrorResponse
-
-		switch {
-		case errors.Is(err, &NotFoundError{}):
-			response = ErrorResponse{
-				Error: AppError{
-					Code:    http.StatusNotFound,
-					Message: "Not Found",
-					Details: err.Error(),
-				},
-			}
-			return c.JSON(http.StatusNotFound, response)
-
-		case errors.As(err, &ValidationError{}):
-			response = ErrorResponse{
-				Error: AppError{
-					Code:    http.StatusBadRequest,
-					Message: "Validation Error",
-					Details: err.Error(),
-				},
-			}
-			return c.JSON(http.StatusBadRequest, response)
+func writeProblem(c echo.Context, problem *apperr.Problem) error {
+	if problem.RetryAfter > 0 {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(problem.RetryAfter))
+	}
+	if c.Request().Method == http.MethodHead {
+		return c.NoContent(problem.Status)
+	}
 
-		default:
-			response = ErrorResponse{
-				Error: AppError{
-					Code:    http.StatusInternalServerError,
-					Message: "Internal Server Error",
-					Details: err.Error(),
-				},
-			}
-			return c.JSON(http.StatusInternalServerError, response)
-		}
+	body, err := json.Marshal(problem)
+	if err != nil {
+		return err
 	}
+	return c.Blob(problem.Status, apperr.MIMEProblem, body)
 }
@@ -4,12 +4,17 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-boilerplate/internal/apperr"
+	"go.uber.org/zap"
 )
 
 func TestErrorHandler(t *testing.T) {
@@ -17,45 +22,62 @@ func TestErrorHandler(t *testing.T) {
 		name           string
 		error          error
 		expectedStatus int
-		expectedBody   ErrorResponse
+		expectedBody   apperr.Problem
 	}{
 		{
-			name:           "Record Not Found Error",
+			name:           "HTTP Error",
 			error:          echo.NewHTTPError(http.StatusNotFound, "record not found"),
 			expectedStatus: http.StatusNotFound,
-			expectedBody: ErrorResponse{
-				Error: AppError{
-					Code:    http.StatusNotFound,
-					Message: "Not Found",
-					Details: "record not found",
-				},
+			expectedBody: apperr.Problem{
+				Type:     "about:blank",
+				Title:    "Not Found",
+				Status:   http.StatusNotFound,
+				Detail:   "record not found",
+				Instance: "/test",
+				Code:     "NOT_FOUND",
 			},
 		},
 		{
-			name: "Validation Error",
-			error: &ValidationError{
-				Field:   "test",
-				Message: "validation failed",
+			name: "Request Error",
+			error: &RequestError{
+				Err:        ErrInvalidRequest,
+				Violations: []apperr.Violation{{Field: "name", Reason: "REQUIRED", Description: "name is required"}},
 			},
-			expectedStatus: http.StatusBadRequest,
-			expectedBody: ErrorResponse{
-				Error: AppError{
-					Code:    http.StatusBadRequest,
-					Message: "Validation failed",
-					Details: "test: validation failed",
-				},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody: apperr.Problem{
+				Type:     "about:blank",
+				Title:    "Unprocessable Entity",
+				Status:   http.StatusUnprocessableEntity,
+				Detail:   "invalid request: name is required",
+				Instance: "/test",
+				Code:     "INVALID_REQUEST",
+				Errors:   []apperr.Violation{{Field: "name", Reason: "REQUIRED", Description: "name is required"}},
+			},
+		},
+		{
+			name:           "Domain Error",
+			error:          fmt.Errorf("%w: 42", apperr.New(apperr.Conflict, "RECORD_LOCKED", "record locked")),
+			expectedStatus: http.StatusConflict,
+			expectedBody: apperr.Problem{
+				Type:     "about:blank",
+				Title:    "Conflict",
+				Status:   http.StatusConflict,
+				Detail:   "record locked: 42",
+				Instance: "/test",
+				Code:     "RECORD_LOCKED",
 			},
 		},
 		{
 			name:           "Unknown Error",
 			error:          errors.New("unknown error"),
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody: ErrorResponse{
-				Error: AppError{
-					Code:    http.StatusInternalServerError,
-					Message: "Internal server error",
-					Details: "An unexpected error occurred",
-				},
+			expectedBody: apperr.Problem{
+				Type:     "about:blank",
+				Title:    "Internal Server Error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "internal error",
+				Instance: "/test",
+				Code:     "INTERNAL_SERVER_ERROR",
 			},
 		},
 	}
@@ -63,7 +85,7 @@ func TestErrorHandler(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			e := echo.New()
-			e.Use(ErrorHandler())
+			e.HTTPErrorHandler = ErrorHandler(zap.NewNop())
 
 			e.GET("/test", func(c echo.Context) error {
 				return tt.error
@@ -74,8 +96,9 @@ func TestErrorHandler(t *testing.T) {
 			e.ServeHTTP(rec, req)
 
 			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, apperr.MIMEProblem, rec.Header().Get(echo.HeaderContentType))
 
-			var response ErrorResponse
+			var response apperr.Problem
 			err := json.Unmarshal(rec.Body.Bytes(), &response)
 			assert.NoError(t, err)
 			assert.Equal(t, tt.expectedBody, response)
@@ -86,6 +109,7 @@ func TestErrorHandler(t *testing.T) {
 type TestStruct struct {
 	Name  string `json:"name" validate:"required,min=3"`
 	Email string `json:"email" validate:"required,email"`
+	Age   int    `json:"age" validate:"gte=0"`
 }
 
 func TestValidationMiddleware(t *testing.T) {
@@ -109,7 +133,7 @@ func TestValidationMiddleware(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			e := echo.New()
-			e.Use(ValidationMiddleware())
+			ValidationMiddleware(e)
 
 			e.POST("/test", func(c echo.Context) error {
 				return c.NoContent(http.StatusOK)
@@ -144,22 +168,23 @@ func TestRequestValidator(t *testing.T) {
 			payload: TestStruct{
 				Name: "Super star",
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusUnprocessableEntity,
 		},
 		{
 			name: "Invalid Request - Short Name",
 			payload: TestStruct{
-				Name:  "Star",
+				Name:  "St",
 				Email: "superstar@example.com",
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusUnprocessableEntity,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			e := echo.New()
-			e.Use(ErrorHandler())
+			e.Validator = &CustomValidator{Validator: GetValidator()}
+			e.HTTPErrorHandler = ErrorHandler(zap.NewNop())
 
 			e.POST("/test", func(c echo.Context) error {
 				var validated TestStruct
@@ -182,3 +207,48 @@ func TestRequestValidator(t *testing.T) {
 		})
 	}
 }
+
+func TestInvalidRequest(t *testing.T) {
+	violations := func(err error) []apperr.Violation {
+		t.Helper()
+		var requestErr *RequestError
+		require.ErrorAs(t, err, &requestErr)
+		return requestErr.Violations
+	}
+	bind := func(body string) error {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := echo.New().NewContext(req, httptest.NewRecorder())
+		return InvalidRequest(c.Bind(&TestStruct{}))
+	}
+
+	// Validation failures name the fields as they are sent
+	validator := &CustomValidator{Validator: GetValidator()}
+	err := validator.Validate(&TestStruct{Name: "St", Email: "superstar@example.com", Age: -1})
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+	assert.Equal(t, []apperr.Violation{
+		{Field: "name", Reason: "MIN", Description: "name must be at least 3 characters"},
+		{Field: "age", Reason: "GTE", Description: "age must be at least 0"},
+	}, violations(err))
+	assert.Equal(t, http.StatusUnprocessableEntity, apperr.ToProblem(err).Status)
+
+	// Undecodable requests are a 400 that does not leak the decoder's text
+	err = bind(`{"age": "old"}`)
+	assert.ErrorIs(t, err, ErrMalformedRequest)
+	assert.Equal(t, []apperr.Violation{{Field: "age", Description: "age must be of type int, not string"}}, violations(err))
+	assert.Equal(t, http.StatusBadRequest, apperr.ToProblem(err).Status)
+
+	err = bind(`{"name": `)
+	assert.ErrorIs(t, err, ErrMalformedRequest)
+
+	dec := json.NewDecoder(strings.NewReader(`{"id": 1}`))
+	dec.DisallowUnknownFields()
+	err = InvalidRequest(dec.Decode(&TestStruct{}))
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+	assert.Equal(t, []apperr.Violation{{Field: "id", Reason: "UNKNOWN_FIELD", Description: "id is not a field of the request"}}, violations(err))
+
+	// Errors of the application pass through
+	notFound := apperr.New(apperr.NotFound, "THING_NOT_FOUND", "thing not found")
+	assert.Equal(t, notFound, InvalidRequest(notFound))
+}
@@ -43,15 +43,8 @@ func RBAC(requiredService, requiredPermission string) echo.MiddlewareFunc {
 				return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
 			}
 
-			hasAccess := false
-			for _, perm := range claims.Permissions {
-				if perm == fmt.Sprintf("%s:%s", requiredService, requiredPermission) {
-					hasAccess = true
-					break
-				}
-			}
-
-			if !hasAccess {
+			// The gRPC RBAC interceptors check the same claims
+			if !claims.HasPermission(fmt.Sprintf("%s:%s", requiredService, requiredPermission)) {
 				return echo.NewHTTPError(http.StatusForbidden, "Insufficient permissions")
 			}
 
@@ -52,7 +52,7 @@ func CORS(allowedOrigins []string) echo.MiddlewareFunc {
 			header.Set("Access-Control-Allow-Origin", strings.Join(allowedOrigins, ","))
 			header.Set("Access-Control-Allow-Credentials", "true")
 			header.Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-			header.Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			header.Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 
 			if c.Request().Method == http.MethodOptions {
 				return c.NoContent(http.StatusNoContent)
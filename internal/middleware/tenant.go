@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go-boilerplate/internal/tenant"
+)
+
+// Tenant resolves the tenant of each request and puts it in the request
+// context; see tenant.Resolve. It runs after Authenticate. header names the
+// trusted tenant header, or is empty if there is none. Requests whose header
+// contradicts their token are rejected with 403, malformed tenant IDs with
+// 400.
+func Tenant(header string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			var value string
+			if header != "" {
+				value = req.Header.Get(header)
+			}
+			id, err := tenant.Resolve(req.Context(), value)
+			if errors.Is(err, tenant.ErrMismatch) {
+				return echo.NewHTTPError(http.StatusForbidden, err.Error())
+			}
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+
+			c.SetRequest(req.WithContext(tenant.NewContext(req.Context(), id)))
+			return next(c)
+		}
+	}
+}
@@ -23,18 +23,36 @@
 package middleware
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"reflect"
 	"strings"
+	"unicode"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
+	"go-boilerplate/internal/apperr"
 )
 
 var validate *validator.Validate
 
 func init() {
 	validate = validator.New()
+	// Violations name fields as clients send them
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "query", "param", "form"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return ""
+	})
 }
 
 // GetValidator returns the validator instance
@@ -42,14 +60,149 @@ func GetValidator() *validator.Validate {
 	return validate
 }
 
-// ValidationError represents a validation error
-type ValidationError struct {
-	Field   string `json:"field"`
-	Message string `json:"message"`
+// ErrInvalidRequest is wrapped by the *RequestError of a request whose
+// fields break their validation rules.
+var ErrInvalidRequest = apperr.New(apperr.InvalidArgument, "INVALID_REQUEST", "invalid request")
+
+// ErrMalformedRequest is wrapped by the *RequestError of a request that
+// cannot be decoded, such as a body that is not JSON or a parameter of the
+// wrong type.
+var ErrMalformedRequest = apperr.New(apperr.InvalidArgument, "MALFORMED_REQUEST", "malformed request").WithHTTPStatus(http.StatusBadRequest)
+
+// RequestError is returned for a request that fails to bind or validate.
+type RequestError struct {
+	// Err is ErrInvalidRequest or ErrMalformedRequest.
+	Err *apperr.Error
+	// Violations lists the problems found, one per field.
+	Violations []apperr.Violation
+}
+
+func (e *RequestError) Error() string {
+	descriptions := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		descriptions[i] = violation.Description
+	}
+	return fmt.Sprintf("%v: %s", e.Err, strings.Join(descriptions, "; "))
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// FieldViolations reports the violations of the request.
+func (e *RequestError) FieldViolations() []apperr.Violation {
+	return e.Violations
+}
+
+// InvalidRequest returns the error reporting a failure of echo.Context.Bind,
+// echo.Context.Validate or of decoding JSON to the client: a *RequestError
+// listing the fields at fault. Errors of the application are returned as they
+// are. Handlers return it rather than the error itself, whose text is meant
+// for developers.
+func InvalidRequest(err error) error {
+	if _, ok := apperr.From(err); ok {
+		return err
+	}
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		violations := make([]apperr.Violation, len(validationErrors))
+		for i, fieldErr := range validationErrors {
+			field := fieldErr.Namespace()
+			// The namespace starts with the name of the struct
+			if _, path, ok := strings.Cut(field, "."); ok {
+				field = path
+			}
+			violations[i] = apperr.Violation{
+				Field:       field,
+				Reason:      strings.ToUpper(fieldErr.Tag()),
+				Description: describe(field, fieldErr),
+			}
+		}
+		return &RequestError{Err: ErrInvalidRequest, Violations: violations}
+	}
+
+	// encoding/json has no type for the error of DisallowUnknownFields
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		field = strings.Trim(field, `"`)
+		return &RequestError{Err: ErrInvalidRequest, Violations: []apperr.Violation{{
+			Field:       field,
+			Reason:      "UNKNOWN_FIELD",
+			Description: fmt.Sprintf("%s is not a field of the request", field),
+		}}}
+	}
+
+	violation := apperr.Violation{Description: "the request cannot be decoded"}
+	var bindingErr *echo.BindingError
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	var httpErr *echo.HTTPError
+	switch {
+	case errors.As(err, &bindingErr):
+		violation.Field = bindingErr.Field
+		violation.Description = fmt.Sprintf("%s has an invalid value", bindingErr.Field)
+	case errors.As(err, &typeErr):
+		violation.Field = typeErr.Field
+		violation.Description = fmt.Sprintf("%s must be of type %s, not %s", typeErr.Field, typeErr.Type, typeErr.Value)
+	case errors.As(err, &syntaxErr):
+		violation.Description = fmt.Sprintf("the body is not valid JSON at offset %d", syntaxErr.Offset)
+	case errors.As(err, &httpErr) && httpErr.Code == http.StatusUnsupportedMediaType:
+		return err
+	}
+	return &RequestError{Err: ErrMalformedRequest, Violations: []apperr.Violation{violation}}
+}
+
+// describe says what is wrong with the value of field
+func describe(field string, err validator.FieldError) string {
+	switch err.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "min", "gte":
+		return fmt.Sprintf("%s must be at least %s", field, quantity(err))
+	case "max", "lte":
+		return fmt.Sprintf("%s must be at most %s", field, quantity(err))
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", field, err.Param())
+	case "lt":
+		return fmt.Sprintf("%s must be less than %s", field, err.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of %s", field, strings.Join(strings.Fields(err.Param()), ", "))
+	case "uuid":
+		return fmt.Sprintf("%s must be a UUID", field)
+	case "excluded_with":
+		return fmt.Sprintf("%s cannot be set together with %s", field, snakeCase(err.Param()))
+	default:
+		return fmt.Sprintf("%s is invalid", field)
+	}
+}
+
+// quantity is the bound of a min or max rule, counted in characters or
+// items for strings and collections
+func quantity(err validator.FieldError) string {
+	switch err.Kind() {
+	case reflect.String:
+		return err.Param() + " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return err.Param() + " items"
+	default:
+		return err.Param()
+	}
 }
 
-func (v *ValidationError) Error() string {
-	return fmt.Sprintf("%s: %s", v.Field, v.Message)
+// snakeCase returns the JSON name of a Go field named by a rule, e.g.
+// expires_at for ExpiresAt
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
 }
 
 // CustomValidator is a custom validator for Echo
@@ -57,21 +210,11 @@ type CustomValidator struct {
 	Validator *validator.Validate
 }
 
-// Validate implements echo.Validator interface
+// Validate implements echo.Validator interface. Validation failures are
+// returned as a *RequestError, as InvalidRequest reports them.
 func (cv *CustomValidator) Validate(i interface{}) error {
 	if err := cv.Validator.Struct(i); err != nil {
-		if validationErrors, ok := err.(validator.ValidationErrors); ok {
-			var errorMessages []string
-			for _, e := range validationErrors {
-				message := fmt.Sprintf("%s: failed validation for '%s'", e.Field(), e.Tag())
-				errorMessages = append(errorMessages, message)
-			}
-			return &ValidationError{
-				Field:   "multiple",
-				Message: strings.Join(errorMessages, "; "),
-			}
-		}
-		return err
+		return InvalidRequest(err)
 	}
 	return nil
 }
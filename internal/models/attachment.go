@@ -0,0 +1,22 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Attachment describes a file attached to a message. The file itself is kept
+// in the blob store under StorageKey; ContentType is sniffed from its
+// contents and Checksum is their hex-encoded SHA-256.
+type Attachment struct {
+	ID          uuid.UUID `json:"id"`
+	MessageID   uuid.UUID `json:"message_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum"`
+	UploaderID  string    `json:"uploader_id,omitempty"`
+	StorageKey  string    `json:"-"`
+	TenantID    string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -5,12 +5,31 @@ import (
 	"time"
 )
 
+// Statuses of a message. Drafts and scheduled messages are only shown to
+// their author; a scheduled message is published once its PublishAt is due.
+const (
+	StatusDraft     = "draft"
+	StatusScheduled = "scheduled"
+	StatusPublished = "published"
+)
+
 type Message struct {
-	ID        uuid.UUID  `json:"id" db:"id"`
-	Content   string     `json:"content" db:"content"`
-	CreatedAt time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
-	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	ID         uuid.UUID              `json:"id" db:"id"`
+	Content    string                 `json:"content" db:"content"`
+	AuthorID   string                 `json:"author_id,omitempty" db:"author_id"`
+	TenantID   string                 `json:"-" db:"tenant_id"`
+	Labels     []string               `json:"labels,omitempty" db:"labels"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty" db:"metadata"`
+	ParentID   *uuid.UUID             `json:"parent_id,omitempty" db:"parent_id"`
+	ThreadID   uuid.UUID              `json:"thread_id" db:"thread_id"`
+	ReplyCount int64                  `json:"reply_count" db:"reply_count"`
+	Version    int64                  `json:"version" db:"version"`
+	CreatedAt  time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time              `json:"updated_at" db:"updated_at"`
+	DeletedAt  *time.Time             `json:"deleted_at,omitempty" db:"deleted_at"`
+	ExpiresAt  *time.Time             `json:"expires_at,omitempty" db:"expires_at"`
+	Status     string                 `json:"status" db:"status"`
+	PublishAt  *time.Time             `json:"publish_at,omitempty" db:"publish_at"`
 }
 
 
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// OutboxEvent is an event recorded in the transactional outbox, waiting to be
+// relayed to Kafka
+type OutboxEvent struct {
+	ID           int64      `json:"id" db:"id"`
+	AggregateID  uuid.UUID  `json:"aggregate_id" db:"aggregate_id"`
+	EventType    string     `json:"event_type" db:"event_type"`
+	Payload      []byte     `json:"payload" db:"payload"`
+	Attempts     int32      `json:"attempts" db:"attempts"`
+	LastError    string     `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	DispatchedAt *time.Time `json:"dispatched_at,omitempty" db:"dispatched_at"`
+}
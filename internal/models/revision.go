@@ -0,0 +1,18 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// MessageRevision is an immutable snapshot of a message's content, recorded
+// on every create and update. Revision equals the message version it
+// produced.
+type MessageRevision struct {
+	MessageID uuid.UUID `json:"message_id"`
+	Revision  int64     `json:"revision"`
+	Content   string    `json:"content"`
+	Editor    string    `json:"editor,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	TenantID  string    `json:"-"`
+}
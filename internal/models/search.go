@@ -0,0 +1,10 @@
+package models
+
+// SearchResult is a message matching a full-text search. Snippet is an
+// excerpt of the content with the matches wrapped in <mark> tags; it is not
+// HTML escaped.
+type SearchResult struct {
+	Message Message `json:"message"`
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
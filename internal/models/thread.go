@@ -0,0 +1,20 @@
+package models
+
+// Thread is a conversation: its root message and the replies below it, as
+// a tree. Truncated is set when the depth or size limit cut replies off;
+// the reply counts of the nodes still tell how many there are.
+type Thread struct {
+	Root      *ThreadNode `json:"root"`
+	Truncated bool        `json:"truncated"`
+}
+
+// ThreadNode is a message of a thread with its live replies, oldest first.
+// A deleted or expired message, or one not yet published that the reader
+// did not write, stays in the tree as a tombstone while live replies remain
+// below it, with its content, labels and metadata blanked. A root that was
+// purged is a tombstone holding only its ID.
+type ThreadNode struct {
+	Message
+	Tombstone bool          `json:"tombstone,omitempty"`
+	Replies   []*ThreadNode `json:"replies,omitempty"`
+}
@@ -0,0 +1,306 @@
+package moderation
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalizer brings content to Unicode normalization form C and looks for
+// control and invisible formatting characters, such as zero-width spaces
+// and bidirectional overrides, which are used to slip past the other
+// filters. Line breaks, tabs and the zero-width joiner of emoji sequences
+// are allowed. Masking removes the characters.
+type Normalizer struct {
+	action Action
+}
+
+func NewNormalizer(action Action) *Normalizer {
+	return &Normalizer{action: action}
+}
+
+func (n *Normalizer) Name() string {
+	return "normalize"
+}
+
+func (n *Normalizer) Check(ctx context.Context, content *Content) ([]Violation, error) {
+	content.Text = norm.NFC.String(content.Text)
+
+	hidden := 0
+	cleaned := strings.Map(func(r rune) rune {
+		if isHidden(r) {
+			hidden++
+			return -1
+		}
+		return r
+	}, content.Text)
+	if hidden == 0 {
+		return nil, nil
+	}
+
+	if n.action == ActionMask {
+		content.Text = cleaned
+	}
+	return []Violation{{
+		Filter: n.Name(),
+		Action: n.action,
+		Reason: fmt.Sprintf("content contains %d control or invisible characters", hidden),
+	}}, nil
+}
+
+// isHidden reports whether r is a control or formatting character that is
+// not allowed in content.
+func isHidden(r rune) bool {
+	switch r {
+	case '\n', '\r', '\t', '\u200d':
+		return false
+	}
+	return unicode.Is(unicode.Cc, r) || unicode.Is(unicode.Cf, r)
+}
+
+// WordFilter looks for blocked words and phrases, ignoring case. Only whole
+// words match: blocking "ass" leaves "class" alone. Masking replaces each
+// letter of a match with an asterisk.
+type WordFilter struct {
+	words  *regexp.Regexp
+	action Action
+}
+
+func NewWordFilter(words []string, action Action) *WordFilter {
+	var quoted []string
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	if len(quoted) == 0 {
+		return &WordFilter{action: action}
+	}
+	// Longer words first, so that the longest of overlapping words matches
+	sort.Slice(quoted, func(i, j int) bool { return len(quoted[i]) > len(quoted[j]) })
+
+	return &WordFilter{
+		words:  regexp.MustCompile(`(?i)` + strings.Join(quoted, "|")),
+		action: action,
+	}
+}
+
+func (f *WordFilter) Name() string {
+	return "blocked_words"
+}
+
+func (f *WordFilter) Check(ctx context.Context, content *Content) ([]Violation, error) {
+	if f.words == nil {
+		return nil, nil
+	}
+
+	var matches [][]int
+	for _, match := range f.words.FindAllStringIndex(content.Text, -1) {
+		if wholeWord(content.Text, match[0], match[1]) {
+			matches = append(matches, match)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	if f.action == ActionMask {
+		content.Text = replace(content.Text, matches, stars)
+	}
+	return []Violation{{
+		Filter: f.Name(),
+		Action: f.action,
+		Reason: fmt.Sprintf("content contains %d blocked words", len(matches)),
+	}}, nil
+}
+
+// wholeWord reports whether text[start:end] is not part of a longer word.
+func wholeWord(text string, start, end int) bool {
+	before, _ := utf8.DecodeLastRuneInString(text[:start])
+	after, _ := utf8.DecodeRuneInString(text[end:])
+	return !isWordRune(before) && !isWordRune(after)
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+// PatternFilter looks for matches of regular expressions. Masking replaces
+// each character of a match with an asterisk.
+type PatternFilter struct {
+	patterns []*regexp.Regexp
+	action   Action
+}
+
+func NewPatternFilter(patterns []*regexp.Regexp, action Action) *PatternFilter {
+	return &PatternFilter{patterns: patterns, action: action}
+}
+
+// LoadPatterns reads the regular expressions in the file at path, one per
+// line, in RE2 syntax. Blank lines and lines starting with # are skipped.
+func LoadPatterns(path string) ([]*regexp.Regexp, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open moderation patterns: %w", err)
+	}
+	defer file.Close()
+
+	var patterns []*regexp.Regexp
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		pattern, err := regexp.Compile(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read moderation patterns: %w", err)
+	}
+
+	return patterns, nil
+}
+
+func (f *PatternFilter) Name() string {
+	return "patterns"
+}
+
+func (f *PatternFilter) Check(ctx context.Context, content *Content) ([]Violation, error) {
+	found := 0
+	for _, pattern := range f.patterns {
+		matches := pattern.FindAllStringIndex(content.Text, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		found += len(matches)
+		if f.action == ActionMask {
+			content.Text = replace(content.Text, matches, stars)
+		}
+	}
+	if found == 0 {
+		return nil, nil
+	}
+
+	return []Violation{{
+		Filter: f.Name(),
+		Action: f.action,
+		Reason: fmt.Sprintf("content matches blocked patterns %d times", found),
+	}}, nil
+}
+
+// linkPattern matches the links counted by LinkFilter.
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"']+`)
+
+// LinkFilter bounds the number of links, http(s) URLs or bare www.
+// addresses, in content. Masking removes the links past the limit.
+type LinkFilter struct {
+	max    int
+	action Action
+}
+
+func NewLinkFilter(max int, action Action) *LinkFilter {
+	return &LinkFilter{max: max, action: action}
+}
+
+func (f *LinkFilter) Name() string {
+	return "links"
+}
+
+func (f *LinkFilter) Check(ctx context.Context, content *Content) ([]Violation, error) {
+	links := linkPattern.FindAllStringIndex(content.Text, -1)
+	if len(links) <= f.max {
+		return nil, nil
+	}
+
+	if f.action == ActionMask {
+		content.Text = replace(content.Text, links[f.max:], func(string) string { return "[link removed]" })
+	}
+	return []Violation{{
+		Filter: f.Name(),
+		Action: f.action,
+		Reason: fmt.Sprintf("content has %d links, at most %d are allowed", len(links), f.max),
+	}}, nil
+}
+
+// Cache remembers recent content for DuplicateFilter. The message cache
+// satisfies it, and keeps the keys of each tenant apart.
+type Cache interface {
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	Get(ctx context.Context, key string, dest interface{}) error
+}
+
+// DuplicateFilter catches an author posting the same content again within
+// a time window, ignoring case and whitespace. Duplicates cannot be masked:
+// ActionMask flags them instead. A cache that fails lets content through.
+type DuplicateFilter struct {
+	cache  Cache
+	window time.Duration
+	action Action
+}
+
+func NewDuplicateFilter(cache Cache, window time.Duration, action Action) *DuplicateFilter {
+	if action == ActionMask {
+		action = ActionFlag
+	}
+	return &DuplicateFilter{cache: cache, window: window, action: action}
+}
+
+func (f *DuplicateFilter) Name() string {
+	return "duplicate"
+}
+
+func (f *DuplicateFilter) Check(ctx context.Context, content *Content) ([]Violation, error) {
+	folded := strings.ToLower(strings.Join(strings.Fields(content.Text), " "))
+	sum := sha256.Sum256([]byte(content.AuthorID + "\x00" + folded))
+	key := "moderation:duplicate:" + hex.EncodeToString(sum[:])
+
+	var seen bool
+	duplicate := f.cache.Get(ctx, key, &seen) == nil && seen
+	if err := f.cache.Set(ctx, key, true, f.window); err != nil {
+		// Log error but don't fail the request
+		// TODO: Add proper logging
+	}
+	if !duplicate {
+		return nil, nil
+	}
+
+	return []Violation{{
+		Filter: f.Name(),
+		Action: f.action,
+		Reason: fmt.Sprintf("the same content was already posted within %s", f.window),
+	}}, nil
+}
+
+// replace returns text with the non-overlapping matches, given as ordered
+// index pairs, replaced by what with returns for them.
+func replace(text string, matches [][]int, with func(match string) string) string {
+	var replaced strings.Builder
+	last := 0
+	for _, match := range matches {
+		replaced.WriteString(text[last:match[0]])
+		replaced.WriteString(with(text[match[0]:match[1]]))
+		last = match[1]
+	}
+	replaced.WriteString(text[last:])
+	return replaced.String()
+}
+
+// stars masks every character of match with an asterisk.
+func stars(match string) string {
+	return strings.Repeat("*", utf8.RuneCountInString(match))
+}
@@ -0,0 +1,190 @@
+// Package moderation reviews the content of messages before they are
+// written.
+//
+// A Chain runs the content through a series of ContentFilter
+// implementations, in order. Each filter is configured with the Action it
+// takes on the content it objects to: reject the write, mask the offending
+// parts, or flag the message for review and let it through. Masking
+// filters rewrite the content seen by the filters after them, so a Chain
+// usually starts with the Normalizer. Rejections are reported together as a
+// *RejectionError, which lists every rejecting violation so that clients
+// can tell their users what to fix.
+//
+// Metrics:
+//   - moderation_violations_total{filter,action}: violations found
+//   - moderation_reviews_total{outcome}: reviewed contents by outcome,
+//     one of accepted, masked, flagged or rejected
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go-boilerplate/internal/apperr"
+)
+
+// Action is what a filter does with content it objects to.
+type Action string
+
+const (
+	// ActionReject refuses the write.
+	ActionReject Action = "reject"
+	// ActionMask replaces the offending parts of the content and lets the
+	// write through.
+	ActionMask Action = "mask"
+	// ActionFlag lets the write through unchanged and marks the message for
+	// review.
+	ActionFlag Action = "flag"
+)
+
+// ErrRejected is wrapped by every *RejectionError.
+var ErrRejected = apperr.New(apperr.InvalidArgument, "CONTENT_REJECTED", "content rejected")
+
+// Violation is an objection of one filter to some content.
+type Violation struct {
+	// Filter is the name of the filter that found the violation.
+	Filter string `json:"filter"`
+	// Action is what the filter did about it.
+	Action Action `json:"action"`
+	// Reason describes the violation for the author of the content.
+	Reason string `json:"reason"`
+}
+
+// RejectionError is returned for content that at least one filter rejects.
+type RejectionError struct {
+	// Violations lists the rejecting violations, in filter order.
+	Violations []Violation
+}
+
+func (e *RejectionError) Error() string {
+	reasons := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		reasons[i] = violation.Reason
+	}
+	return fmt.Sprintf("%v: %s", ErrRejected, strings.Join(reasons, "; "))
+}
+
+func (e *RejectionError) Unwrap() error {
+	return ErrRejected
+}
+
+// FieldViolations reports the violations against the content field.
+func (e *RejectionError) FieldViolations() []apperr.Violation {
+	violations := make([]apperr.Violation, len(e.Violations))
+	for i, violation := range e.Violations {
+		violations[i] = apperr.Violation{Field: "content", Reason: violation.Filter, Description: violation.Reason}
+	}
+	return violations
+}
+
+// Content is the text of a message under review, with its author.
+type Content struct {
+	// Text is the content of the message. Masking filters rewrite it.
+	Text string
+	// AuthorID is the user writing the message, or "" for an anonymous
+	// caller.
+	AuthorID string
+}
+
+// ContentFilter inspects content on behalf of a Chain.
+type ContentFilter interface {
+	// Name identifies the filter in violations and metrics.
+	Name() string
+	// Check returns the violations found in content. A filter that masks
+	// rewrites content.Text accordingly. An error means the content could
+	// not be reviewed, not that it was found wanting.
+	Check(ctx context.Context, content *Content) ([]Violation, error)
+}
+
+// Metrics holds the Prometheus collectors reported by a Chain.
+type Metrics struct {
+	violations *prometheus.CounterVec
+	reviews    *prometheus.CounterVec
+}
+
+// NewMetrics creates the moderation metrics and registers them with reg.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		violations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "moderation_violations_total",
+			Help: "Number of violations found by the content filters, by filter and action taken.",
+		}, []string{"filter", "action"}),
+		reviews: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "moderation_reviews_total",
+			Help: "Number of message contents reviewed, by outcome.",
+		}, []string{"outcome"}),
+	}
+
+	reg.MustRegister(m.violations, m.reviews)
+	return m
+}
+
+// Chain runs content through its filters in order. A nil *Chain accepts
+// any content as it is.
+type Chain struct {
+	filters []ContentFilter
+	metrics *Metrics
+}
+
+// NewChain returns a chain of filters, reporting to metrics.
+func NewChain(metrics *Metrics, filters ...ContentFilter) *Chain {
+	return &Chain{filters: filters, metrics: metrics}
+}
+
+// Review runs content through every filter and returns the violations that
+// were masked or flagged. If any filter rejects the content, the error is a
+// *RejectionError listing the rejections. content.Text holds the text as
+// masked by the filters.
+func (c *Chain) Review(ctx context.Context, content *Content) ([]Violation, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	var accepted, rejected []Violation
+	for _, filter := range c.filters {
+		violations, err := filter.Check(ctx, content)
+		if err != nil {
+			return nil, fmt.Errorf("content filter %s: %w", filter.Name(), err)
+		}
+		for _, violation := range violations {
+			c.metrics.violations.WithLabelValues(violation.Filter, string(violation.Action)).Inc()
+			if violation.Action == ActionReject {
+				rejected = append(rejected, violation)
+			} else {
+				accepted = append(accepted, violation)
+			}
+		}
+	}
+
+	c.metrics.reviews.WithLabelValues(outcome(accepted, rejected)).Inc()
+	if len(rejected) > 0 {
+		return nil, &RejectionError{Violations: rejected}
+	}
+	return accepted, nil
+}
+
+// Flagged reports whether any of violations flags the content for review.
+func Flagged(violations []Violation) bool {
+	for _, violation := range violations {
+		if violation.Action == ActionFlag {
+			return true
+		}
+	}
+	return false
+}
+
+// outcome names the result of a review for the reviews metric.
+func outcome(accepted, rejected []Violation) string {
+	switch {
+	case len(rejected) > 0:
+		return "rejected"
+	case Flagged(accepted):
+		return "flagged"
+	case len(accepted) > 0:
+		return "masked"
+	default:
+		return "accepted"
+	}
+}
@@ -0,0 +1,147 @@
+package moderation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-boilerplate/internal/cache"
+)
+
+func check(t *testing.T, filter ContentFilter, text string) (string, []Violation) {
+	t.Helper()
+	content := &Content{Text: text, AuthorID: "alice"}
+	violations, err := filter.Check(context.Background(), content)
+	require.NoError(t, err)
+	return content.Text, violations
+}
+
+func TestNormalizer(t *testing.T) {
+	// Decomposed characters are composed
+	text, violations := check(t, NewNormalizer(ActionReject), "cafe\u0301\n\tfamily: \U0001F468\u200d\U0001F469\u200d\U0001F467")
+	assert.Equal(t, "caf\u00e9\n\tfamily: \U0001F468\u200d\U0001F469\u200d\U0001F467", text)
+	assert.Empty(t, violations)
+
+	text, violations = check(t, NewNormalizer(ActionMask), "fr\u200bee mo\u202eney\x07")
+	assert.Equal(t, "free money", text)
+	require.Len(t, violations, 1)
+	assert.Equal(t, Violation{Filter: "normalize", Action: ActionMask, Reason: "content contains 3 control or invisible characters"}, violations[0])
+
+	text, violations = check(t, NewNormalizer(ActionFlag), "fr\u200bee")
+	assert.Equal(t, "fr\u200bee", text)
+	require.Len(t, violations, 1)
+	assert.Equal(t, ActionFlag, violations[0].Action)
+}
+
+func TestWordFilter(t *testing.T) {
+	filter := NewWordFilter([]string{"darn", "heck it", " "}, ActionMask)
+
+	text, violations := check(t, filter, "Darn, HECK IT! darned, undarn, _darn")
+	assert.Equal(t, "****, *******! darned, undarn, _darn", text)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "content contains 2 blocked words", violations[0].Reason)
+
+	_, violations = check(t, filter, "nothing to see")
+	assert.Empty(t, violations)
+
+	_, violations = check(t, NewWordFilter(nil, ActionReject), "anything")
+	assert.Empty(t, violations)
+}
+
+func TestPatternFilter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patterns.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# card numbers\n\\b\\d{4}(?:[ -]?\\d{4}){3}\\b\n\n(?i)buy now\n"), 0o600))
+	patterns, err := LoadPatterns(path)
+	require.NoError(t, err)
+	require.Len(t, patterns, 2)
+
+	text, violations := check(t, NewPatternFilter(patterns, ActionMask), "Buy now with 1234 5678 9012 3456")
+	assert.Equal(t, "******* with *******************", text)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "content matches blocked patterns 2 times", violations[0].Reason)
+
+	require.NoError(t, os.WriteFile(path, []byte("ok\n(unclosed\n"), 0o600))
+	_, err = LoadPatterns(path)
+	assert.ErrorContains(t, err, "patterns.txt:2")
+}
+
+func TestLinkFilter(t *testing.T) {
+	text := "see https://a.example/x, www.b.example and http://c.example"
+
+	_, violations := check(t, NewLinkFilter(3, ActionReject), text)
+	assert.Empty(t, violations)
+
+	masked, violations := check(t, NewLinkFilter(1, ActionMask), text)
+	assert.Equal(t, "see https://a.example/x, [link removed] and [link removed]", masked)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "content has 3 links, at most 1 are allowed", violations[0].Reason)
+}
+
+func TestDuplicateFilter(t *testing.T) {
+	filter := NewDuplicateFilter(cache.NewMemoryCache(), time.Minute, ActionMask)
+	ctx := context.Background()
+
+	violations, err := filter.Check(ctx, &Content{Text: "Buy my stuff", AuthorID: "alice"})
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+
+	violations, err = filter.Check(ctx, &Content{Text: "  buy MY   stuff ", AuthorID: "alice"})
+	require.NoError(t, err)
+	require.Len(t, violations, 1)
+	assert.Equal(t, ActionFlag, violations[0].Action, "duplicates cannot be masked")
+
+	// Other authors may say the same
+	violations, err = filter.Check(ctx, &Content{Text: "Buy my stuff", AuthorID: "bob"})
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+}
+
+func TestChain(t *testing.T) {
+	metrics := NewMetrics(prometheus.NewRegistry())
+	chain := NewChain(metrics,
+		NewNormalizer(ActionMask),
+		NewWordFilter([]string{"darn"}, ActionMask),
+		NewPatternFilter([]*regexp.Regexp{regexp.MustCompile(`(?i)casino`)}, ActionFlag),
+		NewLinkFilter(0, ActionReject),
+	)
+	ctx := context.Background()
+
+	// Filters see the content as masked by those before them
+	content := &Content{Text: "d\u200barn casino"}
+	violations, err := chain.Review(ctx, content)
+	require.NoError(t, err)
+	assert.Equal(t, "**** casino", content.Text)
+	require.Len(t, violations, 3)
+	assert.True(t, Flagged(violations))
+
+	_, err = chain.Review(ctx, &Content{Text: "darn https://spam.example https://more.example"})
+	var rejection *RejectionError
+	require.ErrorAs(t, err, &rejection)
+	assert.ErrorIs(t, err, ErrRejected)
+	require.Len(t, rejection.Violations, 1)
+	assert.Equal(t, "links", rejection.Violations[0].Filter)
+	assert.Equal(t, "content rejected: content has 2 links, at most 0 are allowed", err.Error())
+
+	content = &Content{Text: "all good"}
+	violations, err = chain.Review(ctx, content)
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.violations.WithLabelValues("blocked_words", "mask")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.reviews.WithLabelValues("flagged")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.reviews.WithLabelValues("rejected")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.reviews.WithLabelValues("accepted")))
+
+	// A nil chain accepts anything
+	var none *Chain
+	violations, err = none.Review(ctx, &Content{Text: "darn"})
+	assert.NoError(t, err)
+	assert.Empty(t, violations)
+}
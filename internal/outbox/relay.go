@@ -0,0 +1,178 @@
+// Package outbox relays events from the transactional outbox to Kafka.
+//
+// Message changes and the events describing them are committed together by
+// the service layer. The Relay polls the outbox, publishes pending events in
+// insertion order, marks them dispatched and periodically purges dispatched
+// events once they are older than the retention period.
+//
+// Delivery is at-least-once: an event whose dispatch could not be recorded
+// (for example because the database went away right after publishing) will
+// be published again on the next pass. A failed publish stops the batch so
+// later events never overtake earlier ones; the relay retries it with
+// exponential backoff.
+//
+// Metrics:
+//   - outbox_pending_events: events waiting to be dispatched
+//   - outbox_oldest_pending_age_seconds: age of the oldest pending event
+//   - outbox_published_total: events published to Kafka
+//   - outbox_publish_failures_total: failed publish attempts
+//   - outbox_purged_total: dispatched events deleted by the purge
+package outbox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go-boilerplate/config"
+	"go-boilerplate/internal/models"
+	"go-boilerplate/internal/service"
+	"go.uber.org/zap"
+)
+
+// Metrics holds the Prometheus collectors reported by the relay.
+type Metrics struct {
+	pending          prometheus.Gauge
+	oldestPendingAge prometheus.Gauge
+	published        prometheus.Counter
+	failures         prometheus.Counter
+	purged           prometheus.Counter
+}
+
+// NewMetrics creates the relay metrics and registers them with reg.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		pending: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "outbox_pending_events",
+			Help: "Number of outbox events waiting to be dispatched.",
+		}),
+		oldestPendingAge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "outbox_oldest_pending_age_seconds",
+			Help: "Age in seconds of the oldest outbox event waiting to be dispatched.",
+		}),
+		published: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "outbox_published_total",
+			Help: "Number of outbox events published.",
+		}),
+		failures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "outbox_publish_failures_total",
+			Help: "Number of failed attempts to publish an outbox event.",
+		}),
+		purged: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "outbox_purged_total",
+			Help: "Number of dispatched outbox events purged.",
+		}),
+	}
+
+	reg.MustRegister(m.pending, m.oldestPendingAge, m.published, m.failures, m.purged)
+	return m
+}
+
+// Relay publishes outbox events until its context is cancelled.
+type Relay struct {
+	store     service.OutboxStore
+	publisher service.EventPublisher
+	cfg       config.OutboxConfig
+	metrics   *Metrics
+	logger    *zap.Logger
+}
+
+func NewRelay(store service.OutboxStore, publisher service.EventPublisher, cfg config.OutboxConfig, metrics *Metrics, logger *zap.Logger) *Relay {
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		cfg:       cfg,
+		metrics:   metrics,
+		logger:    logger,
+	}
+}
+
+// Run dispatches and purges the outbox until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	backoff := r.cfg.PollInterval
+	lastPurge := time.Time{}
+
+	for {
+		wait := r.cfg.PollInterval
+
+		dispatched, err := r.DispatchOnce(ctx)
+		switch {
+		case errors.Is(err, service.ErrOutboxLocked):
+			// Another replica is relaying; check again later
+		case err != nil:
+			r.logger.Error("Failed to dispatch outbox events", zap.Error(err), zap.Duration("retry_in", backoff))
+			wait = backoff
+			backoff = minDuration(backoff*2, r.cfg.MaxBackoff)
+		default:
+			backoff = r.cfg.PollInterval
+			if dispatched == int(r.cfg.BatchSize) {
+				// A full batch means there is likely more waiting
+				wait = 0
+			}
+		}
+
+		if time.Since(lastPurge) >= r.cfg.PurgeInterval {
+			if _, err := r.PurgeOnce(ctx); err != nil {
+				r.logger.Error("Failed to purge outbox events", zap.Error(err))
+			}
+			lastPurge = time.Now()
+		}
+
+		r.updateBacklog(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// DispatchOnce publishes one batch of pending events and reports how many
+// were dispatched.
+func (r *Relay) DispatchOnce(ctx context.Context) (int, error) {
+	dispatched, err := r.store.DispatchOutbox(ctx, r.cfg.BatchSize, func(event *models.OutboxEvent) error {
+		if err := r.publisher.PublishEvent(event); err != nil {
+			r.metrics.failures.Inc()
+			return err
+		}
+		r.metrics.published.Inc()
+		return nil
+	})
+
+	return dispatched, err
+}
+
+// PurgeOnce deletes events dispatched longer ago than the retention period.
+func (r *Relay) PurgeOnce(ctx context.Context) (int64, error) {
+	purged, err := r.store.PurgeOutbox(ctx, time.Now().Add(-r.cfg.Retention))
+	if err != nil {
+		return 0, err
+	}
+
+	r.metrics.purged.Add(float64(purged))
+	return purged, nil
+}
+
+func (r *Relay) updateBacklog(ctx context.Context) {
+	backlog, err := r.store.OutboxBacklog(ctx)
+	if err != nil {
+		r.logger.Warn("Failed to read outbox backlog", zap.Error(err))
+		return
+	}
+
+	r.metrics.pending.Set(float64(backlog.Pending))
+	if backlog.Pending == 0 {
+		r.metrics.oldestPendingAge.Set(0)
+	} else {
+		r.metrics.oldestPendingAge.Set(time.Since(backlog.OldestCreatedAt).Seconds())
+	}
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,136 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-boilerplate/config"
+	"go-boilerplate/internal/cache"
+	"go-boilerplate/internal/kafka"
+	"go-boilerplate/internal/models"
+	"go-boilerplate/internal/service"
+	"go.uber.org/zap"
+)
+
+// flakyPublisher fails the first failures publish attempts.
+type flakyPublisher struct {
+	*kafka.MemoryProducer
+	failures int
+}
+
+func (p *flakyPublisher) PublishEvent(event *models.OutboxEvent) error {
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("broker unavailable")
+	}
+	return p.MemoryProducer.PublishEvent(event)
+}
+
+func testConfig() config.OutboxConfig {
+	return config.OutboxConfig{
+		PollInterval:  10 * time.Millisecond,
+		BatchSize:     10,
+		MaxBackoff:    50 * time.Millisecond,
+		Retention:     time.Hour,
+		PurgeInterval: time.Hour,
+	}
+}
+
+func createMessages(t *testing.T, store *service.MemoryStore, contents ...string) []*models.Message {
+	t.Helper()
+	messageService := service.NewMessageService(store, cache.NewMemoryCache(), service.Options{})
+	messages := make([]*models.Message, len(contents))
+	for i, content := range contents {
+		messages[i] = &models.Message{Content: content}
+		require.NoError(t, messageService.CreateMessage(context.Background(), messages[i]))
+	}
+	return messages
+}
+
+func TestRelay_DispatchOnce_PublishesInOrder(t *testing.T) {
+	store := service.NewMemoryStore()
+	producer := kafka.NewMemoryProducer()
+	relay := NewRelay(store, producer, testConfig(), NewMetrics(prometheus.NewRegistry()), zap.NewNop())
+
+	messages := createMessages(t, store, "first", "second", "third")
+
+	dispatched, err := relay.DispatchOnce(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, dispatched)
+	events := producer.Events()
+	require.Len(t, events, 3)
+	for i, message := range messages {
+		assert.Equal(t, message.ID, events[i].AggregateID)
+	}
+
+	backlog, err := store.OutboxBacklog(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, backlog.Pending)
+}
+
+func TestRelay_DispatchOnce_StopsAtFailure(t *testing.T) {
+	store := service.NewMemoryStore()
+	publisher := &flakyPublisher{MemoryProducer: kafka.NewMemoryProducer(), failures: 1}
+	relay := NewRelay(store, publisher, testConfig(), NewMetrics(prometheus.NewRegistry()), zap.NewNop())
+
+	messages := createMessages(t, store, "first", "second")
+
+	// The first event fails, so nothing after it may be published
+	dispatched, err := relay.DispatchOnce(context.Background())
+	assert.Error(t, err)
+	assert.Zero(t, dispatched)
+	assert.Empty(t, publisher.Events())
+
+	// The retry publishes both events, still in order
+	dispatched, err = relay.DispatchOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, dispatched)
+	events := publisher.Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, messages[0].ID, events[0].AggregateID)
+	assert.Equal(t, messages[1].ID, events[1].AggregateID)
+}
+
+func TestRelay_PurgeOnce(t *testing.T) {
+	store := service.NewMemoryStore()
+	cfg := testConfig()
+	cfg.Retention = 0
+	relay := NewRelay(store, kafka.NewMemoryProducer(), cfg, NewMetrics(prometheus.NewRegistry()), zap.NewNop())
+
+	createMessages(t, store, "first", "second")
+	_, err := relay.DispatchOnce(context.Background())
+	require.NoError(t, err)
+
+	purged, err := relay.PurgeOnce(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+}
+
+func TestRelay_Run(t *testing.T) {
+	store := service.NewMemoryStore()
+	publisher := &flakyPublisher{MemoryProducer: kafka.NewMemoryProducer(), failures: 2}
+	relay := NewRelay(store, publisher, testConfig(), NewMetrics(prometheus.NewRegistry()), zap.NewNop())
+
+	createMessages(t, store, "first")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		relay.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return len(publisher.Events()) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	<-done
+}
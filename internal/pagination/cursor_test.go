@@ -0,0 +1,43 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodec_RoundTrip(t *testing.T) {
+	codec := NewCodec([]byte("secret"))
+	cursor := Cursor{
+		At:       time.Date(2024, 1, 2, 3, 4, 5, 123456000, time.UTC),
+		ID:       uuid.New(),
+		Order:    MaxOrder,
+		Backward: true,
+	}
+
+	decoded, err := codec.Decode(codec.Encode(cursor))
+	require.NoError(t, err)
+	assert.True(t, cursor.At.Equal(decoded.At))
+	assert.Equal(t, cursor.ID, decoded.ID)
+	assert.Equal(t, uint8(MaxOrder), decoded.Order)
+	assert.True(t, decoded.Backward)
+}
+
+func TestCodec_RejectsTamperedTokens(t *testing.T) {
+	codec := NewCodec([]byte("secret"))
+	token := codec.Encode(Cursor{At: time.Now(), ID: uuid.New()})
+
+	_, err := NewCodec([]byte("other secret")).Decode(token)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	tampered := []byte(token)
+	tampered[5] ^= 1
+	_, err = codec.Decode(string(tampered))
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	_, err = codec.Decode("not a cursor")
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
@@ -0,0 +1,109 @@
+// Package scheduler publishes scheduled messages when their time comes.
+//
+// A message created or updated with status scheduled stays hidden from
+// everyone but its author until its publish_at. The Scheduler periodically
+// publishes the messages that are due, in batches of
+// SCHEDULER_PUBLISH_BATCH_SIZE every SCHEDULER_PUBLISH_INTERVAL, and the
+// service emits a message.published event for each. Several replicas can run
+// a Scheduler at the same time: a message claimed by one is skipped by the
+// others and never picked again once published, so it is published, and its
+// event emitted, exactly once.
+//
+// Metrics:
+//   - scheduler_published_messages_total: scheduled messages published
+//   - scheduler_publish_failures_total: failed publish batches
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go-boilerplate/config"
+	"go-boilerplate/internal/service"
+	"go.uber.org/zap"
+)
+
+// Metrics holds the Prometheus collectors reported by the scheduler.
+type Metrics struct {
+	published prometheus.Counter
+	failures  prometheus.Counter
+}
+
+// NewMetrics creates the scheduler metrics and registers them with reg.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		published: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "scheduler_published_messages_total",
+			Help: "Number of scheduled messages published.",
+		}),
+		failures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "scheduler_publish_failures_total",
+			Help: "Number of failed attempts to publish a batch of scheduled messages.",
+		}),
+	}
+
+	reg.MustRegister(m.published, m.failures)
+	return m
+}
+
+// Scheduler publishes scheduled messages that are due until its context is
+// cancelled.
+type Scheduler struct {
+	messageService *service.MessageService
+	cfg            config.SchedulerConfig
+	metrics        *Metrics
+	logger         *zap.Logger
+}
+
+func NewScheduler(messageService *service.MessageService, cfg config.SchedulerConfig, metrics *Metrics, logger *zap.Logger) *Scheduler {
+	return &Scheduler{
+		messageService: messageService,
+		cfg:            cfg,
+		metrics:        metrics,
+		logger:         logger,
+	}
+}
+
+// Run publishes due messages every PublishInterval until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PublishInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.PublishOnce(ctx); err != nil {
+			s.logger.Error("Failed to publish scheduled messages", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishOnce publishes every scheduled message that is due by now, one
+// batch at a time, and reports how many were published.
+func (s *Scheduler) PublishOnce(ctx context.Context) (int, error) {
+	total := 0
+	for ctx.Err() == nil {
+		published, err := s.messageService.PublishMessages(ctx, s.cfg.PublishBatchSize)
+		if err != nil {
+			s.metrics.failures.Inc()
+			return total, err
+		}
+
+		s.metrics.published.Add(float64(published))
+		total += published
+
+		if published < int(s.cfg.PublishBatchSize) {
+			break
+		}
+	}
+
+	if total > 0 {
+		s.logger.Info("Published scheduled messages", zap.Int("count", total))
+	}
+	return total, ctx.Err()
+}
@@ -0,0 +1,132 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-boilerplate/config"
+	"go-boilerplate/internal/auth"
+	"go-boilerplate/internal/cache"
+	"go-boilerplate/internal/events"
+	"go-boilerplate/internal/models"
+	"go-boilerplate/internal/service"
+	"go-boilerplate/internal/tenant"
+	"go.uber.org/zap"
+)
+
+func TestScheduler_PublishOnce(t *testing.T) {
+	ctx := auth.NewContext(context.Background(), &auth.Claims{UserID: "author"})
+	reader := auth.NewContext(context.Background(), &auth.Claims{UserID: "reader"})
+	store := service.NewMemoryStore()
+	messageService := service.NewMessageService(store, cache.NewMemoryCache(), service.Options{})
+
+	soon := time.Now().Add(20 * time.Millisecond)
+	later := time.Now().Add(time.Hour)
+
+	var messages []*models.Message
+	for _, publishAt := range []*time.Time{nil, &soon, &soon, &later} {
+		message := &models.Message{Content: "announcement", PublishAt: publishAt}
+		require.NoError(t, messageService.CreateMessage(ctx, message))
+		messages = append(messages, message)
+	}
+	assert.Equal(t, models.StatusPublished, messages[0].Status)
+	assert.Equal(t, models.StatusScheduled, messages[1].Status)
+
+	// A message of another tenant is published all the same
+	other := &models.Message{Content: "elsewhere", PublishAt: &soon}
+	require.NoError(t, messageService.CreateMessage(tenant.NewContext(ctx, "acme"), other))
+
+	// Skip the events recorded so far
+	_, err := store.DispatchOutbox(ctx, 100, func(*models.OutboxEvent) error { return nil })
+	require.NoError(t, err)
+
+	time.Sleep(30 * time.Millisecond)
+
+	// Due messages stay hidden until the scheduler publishes them
+	_, err = messageService.GetMessage(reader, messages[1].ID)
+	assert.ErrorIs(t, err, service.ErrMessageNotFound)
+
+	// A batch size of one exercises publishing in several batches
+	scheduler := NewScheduler(messageService, config.SchedulerConfig{
+		PublishInterval:  time.Hour,
+		PublishBatchSize: 1,
+	}, NewMetrics(prometheus.NewRegistry()), zap.NewNop())
+
+	published, err := scheduler.PublishOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, published)
+
+	for _, message := range messages[:3] {
+		got, err := messageService.GetMessage(reader, message.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusPublished, got.Status)
+	}
+	_, err = messageService.GetMessage(reader, messages[3].ID)
+	assert.ErrorIs(t, err, service.ErrMessageNotFound)
+
+	var publishedIDs []string
+	_, err = store.DispatchOutbox(ctx, 100, func(event *models.OutboxEvent) error {
+		assert.Equal(t, events.TypeMessagePublished, event.EventType)
+		publishedIDs = append(publishedIDs, event.AggregateID.String())
+		return nil
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{messages[1].ID.String(), messages[2].ID.String(), other.ID.String()}, publishedIDs)
+
+	// Nothing is left to publish
+	published, err = scheduler.PublishOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, published)
+}
+
+func TestScheduler_PublishesOnce(t *testing.T) {
+	ctx := context.Background()
+	store := service.NewMemoryStore()
+	messageService := service.NewMessageService(store, cache.NewMemoryCache(), service.Options{})
+
+	soon := time.Now().Add(20 * time.Millisecond)
+	for i := 0; i < 20; i++ {
+		require.NoError(t, messageService.CreateMessage(ctx, &models.Message{Content: "due", PublishAt: &soon}))
+	}
+	_, err := store.DispatchOutbox(ctx, 100, func(*models.OutboxEvent) error { return nil })
+	require.NoError(t, err)
+
+	time.Sleep(30 * time.Millisecond)
+
+	// Replicas racing over the same messages publish each of them once
+	var wg sync.WaitGroup
+	totals := make([]int, 4)
+	for i := range totals {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			scheduler := NewScheduler(messageService, config.SchedulerConfig{
+				PublishInterval:  time.Hour,
+				PublishBatchSize: 3,
+			}, NewMetrics(prometheus.NewRegistry()), zap.NewNop())
+
+			published, err := scheduler.PublishOnce(ctx)
+			assert.NoError(t, err)
+			totals[i] = published
+		}(i)
+	}
+	wg.Wait()
+
+	sum := 0
+	for _, total := range totals {
+		sum += total
+	}
+	assert.Equal(t, 20, sum)
+
+	dispatched, err := store.DispatchOutbox(ctx, 100, func(event *models.OutboxEvent) error {
+		assert.Equal(t, events.TypeMessagePublished, event.EventType)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 20, dispatched)
+}
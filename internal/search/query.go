@@ -0,0 +1,208 @@
+// Package search parses full-text search queries.
+//
+// A query is a list of terms that must all match. A term is a word, a
+// prefix written as word*, or a phrase in double quotes whose words must
+// appear next to each other, as in "hello wor*". Everything but letters and
+// digits separates words. Queries render to PostgreSQL to_tsquery syntax and
+// can also be matched in memory, which compares whole words case
+// insensitively without stemming or stop words.
+package search
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+
+	"go-boilerplate/internal/apperr"
+)
+
+// ErrInvalidQuery is returned when a query has nothing to search for.
+var ErrInvalidQuery = apperr.New(apperr.InvalidArgument, "INVALID_QUERY", "invalid search query").WithField("q").WithHTTPStatus(http.StatusBadRequest)
+
+// Markers wrapped around the matches in highlighted snippets. Snippets are
+// not HTML escaped.
+const (
+	StartSel = "<mark>"
+	StopSel  = "</mark>"
+)
+
+// maxTerms bounds the work a single query can cause.
+const maxTerms = 32
+
+// Term is a word, prefix or phrase that a matching text contains.
+type Term struct {
+	Words []string
+	// Prefix matches the last word as a prefix rather than a whole word.
+	Prefix bool
+}
+
+// Query is a parsed search query.
+type Query struct {
+	Terms []Term
+}
+
+// Parse parses a search query.
+func Parse(text string) (Query, error) {
+	var query Query
+
+	for i, segment := range strings.Split(text, `"`) {
+		words := scan(segment)
+		if len(words) == 0 {
+			continue
+		}
+
+		if i%2 == 1 {
+			// Odd segments are inside quotes
+			phrase := Term{Prefix: words[len(words)-1].prefix}
+			for _, w := range words {
+				phrase.Words = append(phrase.Words, w.text)
+			}
+			query.Terms = append(query.Terms, phrase)
+			continue
+		}
+
+		for _, w := range words {
+			query.Terms = append(query.Terms, Term{Words: []string{w.text}, Prefix: w.prefix})
+		}
+	}
+
+	if len(query.Terms) == 0 {
+		return Query{}, fmt.Errorf("%w: no words to search for", ErrInvalidQuery)
+	}
+	if len(query.Terms) > maxTerms {
+		return Query{}, fmt.Errorf("%w: more than %d terms", ErrInvalidQuery, maxTerms)
+	}
+
+	return query, nil
+}
+
+// TSQuery renders the query in PostgreSQL to_tsquery syntax.
+func (q Query) TSQuery() string {
+	terms := make([]string, len(q.Terms))
+	for i, term := range q.Terms {
+		words := make([]string, len(term.Words))
+		for j, w := range term.Words {
+			// Words only hold letters and digits, so quoting is safe
+			words[j] = "'" + w + "'"
+		}
+		if term.Prefix {
+			words[len(words)-1] += ":*"
+		}
+
+		terms[i] = strings.Join(words, " <-> ")
+		if len(words) > 1 {
+			terms[i] = "(" + terms[i] + ")"
+		}
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+// Span is the byte range of a match in a text.
+type Span struct {
+	Start, End int
+}
+
+// Match reports whether text contains every term of the query. rank grows
+// with the number of matches and spans locates each matched word.
+func (q Query) Match(text string) (rank float32, spans []Span, ok bool) {
+	tokens := tokenize(text)
+
+	for _, term := range q.Terms {
+		found := false
+		for i := 0; i+len(term.Words) <= len(tokens); i++ {
+			if !term.matchesAt(tokens[i:]) {
+				continue
+			}
+			found = true
+			rank++
+			for _, t := range tokens[i : i+len(term.Words)] {
+				spans = append(spans, t.span)
+			}
+		}
+		if !found {
+			return 0, nil, false
+		}
+	}
+
+	return rank, spans, true
+}
+
+// Highlight wraps the spans of text in StartSel and StopSel.
+func Highlight(text string, spans []Span) string {
+	sorted := append([]Span(nil), spans...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	var b strings.Builder
+	last := 0
+	for _, span := range sorted {
+		if span.Start < last {
+			// Already highlighted through another term
+			continue
+		}
+		b.WriteString(text[last:span.Start])
+		b.WriteString(StartSel)
+		b.WriteString(text[span.Start:span.End])
+		b.WriteString(StopSel)
+		last = span.End
+	}
+	b.WriteString(text[last:])
+
+	return b.String()
+}
+
+func (t Term) matchesAt(tokens []token) bool {
+	for i, w := range t.Words {
+		if i == len(t.Words)-1 && t.Prefix {
+			if !strings.HasPrefix(tokens[i].text, w) {
+				return false
+			}
+		} else if tokens[i].text != w {
+			return false
+		}
+	}
+	return true
+}
+
+type word struct {
+	text   string
+	prefix bool
+}
+
+// scan splits a query segment into lower-cased words, noting which are
+// followed by a * prefix marker.
+func scan(segment string) []word {
+	var words []word
+	for _, t := range tokenize(segment) {
+		prefix := t.span.End < len(segment) && segment[t.span.End] == '*'
+		words = append(words, word{text: t.text, prefix: prefix})
+	}
+	return words
+}
+
+type token struct {
+	text string
+	span Span
+}
+
+// tokenize splits text into lower-cased runs of letters and digits.
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			tokens = append(tokens, token{text: strings.ToLower(text[start:i]), span: Span{start, i}})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{text: strings.ToLower(text[start:]), span: Span{start, len(text)}})
+	}
+	return tokens
+}
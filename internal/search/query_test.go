@@ -0,0 +1,55 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_TSQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"hello", "'hello'"},
+		{"Hello World", "'hello' & 'world'"},
+		{"wor*", "'wor':*"},
+		{`"hello world"`, "('hello' <-> 'world')"},
+		{`say "hello wor*" again`, "'say' & ('hello' <-> 'wor':*) & 'again'"},
+		{`it's 'quoted'; DROP`, "'it' & 's' & 'quoted' & 'drop'"},
+		{`"unterminated phrase`, "('unterminated' <-> 'phrase')"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, err := Parse(tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, query.TSQuery())
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, text := range []string{"", "   ", `""`, "*&|!"} {
+		_, err := Parse(text)
+		assert.ErrorIs(t, err, ErrInvalidQuery, text)
+	}
+}
+
+func TestQuery_Match(t *testing.T) {
+	text := "Hello world, the world says hello"
+
+	query, err := Parse(`"world says" hel*`)
+	require.NoError(t, err)
+
+	rank, spans, ok := query.Match(text)
+	require.True(t, ok)
+	assert.Equal(t, float32(3), rank)
+	assert.Equal(t, "<mark>Hello</mark> world, the <mark>world</mark> <mark>says</mark> <mark>hello</mark>", Highlight(text, spans))
+
+	query, err = Parse(`"says world"`)
+	require.NoError(t, err)
+	_, _, ok = query.Match(text)
+	assert.False(t, ok)
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/google/uuid"
+	"go-boilerplate/internal/apperr"
+	"go-boilerplate/internal/models"
+	"go-boilerplate/internal/tenant"
+)
+
+// MaxAttachments is the largest number of files attached to one message.
+const MaxAttachments = 20
+
+// maxFilenameLength bounds the stored filename of an attachment, in bytes.
+const maxFilenameLength = 255
+
+// sniffLength is how much of an upload is read to detect its content type.
+const sniffLength = 3072
+
+// ErrAttachmentTooLarge is returned for an upload larger than the maximum
+// attachment size.
+var ErrAttachmentTooLarge = apperr.New(apperr.InvalidArgument, "ATTACHMENT_TOO_LARGE", "attachment too large").WithField("file").WithHTTPStatus(http.StatusRequestEntityTooLarge)
+
+// ErrAttachmentType is returned for an upload whose content type is not
+// among the allowed types.
+var ErrAttachmentType = apperr.New(apperr.InvalidArgument, "ATTACHMENT_TYPE_NOT_ALLOWED", "attachment type not allowed").WithField("file").WithHTTPStatus(http.StatusUnsupportedMediaType)
+
+// ErrTooManyAttachments is returned when a message already has
+// MaxAttachments attachments.
+var ErrTooManyAttachments = apperr.New(apperr.InvalidArgument, "TOO_MANY_ATTACHMENTS", "too many attachments")
+
+// ErrAttachmentsDisabled is returned when the service has no blob store.
+var ErrAttachmentsDisabled = apperr.New(apperr.FailedPrecondition, "ATTACHMENTS_DISABLED", "attachments are not enabled").WithHTTPStatus(http.StatusNotImplemented)
+
+// Upload is a file to attach to a message.
+type Upload struct {
+	// Filename is the name given by the uploader; only its base name is kept.
+	Filename string
+	// Size is the length of Content in bytes.
+	Size    int64
+	Content io.Reader
+}
+
+// MaxAttachmentSize returns the largest upload AddAttachment accepts, in
+// bytes, or 0 if there is no limit.
+func (s *MessageService) MaxAttachmentSize() int64 {
+	return s.maxAttachmentSize
+}
+
+// AddAttachment stores upload and attaches it to a live message, which the
+// caller in ctx must be allowed to change. The content type is sniffed from
+// the content rather than taken from the uploader, and must match one of
+// the allowed types.
+func (s *MessageService) AddAttachment(ctx context.Context, messageID uuid.UUID, upload Upload) (*models.Attachment, error) {
+	if s.blobs == nil {
+		return nil, ErrAttachmentsDisabled
+	}
+	if s.maxAttachmentSize > 0 && upload.Size > s.maxAttachmentSize {
+		return nil, fmt.Errorf("%w: %d bytes, the limit is %d", ErrAttachmentTooLarge, upload.Size, s.maxAttachmentSize)
+	}
+
+	// Check before reading the upload, and again when recording it
+	message, err := s.store.GetMessage(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorize(ctx, message); err != nil {
+		return nil, err
+	}
+	if err := checkAttachmentCount(ctx, s.store, messageID); err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(upload.Content, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	head = head[:n]
+	contentType := mimetype.Detect(head).String()
+	if !s.allowedType(contentType) {
+		return nil, fmt.Errorf("%w: %s", ErrAttachmentType, contentType)
+	}
+
+	attachment := &models.Attachment{
+		ID:          uuid.New(),
+		MessageID:   messageID,
+		Filename:    cleanFilename(upload.Filename),
+		ContentType: contentType,
+		Size:        upload.Size,
+		UploaderID:  callerID(ctx),
+	}
+	attachment.StorageKey = path.Join(tenant.FromContext(ctx), messageID.String(), attachment.ID.String())
+
+	hash := sha256.New()
+	content := io.TeeReader(io.LimitReader(io.MultiReader(bytes.NewReader(head), upload.Content), upload.Size), hash)
+	if err := s.blobs.Put(ctx, attachment.StorageKey, content, upload.Size, contentType); err != nil {
+		return nil, err
+	}
+	attachment.Checksum = hex.EncodeToString(hash.Sum(nil))
+
+	err = s.store.WithTx(ctx, func(tx MessageStore) error {
+		message, err := tx.GetMessageForUpdate(ctx, messageID)
+		if err != nil {
+			return err
+		}
+		if err := authorize(ctx, message); err != nil {
+			return err
+		}
+		if err := checkAttachmentCount(ctx, tx, messageID); err != nil {
+			return err
+		}
+		return tx.CreateAttachment(ctx, attachment)
+	})
+	if err != nil {
+		if err := s.blobs.Delete(ctx, attachment.StorageKey); err != nil {
+			// Log error but don't fail the request
+			// TODO: Add proper logging
+		}
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// ListAttachments returns the attachments of a live message, oldest first.
+func (s *MessageService) ListAttachments(ctx context.Context, messageID uuid.UUID) ([]*models.Attachment, error) {
+	if _, err := s.store.GetMessage(ctx, messageID); err != nil {
+		return nil, err
+	}
+
+	return s.store.ListAttachments(ctx, messageID)
+}
+
+// OpenAttachment returns an attachment of a live message together with its
+// content, which the caller must close.
+func (s *MessageService) OpenAttachment(ctx context.Context, messageID, id uuid.UUID) (*models.Attachment, io.ReadSeekCloser, error) {
+	if s.blobs == nil {
+		return nil, nil, ErrAttachmentsDisabled
+	}
+	if _, err := s.store.GetMessage(ctx, messageID); err != nil {
+		return nil, nil, err
+	}
+
+	attachment, err := s.store.GetAttachment(ctx, messageID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := s.blobs.Open(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return attachment, content, nil
+}
+
+// deleteBlobs deletes the contents of purged attachments. Their metadata is
+// already gone, so a blob left behind is only wasted space.
+func (s *MessageService) deleteBlobs(ctx context.Context, attachments []*models.Attachment) {
+	if s.blobs == nil {
+		return
+	}
+	for _, attachment := range attachments {
+		if err := s.blobs.Delete(ctx, attachment.StorageKey); err != nil {
+			// Log error but don't fail the request
+			// TODO: Add proper logging
+		}
+	}
+}
+
+// allowedType reports whether contentType matches one of the allowed
+// types, either exactly or through a pattern such as "image/*". Parameters
+// such as charset are ignored. Any type is allowed if none are configured.
+func (s *MessageService) allowedType(contentType string) bool {
+	if len(s.attachmentTypes) == 0 {
+		return true
+	}
+	base, _, _ := strings.Cut(contentType, ";")
+	base = strings.ToLower(strings.TrimSpace(base))
+	major, _, _ := strings.Cut(base, "/")
+
+	for _, allowed := range s.attachmentTypes {
+		allowed = strings.ToLower(allowed)
+		if allowed == base || allowed == major+"/*" {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAttachmentCount returns ErrTooManyAttachments if the message already
+// has MaxAttachments attachments.
+func checkAttachmentCount(ctx context.Context, store MessageStore, messageID uuid.UUID) error {
+	attachments, err := store.ListAttachments(ctx, messageID)
+	if err != nil {
+		return err
+	}
+	if len(attachments) >= MaxAttachments {
+		return fmt.Errorf("%w: a message has at most %d attachments", ErrTooManyAttachments, MaxAttachments)
+	}
+	return nil
+}
+
+// cleanFilename keeps the base name of an uploaded filename, without
+// control characters and at most maxFilenameLength bytes long.
+func cleanFilename(filename string) string {
+	filename = path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	filename = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, filename)
+	filename = strings.TrimSpace(filename)
+
+	for len(filename) > maxFilenameLength {
+		_, size := utf8.DecodeLastRuneInString(filename)
+		filename = filename[:len(filename)-size]
+	}
+	if filename == "" || filename == "." || filename == "/" || filename == ".." {
+		return "attachment"
+	}
+	return filename
+}
@@ -0,0 +1,999 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go-boilerplate/internal/models"
+	"go-boilerplate/internal/search"
+	"go-boilerplate/internal/tenant"
+)
+
+// MemoryStore is a MessageStore and OutboxStore that keeps everything in
+// process memory. It mirrors the PostgreSQL semantics (soft deletes,
+// newest-first listing, transactional outbox) so the service can run without
+// a database in development and tests. Like the row-level security
+// policies, every lookup only sees the messages of the tenant in its context.
+type MemoryStore struct {
+	// txMu serializes writers; a transaction holds it from snapshot to commit
+	txMu       *sync.Mutex
+	dispatchMu sync.Mutex
+	inTx       bool
+
+	mu          sync.RWMutex
+	messages    map[uuid.UUID]*models.Message
+	revisions   map[uuid.UUID][]*models.MessageRevision
+	attachments map[uuid.UUID][]*models.Attachment // by message ID
+	outbox      []*models.OutboxEvent
+	nextEventID int64
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		txMu:        &sync.Mutex{},
+		messages:    make(map[uuid.UUID]*models.Message),
+		revisions:   make(map[uuid.UUID][]*models.MessageRevision),
+		attachments: make(map[uuid.UUID][]*models.Attachment),
+	}
+}
+
+func (s *MemoryStore) CreateMessage(ctx context.Context, message *models.Message) error {
+	metadata, err := cloneMetadata(message.Metadata)
+	if err != nil {
+		return err
+	}
+
+	s.write(func() {
+		now := time.Now().UTC()
+		stored := &models.Message{
+			ID:        uuid.New(),
+			Content:   message.Content,
+			AuthorID:  message.AuthorID,
+			TenantID:  tenant.FromContext(ctx),
+			Labels:    cloneLabels(message.Labels),
+			Metadata:  metadata,
+			ThreadID:  message.ThreadID,
+			Version:   1,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if message.ParentID != nil {
+			parentID := *message.ParentID
+			stored.ParentID = &parentID
+		}
+		stored.ExpiresAt = cloneTime(message.ExpiresAt)
+		stored.Status = message.Status
+		stored.PublishAt = cloneTime(message.PublishAt)
+		// Like the database, a message without a thread starts its own
+		if stored.ThreadID == uuid.Nil {
+			stored.ThreadID = stored.ID
+		}
+		s.messages[stored.ID] = stored
+		s.countReply(stored, 1)
+
+		*message = *stored
+	})
+
+	return nil
+}
+
+func (s *MemoryStore) CreateMessages(ctx context.Context, messages []*models.Message) error {
+	for _, message := range messages {
+		if err := s.CreateMessage(ctx, message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) GetMessage(ctx context.Context, id uuid.UUID) (*models.Message, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored, ok := s.messages[id]
+	if !ok || stored.DeletedAt != nil || expired(stored, time.Now()) || !owned(ctx, stored) || !visible(ctx, stored) {
+		return nil, ErrMessageNotFound
+	}
+
+	message := *stored
+	return &message, nil
+}
+
+// GetMessageForUpdate is GetMessage; transactions already hold the store
+// exclusively.
+func (s *MemoryStore) GetMessageForUpdate(ctx context.Context, id uuid.UUID) (*models.Message, error) {
+	return s.GetMessage(ctx, id)
+}
+
+func (s *MemoryStore) UpdateMessage(ctx context.Context, message *models.Message) error {
+	metadata, err := cloneMetadata(message.Metadata)
+	if err != nil {
+		return err
+	}
+
+	err = ErrMessageNotFound
+	s.write(func() {
+		stored, ok := s.messages[message.ID]
+		if !ok || stored.DeletedAt != nil || expired(stored, time.Now()) || !owned(ctx, stored) {
+			return
+		}
+		if message.Version != 0 && message.Version != stored.Version {
+			err = ErrVersionConflict
+			return
+		}
+
+		stored.Content = message.Content
+		stored.Labels = cloneLabels(message.Labels)
+		stored.Metadata = metadata
+		stored.ExpiresAt = cloneTime(message.ExpiresAt)
+		stored.Status = message.Status
+		stored.PublishAt = cloneTime(message.PublishAt)
+		stored.Version++
+		stored.UpdatedAt = time.Now().UTC()
+
+		*message = *stored
+		err = nil
+	})
+
+	return err
+}
+
+func (s *MemoryStore) DeleteMessage(ctx context.Context, id uuid.UUID) error {
+	s.write(func() {
+		// Like the SQL soft delete, removing a missing message is a no-op.
+		if stored, ok := s.messages[id]; ok && stored.DeletedAt == nil && owned(ctx, stored) {
+			now := time.Now().UTC()
+			stored.DeletedAt = &now
+			s.countReply(stored, -1)
+		}
+	})
+
+	return nil
+}
+
+func (s *MemoryStore) DeleteMessages(ctx context.Context, ids []uuid.UUID) ([]*models.Message, error) {
+	var deleted []*models.Message
+	s.write(func() {
+		now := time.Now().UTC()
+		for _, id := range ids {
+			if stored, ok := s.messages[id]; ok && stored.DeletedAt == nil && !expired(stored, now) && owned(ctx, stored) && visible(ctx, stored) {
+				deletedAt := now
+				stored.DeletedAt = &deletedAt
+				s.countReply(stored, -1)
+
+				message := *stored
+				deleted = append(deleted, &message)
+			}
+		}
+	})
+
+	return deleted, nil
+}
+
+func (s *MemoryStore) DeleteReplies(ctx context.Context, parentIDs []uuid.UUID) ([]*models.Message, error) {
+	var deleted []*models.Message
+	s.write(func() {
+		now := time.Now().UTC()
+		// Walk down level by level, through deleted and expired replies
+		// too, like the recursive query
+		visited := make(map[uuid.UUID]bool)
+		parents := make(map[uuid.UUID]bool, len(parentIDs))
+		for _, id := range parentIDs {
+			parents[id] = true
+		}
+		for len(parents) > 0 {
+			children := make(map[uuid.UUID]bool)
+			for _, stored := range s.messages {
+				if stored.ParentID == nil || !parents[*stored.ParentID] || visited[stored.ID] || !owned(ctx, stored) {
+					continue
+				}
+				visited[stored.ID] = true
+				children[stored.ID] = true
+
+				if stored.DeletedAt == nil && !expired(stored, now) {
+					deletedAt := now
+					stored.DeletedAt = &deletedAt
+					s.countReply(stored, -1)
+					deleted = append(deleted, stored)
+				}
+			}
+			parents = children
+		}
+
+		// Copy once every count below is settled
+		for i, stored := range deleted {
+			message := *stored
+			deleted[i] = &message
+		}
+	})
+
+	return deleted, nil
+}
+
+func (s *MemoryStore) ListMessages(ctx context.Context, opts ListOptions) ([]*models.Message, error) {
+	messages := filterMessages(s.live(ctx), opts.Filter)
+	sort.Slice(messages, func(i, j int) bool {
+		return comparePosition(opts.Sort, messages[i], opts.Sort.keyset(messages[j])) < 0
+	})
+
+	switch {
+	case opts.After != nil:
+		// Skip everything up to and including the position
+		start := sort.Search(len(messages), func(i int) bool {
+			return comparePosition(opts.Sort, messages[i], opts.After) > 0
+		})
+		return paginate(messages[start:], ListOptions{Limit: opts.Limit}), nil
+	case opts.Before != nil:
+		// Take the last Limit messages ahead of the position
+		end := sort.Search(len(messages), func(i int) bool {
+			return comparePosition(opts.Sort, messages[i], opts.Before) >= 0
+		})
+		start := end - int(opts.Limit)
+		if start < 0 {
+			start = 0
+		}
+		return messages[start:end], nil
+	}
+
+	return paginate(messages, opts), nil
+}
+
+func (s *MemoryStore) CountMessages(ctx context.Context, filter MessageFilter) (int64, error) {
+	return int64(len(filterMessages(s.live(ctx), filter))), nil
+}
+
+func (s *MemoryStore) ListThread(ctx context.Context, threadID uuid.UUID, limit int32) ([]*models.Message, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	messages := make([]*models.Message, 0)
+	for _, stored := range s.messages {
+		if stored.ThreadID != threadID || !owned(ctx, stored) {
+			continue
+		}
+		message := *stored
+		messages = append(messages, &message)
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].CreatedAt.Equal(messages[j].CreatedAt) {
+			return messages[i].ID.String() < messages[j].ID.String()
+		}
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+	if len(messages) > int(limit) {
+		messages = messages[:limit]
+	}
+
+	return messages, nil
+}
+
+func (s *MemoryStore) SearchMessages(ctx context.Context, query search.Query, opts ListOptions) ([]*models.SearchResult, error) {
+	results := s.search(ctx, query)
+
+	start := int(opts.Offset)
+	if start > len(results) {
+		start = len(results)
+	}
+	end := start + int(opts.Limit)
+	if end > len(results) {
+		end = len(results)
+	}
+
+	return results[start:end], nil
+}
+
+func (s *MemoryStore) CountSearchResults(ctx context.Context, query search.Query) (int64, error) {
+	return int64(len(s.search(ctx, query))), nil
+}
+
+func (s *MemoryStore) ListDeletedMessages(ctx context.Context, opts ListOptions, everyAuthor bool) ([]*models.Message, error) {
+	return paginate(s.deleted(ctx, everyAuthor), opts), nil
+}
+
+func (s *MemoryStore) CountDeletedMessages(ctx context.Context, everyAuthor bool) (int64, error) {
+	return int64(len(s.deleted(ctx, everyAuthor))), nil
+}
+
+func (s *MemoryStore) RestoreMessage(ctx context.Context, id uuid.UUID) (*models.Message, error) {
+	var message *models.Message
+	s.write(func() {
+		stored, ok := s.messages[id]
+		if !ok || stored.DeletedAt == nil || !owned(ctx, stored) {
+			return
+		}
+
+		now := time.Now().UTC()
+		stored.DeletedAt = nil
+		stored.UpdatedAt = now
+		// Like the SQL query, clear an expiry that has passed
+		if expired(stored, now) {
+			stored.ExpiresAt = nil
+		}
+		s.countReply(stored, 1)
+
+		restored := *stored
+		message = &restored
+	})
+	if message == nil {
+		return nil, ErrMessageNotFound
+	}
+
+	return message, nil
+}
+
+func (s *MemoryStore) PurgeMessage(ctx context.Context, id uuid.UUID) (*models.Message, error) {
+	var message *models.Message
+	s.write(func() {
+		if stored, ok := s.messages[id]; ok && owned(ctx, stored) {
+			message = stored
+			s.purge(id)
+		}
+	})
+	if message == nil {
+		return nil, ErrMessageNotFound
+	}
+
+	return message, nil
+}
+
+func (s *MemoryStore) PurgeDeletedMessages(ctx context.Context, deletedBefore time.Time, limit int32) ([]*models.Message, error) {
+	// Like the SQL query, this purges the trash of every tenant
+	var purged []*models.Message
+	s.write(func() {
+		var expired []*models.Message
+		for _, stored := range s.messages {
+			if stored.DeletedAt != nil && stored.DeletedAt.Before(deletedBefore) {
+				expired = append(expired, stored)
+			}
+		}
+
+		// Oldest deletions first, like the SQL query
+		sort.Slice(expired, func(i, j int) bool {
+			return expired[i].DeletedAt.Before(*expired[j].DeletedAt)
+		})
+		if len(expired) > int(limit) {
+			expired = expired[:limit]
+		}
+
+		for _, stored := range expired {
+			s.purge(stored.ID)
+		}
+		purged = expired
+	})
+
+	return purged, nil
+}
+
+func (s *MemoryStore) ExpireMessages(ctx context.Context, expiredBy time.Time, limit int32) ([]*models.Message, error) {
+	// Like the SQL query, this expires the messages of every tenant
+	var deleted []*models.Message
+	s.write(func() {
+		var due []*models.Message
+		for _, stored := range s.messages {
+			if stored.DeletedAt == nil && expired(stored, expiredBy) {
+				due = append(due, stored)
+			}
+		}
+
+		// Soonest expiry first, like the SQL query
+		sort.Slice(due, func(i, j int) bool {
+			return due[i].ExpiresAt.Before(*due[j].ExpiresAt)
+		})
+		if len(due) > int(limit) {
+			due = due[:limit]
+		}
+
+		now := time.Now().UTC()
+		for _, stored := range due {
+			deletedAt := now
+			stored.DeletedAt = &deletedAt
+			stored.UpdatedAt = now
+			s.countReply(stored, -1)
+		}
+
+		// Copy once every count below is settled
+		for _, stored := range due {
+			message := *stored
+			deleted = append(deleted, &message)
+		}
+	})
+
+	return deleted, nil
+}
+
+func (s *MemoryStore) PublishMessages(ctx context.Context, publishedBy time.Time, limit int32) ([]*models.Message, error) {
+	// Like the SQL query, this publishes the messages of every tenant
+	var published []*models.Message
+	s.write(func() {
+		now := time.Now()
+		var due []*models.Message
+		for _, stored := range s.messages {
+			if stored.Status == models.StatusScheduled && stored.DeletedAt == nil && !expired(stored, now) &&
+				!stored.PublishAt.After(publishedBy) {
+				due = append(due, stored)
+			}
+		}
+
+		// Soonest first, like the SQL query
+		sort.Slice(due, func(i, j int) bool {
+			return due[i].PublishAt.Before(*due[j].PublishAt)
+		})
+		if len(due) > int(limit) {
+			due = due[:limit]
+		}
+
+		for _, stored := range due {
+			stored.Status = models.StatusPublished
+			stored.Version++
+			stored.UpdatedAt = now.UTC()
+
+			message := *stored
+			published = append(published, &message)
+		}
+	})
+
+	return published, nil
+}
+
+func (s *MemoryStore) CreateRevision(ctx context.Context, revision *models.MessageRevision) error {
+	s.write(func() {
+		stored := *revision
+		stored.TenantID = tenant.FromContext(ctx)
+		stored.CreatedAt = time.Now().UTC()
+		s.revisions[stored.MessageID] = append(s.revisions[stored.MessageID], &stored)
+
+		*revision = stored
+	})
+
+	return nil
+}
+
+func (s *MemoryStore) CreateRevisions(ctx context.Context, revisions []*models.MessageRevision) error {
+	for _, revision := range revisions {
+		stored := *revision
+		if err := s.CreateRevision(ctx, &stored); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) GetRevision(ctx context.Context, messageID uuid.UUID, revision int64) (*models.MessageRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, stored := range s.revisions[messageID] {
+		if stored.Revision == revision && stored.TenantID == tenant.FromContext(ctx) {
+			found := *stored
+			return &found, nil
+		}
+	}
+
+	return nil, ErrRevisionNotFound
+}
+
+func (s *MemoryStore) ListRevisions(ctx context.Context, messageID uuid.UUID, opts ListOptions) ([]*models.MessageRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Revisions are appended in order, so walk them backwards for newest first
+	stored := s.tenantRevisions(ctx, messageID)
+	revisions := make([]*models.MessageRevision, 0, opts.Limit)
+	for i := len(stored) - 1 - int(opts.Offset); i >= 0 && len(revisions) < int(opts.Limit); i-- {
+		revision := *stored[i]
+		revisions = append(revisions, &revision)
+	}
+
+	return revisions, nil
+}
+
+func (s *MemoryStore) CountRevisions(ctx context.Context, messageID uuid.UUID) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return int64(len(s.tenantRevisions(ctx, messageID))), nil
+}
+
+func (s *MemoryStore) CreateAttachment(ctx context.Context, attachment *models.Attachment) error {
+	s.write(func() {
+		stored := *attachment
+		stored.TenantID = tenant.FromContext(ctx)
+		stored.CreatedAt = time.Now().UTC()
+		s.attachments[stored.MessageID] = append(s.attachments[stored.MessageID], &stored)
+
+		*attachment = stored
+	})
+
+	return nil
+}
+
+func (s *MemoryStore) GetAttachment(ctx context.Context, messageID, id uuid.UUID) (*models.Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, stored := range s.attachments[messageID] {
+		if stored.ID == id && stored.TenantID == tenant.FromContext(ctx) {
+			found := *stored
+			return &found, nil
+		}
+	}
+
+	return nil, ErrAttachmentNotFound
+}
+
+func (s *MemoryStore) ListAttachments(ctx context.Context, messageID uuid.UUID) ([]*models.Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Attachments are appended in order, so they are oldest first
+	attachments := []*models.Attachment{}
+	for _, stored := range s.attachments[messageID] {
+		if stored.TenantID == tenant.FromContext(ctx) {
+			attachment := *stored
+			attachments = append(attachments, &attachment)
+		}
+	}
+
+	return attachments, nil
+}
+
+func (s *MemoryStore) PurgeAttachments(ctx context.Context, messageIDs []uuid.UUID) ([]*models.Attachment, error) {
+	var purged []*models.Attachment
+	s.write(func() {
+		for _, messageID := range messageIDs {
+			var kept []*models.Attachment
+			for _, stored := range s.attachments[messageID] {
+				if stored.TenantID == tenant.FromContext(ctx) || tenant.FromContext(ctx) == tenant.All {
+					purged = append(purged, stored)
+					continue
+				}
+				kept = append(kept, stored)
+			}
+			if len(kept) == 0 {
+				delete(s.attachments, messageID)
+			} else {
+				s.attachments[messageID] = kept
+			}
+		}
+	})
+
+	return purged, nil
+}
+
+func (s *MemoryStore) EnqueueEvent(ctx context.Context, event *models.OutboxEvent) error {
+	s.write(func() {
+		s.nextEventID++
+		stored := &models.OutboxEvent{
+			ID:          s.nextEventID,
+			AggregateID: event.AggregateID,
+			EventType:   event.EventType,
+			Payload:     event.Payload,
+			CreatedAt:   time.Now().UTC(),
+		}
+		s.outbox = append(s.outbox, stored)
+
+		*event = *stored
+	})
+
+	return nil
+}
+
+func (s *MemoryStore) EnqueueEvents(ctx context.Context, events []*models.OutboxEvent) error {
+	for _, event := range events {
+		stored := *event
+		if err := s.EnqueueEvent(ctx, &stored); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) WithTx(ctx context.Context, fn func(tx MessageStore) error) error {
+	// Join the surrounding transaction rather than nesting a new one
+	if s.inTx {
+		return fn(s)
+	}
+
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	// Run fn against a private copy and swap it in only on success
+	tx := s.snapshot()
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.messages = tx.messages
+	s.revisions = tx.revisions
+	s.attachments = tx.attachments
+	s.outbox = tx.outbox
+	s.nextEventID = tx.nextEventID
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *MemoryStore) DispatchOutbox(ctx context.Context, limit int32, publish func(*models.OutboxEvent) error) (int, error) {
+	if !s.dispatchMu.TryLock() {
+		return 0, ErrOutboxLocked
+	}
+	defer s.dispatchMu.Unlock()
+
+	s.mu.RLock()
+	var pending []models.OutboxEvent
+	for _, event := range s.outbox {
+		if event.DispatchedAt == nil && len(pending) < int(limit) {
+			pending = append(pending, *event)
+		}
+	}
+	s.mu.RUnlock()
+
+	dispatched := 0
+	for i := range pending {
+		event := &pending[i]
+		err := publish(event)
+		s.write(func() {
+			stored := s.findEvent(event.ID)
+			if stored == nil {
+				return
+			}
+			if err != nil {
+				stored.Attempts++
+				stored.LastError = err.Error()
+				return
+			}
+			now := time.Now().UTC()
+			stored.DispatchedAt = &now
+		})
+		if err != nil {
+			return dispatched, err
+		}
+		dispatched++
+	}
+
+	return dispatched, nil
+}
+
+func (s *MemoryStore) PurgeOutbox(ctx context.Context, dispatchedBefore time.Time) (int64, error) {
+	var purged int64
+	s.write(func() {
+		kept := s.outbox[:0]
+		for _, event := range s.outbox {
+			if event.DispatchedAt != nil && event.DispatchedAt.Before(dispatchedBefore) {
+				purged++
+				continue
+			}
+			kept = append(kept, event)
+		}
+		s.outbox = kept
+	})
+
+	return purged, nil
+}
+
+func (s *MemoryStore) OutboxBacklog(ctx context.Context) (OutboxBacklog, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	backlog := OutboxBacklog{OldestCreatedAt: time.Now().UTC()}
+	for _, event := range s.outbox {
+		if event.DispatchedAt != nil {
+			continue
+		}
+		if backlog.Pending == 0 {
+			backlog.OldestCreatedAt = event.CreatedAt
+		}
+		backlog.Pending++
+	}
+
+	return backlog, nil
+}
+
+func (s *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+// write runs fn with exclusive access to the store's state.
+func (s *MemoryStore) write(fn func()) {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fn()
+}
+
+// snapshot returns a transaction-bound copy of the store's state.
+func (s *MemoryStore) snapshot() *MemoryStore {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tx := &MemoryStore{
+		txMu:        &sync.Mutex{},
+		inTx:        true,
+		messages:    make(map[uuid.UUID]*models.Message, len(s.messages)),
+		revisions:   make(map[uuid.UUID][]*models.MessageRevision, len(s.revisions)),
+		attachments: make(map[uuid.UUID][]*models.Attachment, len(s.attachments)),
+		outbox:      make([]*models.OutboxEvent, len(s.outbox)),
+		nextEventID: s.nextEventID,
+	}
+	for id, stored := range s.messages {
+		message := *stored
+		tx.messages[id] = &message
+	}
+	// Revisions are immutable, so only the slices need copying
+	for id, stored := range s.revisions {
+		tx.revisions[id] = append([]*models.MessageRevision(nil), stored...)
+	}
+	// Attachments are never changed either
+	for id, stored := range s.attachments {
+		tx.attachments[id] = append([]*models.Attachment(nil), stored...)
+	}
+	for i, stored := range s.outbox {
+		event := *stored
+		tx.outbox[i] = &event
+	}
+
+	return tx
+}
+
+// purge removes a message and its revisions. The caller must hold mu.
+func (s *MemoryStore) purge(id uuid.UUID) {
+	if stored, ok := s.messages[id]; ok && stored.DeletedAt == nil {
+		s.countReply(stored, -1)
+	}
+	delete(s.messages, id)
+	delete(s.revisions, id)
+}
+
+// countReply adds delta to the reply count of the parent of message, if it
+// has one, as message becomes live or stops being so. The caller must hold
+// mu.
+func (s *MemoryStore) countReply(message *models.Message, delta int64) {
+	if message.ParentID == nil {
+		return
+	}
+	if parent, ok := s.messages[*message.ParentID]; ok {
+		parent.ReplyCount += delta
+	}
+}
+
+func (s *MemoryStore) findEvent(id int64) *models.OutboxEvent {
+	for _, event := range s.outbox {
+		if event.ID == id {
+			return event
+		}
+	}
+	return nil
+}
+
+// tenantRevisions returns the revisions of a message of the tenant in ctx.
+// The caller must hold mu.
+func (s *MemoryStore) tenantRevisions(ctx context.Context, messageID uuid.UUID) []*models.MessageRevision {
+	var revisions []*models.MessageRevision
+	for _, revision := range s.revisions[messageID] {
+		if revision.TenantID == tenant.FromContext(ctx) {
+			revisions = append(revisions, revision)
+		}
+	}
+	return revisions
+}
+
+// owned reports whether message belongs to the tenant in ctx.
+func owned(ctx context.Context, message *models.Message) bool {
+	return message.TenantID == tenant.FromContext(ctx)
+}
+
+// live returns copies of the messages of the tenant in ctx that are neither
+// deleted nor expired and that the caller may see, newest first.
+func (s *MemoryStore) live(ctx context.Context) []*models.Message {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	messages := make([]*models.Message, 0, len(s.messages))
+	for _, stored := range s.messages {
+		if stored.DeletedAt != nil || expired(stored, now) || !owned(ctx, stored) || !visible(ctx, stored) {
+			continue
+		}
+		message := *stored
+		messages = append(messages, &message)
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].CreatedAt.Equal(messages[j].CreatedAt) {
+			return messages[i].ID.String() > messages[j].ID.String()
+		}
+		return messages[i].CreatedAt.After(messages[j].CreatedAt)
+	})
+
+	return messages
+}
+
+// search returns the live messages matching query, best match first. The
+// snippet is the whole content with every match highlighted.
+func (s *MemoryStore) search(ctx context.Context, query search.Query) []*models.SearchResult {
+	results := make([]*models.SearchResult, 0)
+	for _, message := range s.live(ctx) {
+		rank, spans, ok := query.Match(message.Content)
+		if !ok {
+			continue
+		}
+		results = append(results, &models.SearchResult{
+			Message: *message,
+			Rank:    rank,
+			Snippet: search.Highlight(message.Content, spans),
+		})
+	}
+
+	// Keep the newest-first order of live among equal ranks
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank > results[j].Rank
+	})
+
+	return results
+}
+
+// deleted returns copies of the soft-deleted messages of the tenant in ctx
+// that the caller may see, most recently deleted first: those written by the
+// caller, and with everyAuthor those of everyone else.
+func (s *MemoryStore) deleted(ctx context.Context, everyAuthor bool) []*models.Message {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	author := callerID(ctx)
+	messages := make([]*models.Message, 0)
+	for _, stored := range s.messages {
+		if stored.DeletedAt == nil || !owned(ctx, stored) || !visible(ctx, stored) {
+			continue
+		}
+		if !everyAuthor && stored.AuthorID != author {
+			continue
+		}
+		message := *stored
+		messages = append(messages, &message)
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].DeletedAt.Equal(*messages[j].DeletedAt) {
+			return messages[i].ID.String() < messages[j].ID.String()
+		}
+		return messages[i].DeletedAt.After(*messages[j].DeletedAt)
+	})
+
+	return messages
+}
+
+// cloneLabels returns a copy of labels that the caller cannot change, nil
+// when there are none.
+func cloneLabels(labels []string) []string {
+	if len(labels) == 0 {
+		return nil
+	}
+	return append([]string(nil), labels...)
+}
+
+// cloneTime returns a copy of t that the caller cannot change.
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	clone := *t
+	return &clone
+}
+
+// cloneMetadata returns a deep copy of metadata, passed through JSON like
+// the metadata column so numbers read back as float64. Empty metadata is
+// nil.
+func cloneMetadata(metadata map[string]interface{}) (map[string]interface{}, error) {
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	var clone map[string]interface{}
+	if err := json.Unmarshal(encoded, &clone); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata: %w", err)
+	}
+	return clone, nil
+}
+
+// hasAll reports whether every one of want is in have.
+func hasAll(have []string, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if h == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// hasKeys reports whether metadata has every one of keys.
+func hasKeys(metadata map[string]interface{}, keys []string) bool {
+	for _, key := range keys {
+		if _, ok := metadata[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// filterMessages returns the messages matching filter.
+func filterMessages(messages []*models.Message, filter MessageFilter) []*models.Message {
+	var ids map[uuid.UUID]bool
+	if len(filter.IDs) > 0 {
+		ids = make(map[uuid.UUID]bool, len(filter.IDs))
+		for _, id := range filter.IDs {
+			ids[id] = true
+		}
+	}
+
+	matching := make([]*models.Message, 0, len(messages))
+	for _, message := range messages {
+		switch {
+		case !filter.CreatedAfter.IsZero() && !message.CreatedAt.After(filter.CreatedAfter),
+			!filter.CreatedBefore.IsZero() && !message.CreatedAt.Before(filter.CreatedBefore),
+			!filter.UpdatedAfter.IsZero() && !message.UpdatedAt.After(filter.UpdatedAfter),
+			!filter.UpdatedBefore.IsZero() && !message.UpdatedAt.Before(filter.UpdatedBefore),
+			ids != nil && !ids[message.ID],
+			filter.AuthorID != "" && message.AuthorID != filter.AuthorID,
+			filter.ParentID != nil && (message.ParentID == nil || *message.ParentID != *filter.ParentID),
+			filter.Status != "" && message.Status != filter.Status,
+			!hasAll(message.Labels, filter.Labels),
+			!hasKeys(message.Metadata, filter.MetadataKeys),
+			!strings.HasPrefix(message.Content, filter.ContentPrefix):
+			continue
+		}
+		matching = append(matching, message)
+	}
+	return matching
+}
+
+// comparePosition reports whether message comes before (-1), at (0) or
+// after (+1) position in order. IDs compare like the uuid type in
+// PostgreSQL.
+func comparePosition(order Sort, message *models.Message, position *Keyset) int {
+	c := order.value(message).Compare(position.At)
+	if c == 0 {
+		c = strings.Compare(message.ID.String(), position.ID.String())
+	}
+	if !order.Ascending {
+		c = -c
+	}
+	return c
+}
+
+// paginate returns the page of messages selected by opts.
+func paginate(messages []*models.Message, opts ListOptions) []*models.Message {
+	start := int(opts.Offset)
+	if start > len(messages) {
+		start = len(messages)
+	}
+	end := start + int(opts.Limit)
+	if end > len(messages) {
+		end = len(messages)
+	}
+
+	return messages[start:end]
+}
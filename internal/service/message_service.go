@@ -2,123 +2,490 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
-	"go-boilerplate/internal/cache"
-	"go-boilerplate/internal/db"
-	"go-boilerplate/internal/kafka"
+	"go-boilerplate/internal/apperr"
+	"go-boilerplate/internal/auth"
+	"go-boilerplate/internal/events"
+	"go-boilerplate/internal/metadata"
 	"go-boilerplate/internal/models"
+	"go-boilerplate/internal/moderation"
+	"go-boilerplate/internal/search"
+	"go-boilerplate/internal/tenant"
+	"net/http"
+	"regexp"
+	"sort"
 	"time"
+	"unicode/utf8"
 )
 
+// MaxBatchSize is the largest number of items a batch operation accepts.
+const MaxBatchSize = 1000
+
+// ErrInvalidBatch is returned for a batch that is empty or larger than
+// MaxBatchSize.
+var ErrInvalidBatch = apperr.New(apperr.InvalidArgument, "INVALID_BATCH", "invalid batch").WithHTTPStatus(http.StatusBadRequest)
+
+// ErrInvalidPageSize is returned for a page size too large to be read.
+var ErrInvalidPageSize = apperr.New(apperr.InvalidArgument, "INVALID_PAGE_SIZE", "invalid page size").WithField("page_size")
+
+// ErrInvalidPage is returned for a page out of the range that can be read,
+// or a keyset page asked for both after and before a position.
+var ErrInvalidPage = apperr.New(apperr.InvalidArgument, "INVALID_PAGE", "invalid page").WithField("page")
+
+// ErrBatchAborted is the result of the items of an atomic batch that were
+// not applied because another item failed.
+var ErrBatchAborted = apperr.New(apperr.Conflict, "BATCH_ABORTED", "not applied because another item of the batch failed").WithHTTPStatus(http.StatusFailedDependency)
+
+// PermissionModerate lets its holders update, delete and restore messages
+// written by anyone.
+const PermissionModerate = "messages:moderate"
+
+// ErrPermissionDenied is returned when the caller may not change a message:
+// only its author or a holder of PermissionModerate may.
+var ErrPermissionDenied = apperr.New(apperr.PermissionDenied, "PERMISSION_DENIED", "only the author of a message may change it")
+
+// errBatchRejected rolls back an atomic batch after one of its items failed.
+var errBatchRejected = errors.New("batch rejected")
+
+// Limits on the content, labels and metadata of a message.
+const (
+	// MaxContentLength bounds the content, in characters.
+	MaxContentLength = 1000
+	MaxLabels        = 32
+	MaxLabelLength   = 64
+	// MaxMetadataSize bounds the JSON encoding of the metadata, in bytes.
+	MaxMetadataSize = 16 << 10
+)
+
+// ErrInvalidContent is returned for a message whose content is empty or
+// longer than MaxContentLength.
+var ErrInvalidContent = apperr.New(apperr.InvalidArgument, "INVALID_CONTENT", "invalid content").WithField("content")
+
+// ErrInvalidLabels is returned for a message whose labels break the rules
+// checked by CheckMessage.
+var ErrInvalidLabels = apperr.New(apperr.InvalidArgument, "INVALID_LABELS", "invalid labels").WithField("labels")
+
+// ErrInvalidMetadata is returned for a message whose metadata is too large
+// or does not match the metadata schema.
+var ErrInvalidMetadata = apperr.New(apperr.InvalidArgument, "INVALID_METADATA", "invalid metadata").WithField("metadata")
+
+// labelPattern matches the characters a label may be made of.
+var labelPattern = regexp.MustCompile(`^[A-Za-z0-9_.:/-]+$`)
+
+// ErrInvalidExpiry is returned for a message set to expire at a time that
+// has already passed.
+var ErrInvalidExpiry = apperr.New(apperr.InvalidArgument, "INVALID_EXPIRY", "invalid expiry").WithField("expires_at")
+
+// ErrInvalidStatus is returned for a message whose status does not fit its
+// publish time, or for an attempt to take a published message back.
+var ErrInvalidStatus = apperr.New(apperr.InvalidArgument, "INVALID_STATUS", "invalid status").WithField("status")
+
+// MessageCacheTTL is how long a message stays cached, unless it expires
+// sooner.
+const MessageCacheTTL = 24 * time.Hour
+
+// ErrParentNotFound is returned for a reply to a message that does not
+// exist or is deleted.
+var ErrParentNotFound = apperr.New(apperr.InvalidArgument, "PARENT_NOT_FOUND", "parent message not found").WithField("parent_id")
+
+// Limits on the threads returned by GetThread.
+const (
+	// MaxThreadDepth is the deepest level of replies returned, the root
+	// message being level 0.
+	MaxThreadDepth = 50
+	// MaxThreadSize bounds the number of messages read for a thread.
+	MaxThreadSize = 1000
+)
+
+// DeletePolicy decides what becomes of the replies to a deleted message.
+type DeletePolicy string
+
+const (
+	// DeleteKeepReplies leaves the replies in place. The deleted message
+	// stays in its thread as a tombstone for as long as replies below it
+	// are live.
+	DeleteKeepReplies DeletePolicy = "keep"
+	// DeleteCascade deletes the replies with the message, their replies in
+	// turn and so on down the thread. Each of them emits message.deleted,
+	// whoever wrote it. Restoring the message does not restore them.
+	DeleteCascade DeletePolicy = "cascade"
+)
+
+// Options configures a MessageService. The zero Options accept any metadata
+// and keep the replies to deleted messages.
+type Options struct {
+	// MetadataSchema constrains the metadata of messages; nil accepts any.
+	MetadataSchema *metadata.Schema
+	// DeletePolicy applies to single and batch deletes. The zero value is
+	// DeleteKeepReplies.
+	DeletePolicy DeletePolicy
+	// Blobs holds the contents of attachments; nil disables attachments.
+	Blobs BlobStore
+	// MaxAttachmentSize bounds uploaded attachments, in bytes; 0 is no limit.
+	MaxAttachmentSize int64
+	// AttachmentTypes lists the content types attachments may have, such as
+	// "application/pdf" or "image/*"; empty allows any.
+	AttachmentTypes []string
+	// Moderation reviews the content of messages as they are created and
+	// whenever an update changes it; nil accepts any content.
+	Moderation *moderation.Chain
+}
+
 type MessageService struct {
-	queries  *db.Queries
-	pool     *pgxpool.Pool
-	cache    *cache.RedisCache
-	producer *kafka.Producer
+	store             MessageStore
+	cache             MessageCache
+	schema            *metadata.Schema
+	deletePolicy      DeletePolicy
+	blobs             BlobStore
+	maxAttachmentSize int64
+	attachmentTypes   []string
+	moderation        *moderation.Chain
 }
 
-func NewMessageService(pool *pgxpool.Pool, cache *cache.RedisCache, producer *kafka.Producer) *MessageService {
+func NewMessageService(store MessageStore, cache MessageCache, opts Options) *MessageService {
 	return &MessageService{
-		queries:  db.New(pool),
-		pool:     pool,
-		cache:    cache,
-		producer: producer,
+		store:             store,
+		cache:             cache,
+		schema:            opts.MetadataSchema,
+		deletePolicy:      opts.DeletePolicy,
+		blobs:             opts.Blobs,
+		maxAttachmentSize: opts.MaxAttachmentSize,
+		attachmentTypes:   opts.AttachmentTypes,
+		moderation:        opts.Moderation,
+	}
+}
+
+// CheckMessage validates the content, labels, metadata and expiry of
+// message. A message has 1 to MaxContentLength characters of content, at
+// most MaxLabels distinct labels of 1 to MaxLabelLength letters, digits,
+// '_', '.', ':', '/' or '-', metadata whose JSON encoding is at most
+// MaxMetadataSize bytes and matches the metadata schema, if there is one,
+// and no expiry or one in the future. Errors wrap ErrInvalidContent,
+// ErrInvalidLabels, ErrInvalidMetadata or ErrInvalidExpiry.
+func (s *MessageService) CheckMessage(message *models.Message) error {
+	if length := utf8.RuneCountInString(message.Content); length == 0 || length > MaxContentLength {
+		return fmt.Errorf("%w: content must be 1 to %d characters, not %d", ErrInvalidContent, MaxContentLength, length)
+	}
+	if message.ExpiresAt != nil && expired(message, time.Now()) {
+		return fmt.Errorf("%w: expires_at %s has already passed", ErrInvalidExpiry, message.ExpiresAt.Format(time.RFC3339))
+	}
+	if len(message.Labels) > MaxLabels {
+		return fmt.Errorf("%w: a message has at most %d labels, not %d", ErrInvalidLabels, MaxLabels, len(message.Labels))
+	}
+	seen := make(map[string]bool, len(message.Labels))
+	for _, label := range message.Labels {
+		if len(label) > MaxLabelLength || !labelPattern.MatchString(label) {
+			return fmt.Errorf("%w: label %q must be 1 to %d letters, digits or any of _.:/-", ErrInvalidLabels, label, MaxLabelLength)
+		}
+		if seen[label] {
+			return fmt.Errorf("%w: label %q is repeated", ErrInvalidLabels, label)
+		}
+		seen[label] = true
+	}
+
+	encoded, err := json.Marshal(message.Metadata)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMetadata, err)
 	}
+	if len(encoded) > MaxMetadataSize {
+		return fmt.Errorf("%w: metadata is %d bytes, the limit is %d", ErrInvalidMetadata, len(encoded), MaxMetadataSize)
+	}
+	if err := s.schema.Validate(message.Metadata); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMetadata, err)
+	}
+	return nil
 }
 
+// CreateMessage stores a new message written by the caller in ctx. A
+// message with a ParentID replies to that message, which must be live, and
+// joins its thread; otherwise it starts a thread of its own. Its status and
+// publish time are settled as described by schedule. message.published is
+// only emitted for messages published after they were created; a message
+// published right away is announced by its message.created alone. The
+// content is reviewed by the content filters, which may reject it with a
+// *moderation.RejectionError, mask parts of it, or flag the message, which
+// emits message.flagged after message.created.
 func (s *MessageService) CreateMessage(ctx context.Context, message *models.Message) error {
-	// Create message in database
-	result, err := s.queries.CreateMessage(ctx, message.Content)
+	if err := s.CheckMessage(message); err != nil {
+		return err
+	}
+	if err := schedule(nil, message, time.Now()); err != nil {
+		return err
+	}
+	flags, err := s.moderate(ctx, message)
 	if err != nil {
 		return err
 	}
+	message.AuthorID = callerID(ctx)
 
-	// Update message with database values
-	*message = models.Message{
-		ID:        result.ID,
-		Content:   result.Content,
-		CreatedAt: result.CreatedAt.Time,
-		UpdatedAt: result.UpdatedAt.Time,
+	// Create the message and its created event in one transaction
+	err = s.store.WithTx(ctx, func(tx MessageStore) error {
+		orphans, err := joinThreads(ctx, tx, []*models.Message{message})
+		if err != nil {
+			return err
+		}
+		if orphans[0] != nil {
+			return orphans[0]
+		}
+		if err := tx.CreateMessage(ctx, message); err != nil {
+			return err
+		}
+		if err := recordRevision(ctx, tx, message); err != nil {
+			return err
+		}
+		if err := enqueueEvent(ctx, tx, events.TypeMessageCreated, message, events.MessageCreatedData{
+			Message: *message,
+		}); err != nil {
+			return err
+		}
+		return enqueueFlagged(ctx, tx, message, flags)
+	})
+	if err != nil {
+		return err
 	}
 
 	// Cache the message
-	if err := s.cache.Set(ctx, message.ID.String(), message, 24*time.Hour); err != nil {
-		// Log error but don't fail the request
-		// TODO: Add proper logging
-	}
-
-	// Publish message created event
-	if err := s.producer.PublishMessage(message); err != nil {
-		// Log error but don't fail the request
-		// TODO: Add proper logging
-	}
+	s.cacheMessage(ctx, message)
+	s.uncacheParent(ctx, message)
 
 	return nil
 }
 
+// GetMessage returns a live message. Drafts and scheduled messages are only
+// found by their author.
 func (s *MessageService) GetMessage(ctx context.Context, id uuid.UUID) (*models.Message, error) {
 	// Try to get from cache first
 	var message models.Message
-	if err := s.cache.Get(ctx, id.String(), &message); err == nil {
+	if err := s.cache.Get(ctx, id.String(), &message); err == nil && !expired(&message, time.Now()) && visible(ctx, &message) {
 		return &message, nil
 	}
 
-	// If not in cache, get from database
-	result, err := s.queries.GetMessage(ctx, id)
+	// If not in cache, get from the store
+	result, err := s.store.GetMessage(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	message = models.Message{
-		ID:        result.ID,
-		Content:   result.Content,
-		CreatedAt: result.CreatedAt.Time,
-		UpdatedAt: result.UpdatedAt.Time,
-	}
-
 	// Cache the message for future requests
-	if err := s.cache.Set(ctx, id.String(), &message, 24*time.Hour); err != nil {
-		// Log error but don't fail the request
-		// TODO: Add proper logging
-	}
+	s.cacheMessage(ctx, result)
 
-	return &message, nil
+	return result, nil
 }
 
+// UpdateMessage replaces the content, labels, metadata, expiry and publish
+// time of a message, and its status unless message.Status is empty. If
+// message.Version is non-zero it must match the current version, otherwise
+// ErrVersionConflict is returned and nothing changes. On success message
+// holds the new state, including the bumped version.
 func (s *MessageService) UpdateMessage(ctx context.Context, message *models.Message) error {
-	// Update message in database
-	params := db.UpdateMessageParams{
-		ID:      message.ID,
-		Content: message.Content,
-	}
-	result, err := s.queries.UpdateMessage(ctx, params)
+	updated, err := s.PatchMessage(ctx, message.ID, message.Version, func(current *models.Message) error {
+		current.Content = message.Content
+		current.Labels = message.Labels
+		current.Metadata = message.Metadata
+		current.ExpiresAt = message.ExpiresAt
+		if message.Status != "" {
+			current.Status = message.Status
+		}
+		current.PublishAt = message.PublishAt
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	// Update message with latest values
-	message.UpdatedAt = result.UpdatedAt.Time
+	*message = *updated
+	return nil
+}
+
+// PatchMessage applies patch to the current state of a message and stores
+// the result. patch runs while the message is locked, so it always sees the
+// latest version; any error it returns aborts the update and is returned
+// unchanged. A non-zero expectedVersion must match the current version,
+// otherwise ErrVersionConflict is returned.
+func (s *MessageService) PatchMessage(ctx context.Context, id uuid.UUID, expectedVersion int64, patch func(current *models.Message) error) (*models.Message, error) {
+	return s.update(ctx, id, expectedVersion, func(tx MessageStore, current *models.Message) error {
+		return patch(current)
+	})
+}
+
+// ListRevisions returns a page of the revisions of a live message, newest
+// first, together with the total number of revisions.
+func (s *MessageService) ListRevisions(ctx context.Context, id uuid.UUID, page, pageSize uint32) ([]*models.MessageRevision, int64, error) {
+	if _, err := s.store.GetMessage(ctx, id); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.store.CountRevisions(ctx, id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts, err := pageOptions(page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	revisions, err := s.store.ListRevisions(ctx, id, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return revisions, total, nil
+}
+
+// GetRevision returns one revision of a live message.
+func (s *MessageService) GetRevision(ctx context.Context, id uuid.UUID, revision int64) (*models.MessageRevision, error) {
+	if _, err := s.store.GetMessage(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.store.GetRevision(ctx, id, revision)
+}
+
+// RestoreRevision brings back the content of an earlier revision. The
+// history is never rewritten: restoring records a new revision, bumps the
+// version and emits message.updated like any other update.
+func (s *MessageService) RestoreRevision(ctx context.Context, id uuid.UUID, revision, expectedVersion int64) (*models.Message, error) {
+	return s.update(ctx, id, expectedVersion, func(tx MessageStore, current *models.Message) error {
+		restored, err := tx.GetRevision(ctx, id, revision)
+		if err != nil {
+			return err
+		}
+
+		current.Content = restored.Content
+		return nil
+	})
+}
+
+// update locks a message, lets mutate change it and stores the result
+// together with a new revision and a message.updated event, followed by
+// message.published if the update published it and message.flagged if the
+// content filters flagged it.
+func (s *MessageService) update(ctx context.Context, id uuid.UUID, expectedVersion int64, mutate func(tx MessageStore, current *models.Message) error) (*models.Message, error) {
+	var message *models.Message
+
+	// Update the message and record its updated event in one transaction
+	err := s.store.WithTx(ctx, func(tx MessageStore) error {
+		before, after, flags, err := s.applyUpdate(ctx, tx, id, expectedVersion, mutate)
+		if err != nil {
+			return err
+		}
+		message = after
+
+		if err := recordRevision(ctx, tx, message); err != nil {
+			return err
+		}
+		if err := enqueueEvent(ctx, tx, events.TypeMessageUpdated, after, events.MessageUpdatedData{
+			Before: *before,
+			After:  *after,
+		}); err != nil {
+			return err
+		}
+		if published(before, after) {
+			if err := enqueueEvent(ctx, tx, events.TypeMessagePublished, after, events.MessagePublishedData{
+				Message: *after,
+			}); err != nil {
+				return err
+			}
+		}
+		return enqueueFlagged(ctx, tx, after, flags)
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	// Update cache
-	if err := s.cache.Set(ctx, message.ID.String(), message, 24*time.Hour); err != nil {
-		// Log error but don't fail the request
-		// TODO: Add proper logging
+	s.cacheMessage(ctx, message)
+
+	return message, nil
+}
+
+// applyUpdate locks a message, lets mutate change it and stores the result,
+// returning the message before and after the update and the violations
+// flagged by the content filters, which only review changed content.
+// Recording the revision and events is left to the caller.
+func (s *MessageService) applyUpdate(ctx context.Context, tx MessageStore, id uuid.UUID, expectedVersion int64, mutate func(tx MessageStore, current *models.Message) error) (*models.Message, *models.Message, []moderation.Violation, error) {
+	before, err := tx.GetMessageForUpdate(ctx, id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := authorize(ctx, before); err != nil {
+		return nil, nil, nil, err
+	}
+	if expectedVersion != 0 && expectedVersion != before.Version {
+		return nil, nil, nil, ErrVersionConflict
 	}
 
-	// Publish message updated event
-	if err := s.producer.PublishMessage(message); err != nil {
-		// Log error but don't fail the request
-		// TODO: Add proper logging
+	after := *before
+	if err := mutate(tx, &after); err != nil {
+		return nil, nil, nil, err
+	}
+	// A zero expiry is no expiry, and likewise for the publish time
+	if after.ExpiresAt != nil && after.ExpiresAt.IsZero() {
+		after.ExpiresAt = nil
+	}
+	if after.PublishAt != nil && after.PublishAt.IsZero() {
+		after.PublishAt = nil
+	}
+	if err := s.CheckMessage(&after); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := schedule(before, &after, time.Now()); err != nil {
+		return nil, nil, nil, err
+	}
+	var flags []moderation.Violation
+	if after.Content != before.Content {
+		if flags, err = s.moderate(ctx, &after); err != nil {
+			return nil, nil, nil, err
+		}
 	}
 
-	return nil
+	// Pin the ID and version so the store re-checks them atomically
+	after.ID = before.ID
+	after.Version = before.Version
+	if err := tx.UpdateMessage(ctx, &after); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return before, &after, flags, nil
 }
 
+// DeleteMessage moves a message to the trash. Its replies are kept or
+// deleted with it according to the delete policy.
 func (s *MessageService) DeleteMessage(ctx context.Context, id uuid.UUID) error {
-	// Delete from database
-	if err := s.queries.DeleteMessage(ctx, id); err != nil {
+	var deleted *models.Message
+	var replies []*models.Message
+
+	// Delete the message and record its deleted event in one transaction
+	err := s.store.WithTx(ctx, func(tx MessageStore) error {
+		before, err := tx.GetMessageForUpdate(ctx, id)
+		if errors.Is(err, ErrMessageNotFound) {
+			// Deleting a missing message is a no-op and emits no event
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := authorize(ctx, before); err != nil {
+			return err
+		}
+		if err := tx.DeleteMessage(ctx, id); err != nil {
+			return err
+		}
+		deleted = before
+		if err := enqueueEvent(ctx, tx, events.TypeMessageDeleted, before, events.MessageDeletedData{
+			Message: *before,
+		}); err != nil {
+			return err
+		}
+
+		replies, err = s.deleteReplies(ctx, tx, []uuid.UUID{id})
+		return err
+	})
+	if err != nil {
 		return err
 	}
 
@@ -127,73 +494,1258 @@ func (s *MessageService) DeleteMessage(ctx context.Context, id uuid.UUID) error
 		// Log error but don't fail the request
 		// TODO: Add proper logging
 	}
-
-	// Publish message deleted event
-	deleteEvent := &models.Message{ID: id}
-	if err := s.producer.PublishMessage(deleteEvent); err != nil {
-		// Log error but don't fail the request
-		// TODO: Add proper logging
+	if deleted != nil {
+		s.uncacheParent(ctx, deleted)
 	}
+	s.uncache(ctx, replies)
 
 	return nil
 }
 
-func (s *MessageService) ListMessages(ctx context.Context) ([]*models.Message, error) {
-	params := db.ListMessagesParams{
-		Limit:  100, // Default limit
-		Offset: 0,
+// deleteReplies deletes the replies below the given messages, if the
+// delete policy says so, and records their deleted events. It returns the
+// replies it deleted.
+func (s *MessageService) deleteReplies(ctx context.Context, tx MessageStore, parentIDs []uuid.UUID) ([]*models.Message, error) {
+	if s.deletePolicy != DeleteCascade || len(parentIDs) == 0 {
+		return nil, nil
+	}
+
+	replies, err := tx.DeleteReplies(ctx, parentIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(replies) == 0 {
+		return nil, nil
+	}
+
+	outbox, err := deletedEvents(replies)
+	if err != nil {
+		return nil, err
+	}
+	return replies, tx.EnqueueEvents(ctx, outbox)
+}
+
+// ListReplies returns a page of the live replies to a live message, oldest
+// first, together with the number of them.
+func (s *MessageService) ListReplies(ctx context.Context, id uuid.UUID, page, pageSize uint32) ([]*models.Message, int64, error) {
+	if _, err := s.store.GetMessage(ctx, id); err != nil {
+		return nil, 0, err
+	}
+
+	filter := MessageFilter{ParentID: &id}
+	total, err := s.store.CountMessages(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts, err := pageOptions(page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts.Filter = filter
+	opts.Sort = Sort{Field: SortCreatedAt, Ascending: true}
+
+	replies, err := s.store.ListMessages(ctx, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return replies, total, nil
+}
+
+// GetThread returns the thread started by the message id as a tree, down to
+// depth levels of replies; depth is capped at MaxThreadDepth, which is also
+// the default when depth is not positive. The first MaxThreadSize messages
+// of the thread are read. Deleted and expired messages, and unpublished
+// ones the caller did not write, are left out unless live replies remain
+// below them, in which case they stay as tombstones. Replies whose parent is
+// gone for good are attached to the root, which is itself a tombstone if it
+// was purged. A thread without live messages is reported as
+// ErrMessageNotFound.
+func (s *MessageService) GetThread(ctx context.Context, id uuid.UUID, depth int) (*models.Thread, error) {
+	if depth <= 0 || depth > MaxThreadDepth {
+		depth = MaxThreadDepth
 	}
-	results, err := s.queries.ListMessages(ctx, params)
+
+	messages, err := s.store.ListThread(ctx, id, MaxThreadSize+1)
 	if err != nil {
 		return nil, err
 	}
+	now := time.Now()
+	thread := &models.Thread{}
+	if len(messages) > MaxThreadSize {
+		messages = messages[:MaxThreadSize]
+		thread.Truncated = true
+	}
+
+	var root *models.Message
+	present := make(map[uuid.UUID]bool, len(messages))
+	for _, message := range messages {
+		present[message.ID] = true
+		if message.ID == id {
+			root = message
+		}
+	}
+	if root == nil {
+		root = &models.Message{ID: id, ThreadID: id, TenantID: tenant.FromContext(ctx)}
+	}
+
+	// Messages are oldest first, and so are the replies of each
+	replies := make(map[uuid.UUID][]*models.Message)
+	for _, message := range messages {
+		if message.ID == id {
+			continue
+		}
+		parentID := id
+		if message.ParentID != nil && present[*message.ParentID] {
+			parentID = *message.ParentID
+		}
+		replies[parentID] = append(replies[parentID], message)
+	}
+
+	// hidden reports whether message itself is gone for the caller
+	hidden := func(message *models.Message) bool {
+		return !present[message.ID] || message.DeletedAt != nil || expired(message, now) || !visible(ctx, message)
+	}
+
+	// live reports whether message or any reply below it is live
+	liveBelow := make(map[uuid.UUID]bool)
+	var live func(message *models.Message) bool
+	live = func(message *models.Message) bool {
+		if alive, ok := liveBelow[message.ID]; ok {
+			return alive
+		}
+		alive := !hidden(message)
+		for _, reply := range replies[message.ID] {
+			// Visit every reply so each is memoized
+			if live(reply) {
+				alive = true
+			}
+		}
+		liveBelow[message.ID] = alive
+		return alive
+	}
+	if !live(root) {
+		return nil, ErrMessageNotFound
+	}
 
-	messages := make([]*models.Message, len(results))
-	for i, result := range results {
-		messages[i] = &models.Message{
-			ID:        result.ID,
-			Content:   result.Content,
-			CreatedAt: result.CreatedAt.Time,
-			UpdatedAt: result.UpdatedAt.Time,
+	var attach func(node *models.ThreadNode, level int)
+	attach = func(node *models.ThreadNode, level int) {
+		for _, reply := range replies[node.ID] {
+			if !live(reply) {
+				continue
+			}
+			if level == depth {
+				thread.Truncated = true
+				return
+			}
+			child := newThreadNode(reply, hidden(reply))
+			attach(child, level+1)
+			node.Replies = append(node.Replies, child)
 		}
 	}
+	thread.Root = newThreadNode(root, hidden(root))
+	attach(thread.Root, 0)
+
+	return thread, nil
+}
+
+// newThreadNode returns the node of message in a thread, blanked to a
+// tombstone if it is one.
+func newThreadNode(message *models.Message, tombstone bool) *models.ThreadNode {
+	node := &models.ThreadNode{Message: *message}
+	if tombstone {
+		node.Tombstone = true
+		node.Content = ""
+		node.Labels = nil
+		node.Metadata = nil
+	}
+	return node
+}
 
-	return messages, nil
+func (s *MessageService) ListMessages(ctx context.Context) ([]*models.Message, error) {
+	return s.store.ListMessages(ctx, ListOptions{
+		Limit:  100, // Default limit
+		Offset: 0,
+	})
 }
 
-func (s *MessageService) ListMessagesPaginated(ctx context.Context, page, pageSize uint32) ([]*models.Message, int64, error) {
+// ListMessagesPaginated returns a page of the live messages matching filter
+// in the given order, together with the number of matching messages.
+func (s *MessageService) ListMessagesPaginated(ctx context.Context, filter MessageFilter, order Sort, page, pageSize uint32) ([]*models.Message, int64, error) {
 	// Get total count
-	total, err := s.queries.GetTotalMessages(ctx)
+	total, err := s.store.CountMessages(ctx, filter)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// Get paginated messages
-	offset := (page - 1) * pageSize
-	
-	// Safely convert uint32 to int32
-	if pageSize > uint32(1<<31-1) || offset > uint32(1<<31-1) {
-		return nil, 0, fmt.Errorf("pagination values too large")
+	opts, err := pageOptions(page, pageSize)
+	if err != nil {
+		return nil, 0, err
 	}
-	
-	results, err := s.queries.ListMessages(ctx, db.ListMessagesParams{
-		Limit:  int32(pageSize),
-		Offset: int32(offset),
-	})
+
+	opts.Filter = filter
+	opts.Sort = order
+
+	messages, err := s.store.ListMessages(ctx, opts)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	// Convert to models
-	messages := make([]*models.Message, len(results))
-	for i, result := range results {
-		messages[i] = &models.Message{
-			ID:        result.ID,
-			Content:   result.Content,
-			CreatedAt: result.CreatedAt.Time,
-			UpdatedAt: result.UpdatedAt.Time,
+	return messages, total, nil
+}
+
+// KeysetPage is a page of messages read by position rather than by offset.
+// Next and Prev are the positions to continue from in either direction, nil
+// when there is nothing more to read that way.
+type KeysetPage struct {
+	Messages []*models.Message
+	Next     *Keyset
+	Prev     *Keyset
+}
+
+// ListMessagesKeyset returns up to pageSize live messages matching filter in
+// the given order, starting right after or right before a position in that
+// order. With neither set it returns the first page. Unlike offset pages,
+// keyset pages do not shift when messages are created or deleted while a
+// client is paging.
+func (s *MessageService) ListMessagesKeyset(ctx context.Context, filter MessageFilter, order Sort, pageSize uint32, after, before *Keyset) (*KeysetPage, error) {
+	if after != nil && before != nil {
+		return nil, fmt.Errorf("%w: only one of after and before may be set", ErrInvalidPage)
+	}
+	if pageSize == 0 || pageSize > uint32(1<<31-2) {
+		return nil, fmt.Errorf("%w: page size must be 1 to %d, not %d", ErrInvalidPageSize, 1<<31-2, pageSize)
+	}
+
+	// Read one extra message to learn whether the walk can go on
+	messages, err := s.store.ListMessages(ctx, ListOptions{
+		Limit:  int32(pageSize) + 1,
+		Filter: filter,
+		Sort:   order,
+		After:  after,
+		Before: before,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	more := len(messages) > int(pageSize)
+	if more {
+		if before != nil {
+			// Walking backwards the extra message is the newest one
+			messages = messages[1:]
+		} else {
+			messages = messages[:pageSize]
 		}
 	}
 
-	return messages, total, nil
+	page := &KeysetPage{Messages: messages}
+	if len(messages) == 0 {
+		return page, nil
+	}
+
+	first, last := order.keyset(messages[0]), order.keyset(messages[len(messages)-1])
+	switch {
+	case before != nil:
+		page.Next = last
+		if more {
+			page.Prev = first
+		}
+	case after != nil:
+		page.Prev = first
+		if more {
+			page.Next = last
+		}
+	default:
+		if more {
+			page.Next = last
+		}
+	}
+
+	return page, nil
+}
+
+// SearchMessages runs a full-text search over live messages and returns a
+// page of the results, best match first, together with the total number of
+// matches. See package search for the query syntax; a query without any
+// words fails with search.ErrInvalidQuery.
+func (s *MessageService) SearchMessages(ctx context.Context, text string, page, pageSize uint32) ([]*models.SearchResult, int64, error) {
+	query, err := search.Parse(text)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.store.CountSearchResults(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts, err := pageOptions(page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	results, err := s.store.SearchMessages(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return results, total, nil
+}
+
+// ListDeletedMessages returns a page of the trash, most recently deleted
+// first, together with the number of messages in the trash. Callers only
+// see the messages they wrote unless they hold PermissionModerate.
+func (s *MessageService) ListDeletedMessages(ctx context.Context, page, pageSize uint32) ([]*models.Message, int64, error) {
+	everyAuthor := moderator(ctx)
+	total, err := s.store.CountDeletedMessages(ctx, everyAuthor)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts, err := pageOptions(page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	messages, err := s.store.ListDeletedMessages(ctx, opts, everyAuthor)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return messages, total, nil
+}
+
+// RestoreMessage takes a deleted message out of the trash and emits
+// message.restored. It returns ErrMessageNotFound if the message is not in
+// the trash of the caller, which as in ListDeletedMessages only holds the
+// messages of others for holders of PermissionModerate.
+func (s *MessageService) RestoreMessage(ctx context.Context, id uuid.UUID) (*models.Message, error) {
+	var message *models.Message
+
+	err := s.store.WithTx(ctx, func(tx MessageStore) error {
+		restored, err := tx.RestoreMessage(ctx, id)
+		if err != nil {
+			return err
+		}
+		// Checked after the fact; the transaction undoes the restore
+		if authorize(ctx, restored) != nil {
+			return ErrMessageNotFound
+		}
+		message = restored
+
+		return enqueueEvent(ctx, tx, events.TypeMessageRestored, message, events.MessageRestoredData{
+			Message: *message,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache the message again
+	s.cacheMessage(ctx, message)
+	s.uncacheParent(ctx, message)
+
+	return message, nil
+}
+
+// PurgeMessage permanently removes a message, whether or not it is in the
+// trash, and emits message.purged. Its revisions and attachments are
+// removed with it.
+func (s *MessageService) PurgeMessage(ctx context.Context, id uuid.UUID) error {
+	var message *models.Message
+	var attachments []*models.Attachment
+
+	err := s.store.WithTx(ctx, func(tx MessageStore) error {
+		purged, err := tx.PurgeMessage(ctx, id)
+		if err != nil {
+			return err
+		}
+		message = purged
+
+		attachments, err = tx.PurgeAttachments(ctx, []uuid.UUID{id})
+		if err != nil {
+			return err
+		}
+
+		return enqueueEvent(ctx, tx, events.TypeMessagePurged, purged, events.MessagePurgedData{
+			Message: *purged,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// Delete from cache
+	if err := s.cache.Del(ctx, id.String()); err != nil {
+		// Log error but don't fail the request
+		// TODO: Add proper logging
+	}
+	s.uncacheParent(ctx, message)
+	s.deleteBlobs(ctx, attachments)
+
+	return nil
+}
+
+// PurgeDeletedMessages permanently removes up to limit messages of any
+// tenant that were deleted before the given time, along with their
+// attachments, emitting message.purged for each, and reports how many were
+// removed.
+func (s *MessageService) PurgeDeletedMessages(ctx context.Context, deletedBefore time.Time, limit int32) (int, error) {
+	// The retention job works across tenants
+	ctx = tenant.NewContext(ctx, tenant.All)
+	var purged int
+	var attachments []*models.Attachment
+
+	err := s.store.WithTx(ctx, func(tx MessageStore) error {
+		messages, err := tx.PurgeDeletedMessages(ctx, deletedBefore, limit)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(messages))
+		for i, message := range messages {
+			ids[i] = message.ID
+		}
+		attachments, err = tx.PurgeAttachments(ctx, ids)
+		if err != nil {
+			return err
+		}
+
+		for _, message := range messages {
+			if err := enqueueEvent(ctx, tx, events.TypeMessagePurged, message, events.MessagePurgedData{
+				Message: *message,
+			}); err != nil {
+				return err
+			}
+		}
+		purged = len(messages)
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	s.deleteBlobs(ctx, attachments)
+
+	return purged, nil
+}
+
+// ExpireMessages moves up to limit messages of any tenant whose expiry has
+// passed to the trash, emitting message.expired for each, and reports how
+// many were expired. Their replies are kept whatever the delete policy.
+func (s *MessageService) ExpireMessages(ctx context.Context, limit int32) (int, error) {
+	// The expiry sweeper works across tenants
+	ctx = tenant.NewContext(ctx, tenant.All)
+	var expiredMessages []*models.Message
+
+	err := s.store.WithTx(ctx, func(tx MessageStore) error {
+		messages, err := tx.ExpireMessages(ctx, time.Now(), limit)
+		if err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		outbox := make([]*models.OutboxEvent, len(messages))
+		for i, message := range messages {
+			// Like message.deleted, carry the state before the change
+			before := *message
+			before.DeletedAt = nil
+
+			event, err := newEvent(events.TypeMessageExpired, message, events.MessageExpiredData{
+				Message: before,
+			})
+			if err != nil {
+				return err
+			}
+			outbox[i] = event
+		}
+		expiredMessages = messages
+
+		return tx.EnqueueEvents(ctx, outbox)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// The messages left the cache when they expired, but the reply counts
+	// of their parents changed just now
+	for _, message := range expiredMessages {
+		s.uncacheParent(tenant.NewContext(ctx, message.TenantID), message)
+	}
+
+	return len(expiredMessages), nil
+}
+
+// PublishMessages publishes up to limit scheduled messages of any tenant
+// whose publish time has come, emitting message.published for each, and
+// reports how many were published. Publishing changes a message like an
+// update does: its version is bumped and recorded as a revision. Concurrent
+// callers never publish the same message, so each is published exactly
+// once.
+func (s *MessageService) PublishMessages(ctx context.Context, limit int32) (int, error) {
+	// The scheduler works across tenants
+	ctx = tenant.NewContext(ctx, tenant.All)
+	var publishedMessages []*models.Message
+
+	err := s.store.WithTx(ctx, func(tx MessageStore) error {
+		messages, err := tx.PublishMessages(ctx, time.Now(), limit)
+		if err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		// Revisions are recorded in the tenant of their message
+		revisions := make(map[string][]*models.MessageRevision)
+		for _, message := range messages {
+			revisions[message.TenantID] = append(revisions[message.TenantID], newRevision(ctx, message))
+		}
+		for tenantID, tenantRevisions := range revisions {
+			if err := tx.CreateRevisions(tenant.NewContext(ctx, tenantID), tenantRevisions); err != nil {
+				return err
+			}
+		}
+
+		outbox := make([]*models.OutboxEvent, len(messages))
+		for i, message := range messages {
+			event, err := newEvent(events.TypeMessagePublished, message, events.MessagePublishedData{
+				Message: *message,
+			})
+			if err != nil {
+				return err
+			}
+			outbox[i] = event
+		}
+		publishedMessages = messages
+
+		return tx.EnqueueEvents(ctx, outbox)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Their authors may have cached them while scheduled
+	for _, message := range publishedMessages {
+		s.uncache(tenant.NewContext(ctx, message.TenantID), []*models.Message{message})
+	}
+
+	return len(publishedMessages), nil
+}
+
+// BatchCreateMessages creates messages in one transaction, writing their
+// revisions and events in bulk. Replies and publishing are handled as in
+// CreateMessage. The result of each message is reported at its index: nil,
+// or ErrInvalidContent, ErrInvalidLabels, ErrInvalidMetadata,
+// ErrInvalidExpiry, ErrInvalidStatus, ErrParentNotFound or a
+// *moderation.RejectionError. Atomic batches behave as in
+// BatchUpdateMessages. Messages that were created hold their stored state.
+// The returned error is only set when the batch as a whole failed.
+func (s *MessageService) BatchCreateMessages(ctx context.Context, messages []*models.Message, atomic bool) ([]error, error) {
+	if err := checkBatchSize(len(messages)); err != nil {
+		return nil, err
+	}
+
+	results := make([]error, len(messages))
+	flags := make([][]moderation.Violation, len(messages))
+	now := time.Now()
+	author := callerID(ctx)
+	failed := false
+	for i, message := range messages {
+		err := s.CheckMessage(message)
+		if err == nil {
+			err = schedule(nil, message, now)
+		}
+		if err == nil {
+			flags[i], err = s.moderate(ctx, message)
+		}
+		if isItemError(err) {
+			results[i] = err
+			failed = true
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		message.AuthorID = author
+	}
+	if failed && atomic {
+		abortBatch(results)
+		return results, nil
+	}
+
+	// Batches are not cached; GetMessage fills the cache on first read
+	var created []*models.Message
+	err := s.store.WithTx(ctx, func(tx MessageStore) error {
+		var pending []*models.Message
+		var indexes []int
+		for i, message := range messages {
+			if results[i] == nil {
+				pending = append(pending, message)
+				indexes = append(indexes, i)
+			}
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+
+		orphans, err := joinThreads(ctx, tx, pending)
+		if err != nil {
+			return err
+		}
+		var createdFlags [][]moderation.Violation
+		for j, message := range pending {
+			if orphans[j] != nil {
+				results[indexes[j]] = orphans[j]
+				failed = true
+				continue
+			}
+			created = append(created, message)
+			createdFlags = append(createdFlags, flags[indexes[j]])
+		}
+		if failed && atomic {
+			return errBatchRejected
+		}
+		if len(created) == 0 {
+			return nil
+		}
+
+		if err := tx.CreateMessages(ctx, created); err != nil {
+			return err
+		}
+
+		revisions := make([]*models.MessageRevision, len(created))
+		outbox := make([]*models.OutboxEvent, len(created))
+		for i, message := range created {
+			revisions[i] = newRevision(ctx, message)
+
+			event, err := newEvent(events.TypeMessageCreated, message, events.MessageCreatedData{
+				Message: *message,
+			})
+			if err != nil {
+				return err
+			}
+			outbox[i] = event
+		}
+		for i, message := range created {
+			if len(createdFlags[i]) == 0 {
+				continue
+			}
+			event, err := newEvent(events.TypeMessageFlagged, message, events.MessageFlaggedData{
+				Message:    *message,
+				Violations: createdFlags[i],
+			})
+			if err != nil {
+				return err
+			}
+			outbox = append(outbox, event)
+		}
+
+		if err := tx.CreateRevisions(ctx, revisions); err != nil {
+			return err
+		}
+		return tx.EnqueueEvents(ctx, outbox)
+	})
+	if errors.Is(err, errBatchRejected) {
+		abortBatch(results)
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, message := range created {
+		s.uncacheParent(ctx, message)
+	}
+
+	return results, nil
+}
+
+// BatchUpdateMessages updates several messages in one transaction. Each
+// update names a message by ID and, with a non-zero Version, the version it
+// expects, as in UpdateMessage. Its content, labels, metadata, expiry,
+// status and publish time replace those of the message unless they are
+// empty, zero-length but non-nil labels and metadata and a zero but non-nil
+// ExpiresAt or PublishAt clearing them. Updates that publish their message
+// emit message.published after message.updated.
+//
+// The result of each update is reported at its index: nil, or
+// ErrMessageNotFound, ErrVersionConflict, ErrPermissionDenied,
+// ErrInvalidContent, ErrInvalidLabels, ErrInvalidMetadata, ErrInvalidExpiry,
+// ErrInvalidStatus or a *moderation.RejectionError. When atomic is set, any
+// failed item rolls back the whole batch and the other items report
+// ErrBatchAborted; otherwise the remaining items are still applied. Updates
+// that succeeded hold the new state of their message. The returned error is
+// only set when the batch as a whole failed.
+func (s *MessageService) BatchUpdateMessages(ctx context.Context, updates []*models.Message, atomic bool) ([]error, error) {
+	if err := checkBatchSize(len(updates)); err != nil {
+		return nil, err
+	}
+
+	results := make([]error, len(updates))
+	updated := make([]*models.Message, len(updates))
+
+	err := s.store.WithTx(ctx, func(tx MessageStore) error {
+		var revisions []*models.MessageRevision
+		var outbox []*models.OutboxEvent
+		failed := false
+
+		for i, update := range updates {
+			before, after, flags, err := s.applyUpdate(ctx, tx, update.ID, update.Version, func(tx MessageStore, current *models.Message) error {
+				if update.Content != "" {
+					current.Content = update.Content
+				}
+				if update.Labels != nil {
+					current.Labels = update.Labels
+				}
+				if update.Metadata != nil {
+					current.Metadata = update.Metadata
+				}
+				if update.ExpiresAt != nil {
+					current.ExpiresAt = update.ExpiresAt
+				}
+				if update.Status != "" {
+					current.Status = update.Status
+				}
+				if update.PublishAt != nil {
+					current.PublishAt = update.PublishAt
+				}
+				return nil
+			})
+			if isItemError(err) {
+				results[i] = err
+				failed = true
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			updated[i] = after
+
+			event, err := newEvent(events.TypeMessageUpdated, after, events.MessageUpdatedData{
+				Before: *before,
+				After:  *after,
+			})
+			if err != nil {
+				return err
+			}
+			revisions = append(revisions, newRevision(ctx, after))
+			outbox = append(outbox, event)
+
+			if published(before, after) {
+				event, err := newEvent(events.TypeMessagePublished, after, events.MessagePublishedData{
+					Message: *after,
+				})
+				if err != nil {
+					return err
+				}
+				outbox = append(outbox, event)
+			}
+			if len(flags) > 0 {
+				event, err := newEvent(events.TypeMessageFlagged, after, events.MessageFlaggedData{
+					Message:    *after,
+					Violations: flags,
+				})
+				if err != nil {
+					return err
+				}
+				outbox = append(outbox, event)
+			}
+		}
+
+		if failed && atomic {
+			return errBatchRejected
+		}
+		if len(outbox) == 0 {
+			return nil
+		}
+
+		if err := tx.CreateRevisions(ctx, revisions); err != nil {
+			return err
+		}
+		return tx.EnqueueEvents(ctx, outbox)
+	})
+	if errors.Is(err, errBatchRejected) {
+		abortBatch(results)
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i, message := range updated {
+		if message == nil {
+			continue
+		}
+		*updates[i] = *message
+
+		// Update cache
+		s.cacheMessage(ctx, message)
+	}
+
+	return results, nil
+}
+
+// BatchDeleteMessages moves several messages to the trash in one
+// transaction. Unlike DeleteMessage, a missing message is an error: it is
+// reported as ErrMessageNotFound at its index, as is an ID repeated within
+// the batch. Messages the caller may not delete are reported as
+// ErrPermissionDenied. Atomic batches behave as in BatchUpdateMessages.
+// Replies follow the delete policy, as in DeleteMessage.
+func (s *MessageService) BatchDeleteMessages(ctx context.Context, ids []uuid.UUID, atomic bool) ([]error, error) {
+	if err := checkBatchSize(len(ids)); err != nil {
+		return nil, err
+	}
+
+	results := make([]error, len(ids))
+	var deleted, replies []*models.Message
+
+	err := s.store.WithTx(ctx, func(tx MessageStore) error {
+		denied, err := deniedMessages(ctx, tx, ids)
+		if err != nil {
+			return err
+		}
+		allowed := ids
+		if len(denied) > 0 {
+			allowed = make([]uuid.UUID, 0, len(ids))
+			for _, id := range ids {
+				if !denied[id] {
+					allowed = append(allowed, id)
+				}
+			}
+		}
+
+		removed, err := tx.DeleteMessages(ctx, allowed)
+		if err != nil {
+			return err
+		}
+
+		found := make(map[uuid.UUID]bool, len(removed))
+		for _, message := range removed {
+			found[message.ID] = true
+		}
+		failed := false
+		for i, id := range ids {
+			if denied[id] {
+				results[i] = ErrPermissionDenied
+				failed = true
+				continue
+			}
+			if !found[id] {
+				results[i] = ErrMessageNotFound
+				failed = true
+				continue
+			}
+			delete(found, id)
+		}
+		if failed && atomic {
+			return errBatchRejected
+		}
+		if len(removed) == 0 {
+			return nil
+		}
+
+		outbox, err := deletedEvents(removed)
+		if err != nil {
+			return err
+		}
+		if err := tx.EnqueueEvents(ctx, outbox); err != nil {
+			return err
+		}
+		deleted = removed
+
+		removedIDs := make([]uuid.UUID, len(removed))
+		for i, message := range removed {
+			removedIDs[i] = message.ID
+		}
+		replies, err = s.deleteReplies(ctx, tx, removedIDs)
+		return err
+	})
+	if errors.Is(err, errBatchRejected) {
+		abortBatch(results)
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.uncache(ctx, deleted)
+	s.uncache(ctx, replies)
+	for _, message := range deleted {
+		s.uncacheParent(ctx, message)
+	}
+
+	return results, nil
+}
+
+// cacheMessage caches message for MessageCacheTTL, or only until it
+// expires if that is sooner.
+func (s *MessageService) cacheMessage(ctx context.Context, message *models.Message) {
+	ttl := MessageCacheTTL
+	if message.ExpiresAt != nil {
+		ttl = time.Until(*message.ExpiresAt)
+		if ttl <= 0 {
+			return
+		}
+		if ttl > MessageCacheTTL {
+			ttl = MessageCacheTTL
+		}
+	}
+
+	if err := s.cache.Set(ctx, message.ID.String(), message, ttl); err != nil {
+		// Log error but don't fail the request
+		// TODO: Add proper logging
+	}
+}
+
+// uncache drops messages from the cache.
+func (s *MessageService) uncache(ctx context.Context, messages []*models.Message) {
+	for _, message := range messages {
+		// Delete from cache
+		if err := s.cache.Del(ctx, message.ID.String()); err != nil {
+			// Log error but don't fail the request
+			// TODO: Add proper logging
+		}
+	}
+}
+
+// uncacheParent drops the message that message replies to from the cache,
+// as its reply count has changed.
+func (s *MessageService) uncacheParent(ctx context.Context, message *models.Message) {
+	if message.ParentID == nil {
+		return
+	}
+	if err := s.cache.Del(ctx, message.ParentID.String()); err != nil {
+		// Log error but don't fail the request
+		// TODO: Add proper logging
+	}
+}
+
+// joinThreads points each reply among messages at the thread of its parent
+// and clears the thread of the others, which start their own. Parents are
+// locked, in a fixed order so concurrent batches cannot deadlock, and must
+// be live; a reply to any other message is reported as ErrParentNotFound
+// at its index.
+func joinThreads(ctx context.Context, tx MessageStore, messages []*models.Message) ([]error, error) {
+	var parentIDs []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, message := range messages {
+		message.ThreadID = uuid.Nil
+		if message.ParentID != nil && !seen[*message.ParentID] {
+			seen[*message.ParentID] = true
+			parentIDs = append(parentIDs, *message.ParentID)
+		}
+	}
+	sort.Slice(parentIDs, func(i, j int) bool {
+		return parentIDs[i].String() < parentIDs[j].String()
+	})
+
+	threads := make(map[uuid.UUID]uuid.UUID, len(parentIDs))
+	for _, parentID := range parentIDs {
+		parent, err := tx.GetMessageForUpdate(ctx, parentID)
+		if errors.Is(err, ErrMessageNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		threads[parentID] = parent.ThreadID
+	}
+
+	results := make([]error, len(messages))
+	for i, message := range messages {
+		if message.ParentID == nil {
+			continue
+		}
+		threadID, ok := threads[*message.ParentID]
+		if !ok {
+			results[i] = fmt.Errorf("%w: %s", ErrParentNotFound, *message.ParentID)
+			continue
+		}
+		message.ThreadID = threadID
+	}
+	return results, nil
+}
+
+// deletedEvents returns the message.deleted events of messages that were
+// just deleted. Like DeleteMessage, each event carries its message as it was
+// before the delete.
+func deletedEvents(messages []*models.Message) ([]*models.OutboxEvent, error) {
+	outbox := make([]*models.OutboxEvent, len(messages))
+	for i, message := range messages {
+		before := *message
+		before.DeletedAt = nil
+
+		event, err := newEvent(events.TypeMessageDeleted, message, events.MessageDeletedData{
+			Message: before,
+		})
+		if err != nil {
+			return nil, err
+		}
+		outbox[i] = event
+	}
+	return outbox, nil
+}
+
+// expired reports whether message had expired by now. Expired messages are
+// hidden like deleted ones until the expiry sweeper moves them to the trash.
+func expired(message *models.Message, now time.Time) bool {
+	return message.ExpiresAt != nil && !message.ExpiresAt.After(now)
+}
+
+// schedule settles the status and publish time of message as of now. before
+// is the stored state of an updated message, or nil for a new one.
+//
+// An empty status keeps the stored one; for a new message it is scheduled if
+// PublishAt is in the future and published otherwise. A draft stays hidden
+// until it is given another status. A scheduled message needs a PublishAt,
+// and is published right away if that has already come. A message is never
+// published in the future, and once published it cannot go back nor change
+// its publish time. Publishing sets PublishAt to now. Errors wrap
+// ErrInvalidStatus, or ErrInvalidExpiry for a scheduled message that would
+// expire before it is published.
+func schedule(before, message *models.Message, now time.Time) error {
+	if message.Status == "" {
+		switch {
+		case before != nil:
+			message.Status = before.Status
+		case message.PublishAt != nil && message.PublishAt.After(now):
+			message.Status = models.StatusScheduled
+		default:
+			message.Status = models.StatusPublished
+		}
+	}
+
+	if before != nil && before.Status == models.StatusPublished {
+		if message.Status != models.StatusPublished {
+			return fmt.Errorf("%w: a published message cannot become %s", ErrInvalidStatus, message.Status)
+		}
+		message.PublishAt = before.PublishAt
+		return nil
+	}
+
+	switch message.Status {
+	case models.StatusDraft:
+		return nil
+	case models.StatusScheduled:
+		if message.PublishAt == nil {
+			return fmt.Errorf("%w: a scheduled message needs publish_at", ErrInvalidStatus)
+		}
+		if message.ExpiresAt != nil && !message.ExpiresAt.After(*message.PublishAt) {
+			return fmt.Errorf("%w: expires_at %s is not after publish_at %s", ErrInvalidExpiry,
+				message.ExpiresAt.Format(time.RFC3339), message.PublishAt.Format(time.RFC3339))
+		}
+		if message.PublishAt.After(now) {
+			return nil
+		}
+	case models.StatusPublished:
+		if message.PublishAt != nil && message.PublishAt.After(now) {
+			return fmt.Errorf("%w: a message published now cannot have publish_at %s in the future; schedule it instead",
+				ErrInvalidStatus, message.PublishAt.Format(time.RFC3339))
+		}
+	default:
+		return fmt.Errorf("%w: status must be %s, %s or %s, not %q", ErrInvalidStatus,
+			models.StatusDraft, models.StatusScheduled, models.StatusPublished, message.Status)
+	}
+
+	message.Status = models.StatusPublished
+	publishedAt := now.UTC()
+	message.PublishAt = &publishedAt
+	return nil
+}
+
+// published reports whether an update from before to after published a
+// message.
+func published(before, after *models.Message) bool {
+	return before.Status != models.StatusPublished && after.Status == models.StatusPublished
+}
+
+// checkBatchSize rejects empty and oversized batches.
+func checkBatchSize(n int) error {
+	if n == 0 || n > MaxBatchSize {
+		return fmt.Errorf("%w: a batch holds 1 to %d items, not %d", ErrInvalidBatch, MaxBatchSize, n)
+	}
+	return nil
+}
+
+// isItemError reports whether err fails a single item of a batch rather
+// than the whole batch.
+func isItemError(err error) bool {
+	for _, target := range []error{ErrMessageNotFound, ErrVersionConflict, ErrPermissionDenied, ErrInvalidContent, ErrInvalidLabels, ErrInvalidMetadata, ErrInvalidExpiry, ErrInvalidStatus, ErrParentNotFound, moderation.ErrRejected} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// abortBatch marks the items of a rolled back batch that did not fail
+// themselves as aborted.
+func abortBatch(results []error) {
+	for i, err := range results {
+		if err == nil {
+			results[i] = ErrBatchAborted
+		}
+	}
+}
+
+// pageOptions converts a 1-based page number and page size to ListOptions
+func pageOptions(page, pageSize uint32) (ListOptions, error) {
+	// Safely convert uint32 to int32
+	if pageSize > uint32(1<<31-1) {
+		return ListOptions{}, fmt.Errorf("%w: page size must be at most %d, not %d", ErrInvalidPageSize, 1<<31-1, pageSize)
+	}
+	offset := uint64(page-1) * uint64(pageSize)
+	if page == 0 || offset > 1<<31-1 {
+		return ListOptions{}, fmt.Errorf("%w: page %d is out of range", ErrInvalidPage, page)
+	}
+
+	return ListOptions{
+		Limit:  int32(pageSize),
+		Offset: int32(offset),
+	}, nil
+}
+
+// recordRevision records the current state of message as the revision
+// matching its version, attributed to the caller in ctx.
+func recordRevision(ctx context.Context, tx MessageStore, message *models.Message) error {
+	return tx.CreateRevision(ctx, newRevision(ctx, message))
+}
+
+// newRevision returns the revision recording the current state of message.
+func newRevision(ctx context.Context, message *models.Message) *models.MessageRevision {
+	revision := &models.MessageRevision{
+		MessageID: message.ID,
+		Revision:  message.Version,
+		Content:   message.Content,
+	}
+	revision.Editor = callerID(ctx)
+
+	return revision
+}
+
+// callerID returns the user ID of the caller in ctx, or "" for an
+// anonymous caller.
+func callerID(ctx context.Context) string {
+	if claims, ok := auth.FromContext(ctx); ok {
+		return claims.UserID
+	}
+	return ""
+}
+
+// authorize returns ErrPermissionDenied unless the caller in ctx may change
+// message: its author, or a holder of PermissionModerate. Messages without
+// an author were written anonymously and only anonymous callers own them.
+func authorize(ctx context.Context, message *models.Message) error {
+	if moderator(ctx) {
+		return nil
+	}
+	if message.AuthorID != callerID(ctx) {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// moderator reports whether the caller in ctx holds PermissionModerate.
+func moderator(ctx context.Context) bool {
+	claims, ok := auth.FromContext(ctx)
+	return ok && claims.HasPermission(PermissionModerate)
+}
+
+// visible reports whether the caller in ctx may see message: anyone may
+// see a published message, but drafts and scheduled messages are only seen
+// by their author. As in authorize, anonymous callers own the messages
+// written anonymously.
+func visible(ctx context.Context, message *models.Message) bool {
+	return message.Status == models.StatusPublished || message.AuthorID == callerID(ctx)
+}
+
+// deniedMessages returns the IDs among ids of live messages the caller in
+// ctx may not change.
+func deniedMessages(ctx context.Context, tx MessageStore, ids []uuid.UUID) (map[uuid.UUID]bool, error) {
+	if moderator(ctx) {
+		return nil, nil
+	}
+
+	messages, err := tx.ListMessages(ctx, ListOptions{
+		Limit:  int32(len(ids)),
+		Filter: MessageFilter{IDs: ids},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	denied := make(map[uuid.UUID]bool)
+	for _, message := range messages {
+		if authorize(ctx, message) != nil {
+			denied[message.ID] = true
+		}
+	}
+	return denied, nil
+}
+
+// moderate runs the content of message, written by the caller in ctx,
+// through the content filters and replaces it with the content as they
+// masked it. It returns the violations to report in a message.flagged
+// event, or none if no filter flagged the message.
+func (s *MessageService) moderate(ctx context.Context, message *models.Message) ([]moderation.Violation, error) {
+	content := &moderation.Content{Text: message.Content, AuthorID: callerID(ctx)}
+	violations, err := s.moderation.Review(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	message.Content = content.Text
+
+	if !moderation.Flagged(violations) {
+		return nil, nil
+	}
+	return violations, nil
+}
+
+// enqueueFlagged records a message.flagged event for message if the content
+// filters flagged it.
+func enqueueFlagged(ctx context.Context, tx MessageStore, message *models.Message, flags []moderation.Violation) error {
+	if len(flags) == 0 {
+		return nil
+	}
+	return enqueueEvent(ctx, tx, events.TypeMessageFlagged, message, events.MessageFlaggedData{
+		Message:    *message,
+		Violations: flags,
+	})
+}
+
+// enqueueEvent wraps data in an event envelope about message and records it
+// in the outbox of tx.
+func enqueueEvent(ctx context.Context, tx MessageStore, eventType string, message *models.Message, data interface{}) error {
+	event, err := newEvent(eventType, message, data)
+	if err != nil {
+		return err
+	}
+
+	return tx.EnqueueEvent(ctx, event)
+}
+
+// newEvent wraps data in an event envelope about message, ready for the
+// outbox. The envelope carries the tenant of the message.
+func newEvent(eventType string, message *models.Message, data interface{}) (*models.OutboxEvent, error) {
+	envelope, err := events.NewEnvelope(eventType, message.ID.String(), data)
+	if err != nil {
+		return nil, err
+	}
+	envelope.TenantID = message.TenantID
+
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s event: %w", eventType, err)
+	}
+
+	return &models.OutboxEvent{
+		AggregateID: message.ID,
+		EventType:   eventType,
+		Payload:     payload,
+	}, nil
 }
@@ -1,174 +1,1184 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go-boilerplate/internal/apperr"
+	"go-boilerplate/internal/auth"
+	"go-boilerplate/internal/blob"
+	"go-boilerplate/internal/cache"
+	"go-boilerplate/internal/events"
+	"go-boilerplate/internal/metadata"
 	"go-boilerplate/internal/models"
+	"go-boilerplate/internal/moderation"
+	"go-boilerplate/internal/tenant"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 )
 
-// MockDB is a mock implementation of the database interface
-type MockDB struct {
-	mock.Mock
+func newTestService() (*MessageService, *MemoryStore, *cache.MemoryCache) {
+	store := NewMemoryStore()
+	memoryCache := cache.NewMemoryCache()
+	return NewMessageService(store, memoryCache, Options{}), store, memoryCache
 }
 
-func (m *MockDB) WithContext(ctx context.Context) interface{} {
-	return m
-}
-
-func (m *MockDB) Create(value interface{}) error {
-	args := m.Called(value)
-	return args.Error(0)
-}
-
-func (m *MockDB) First(dest interface{}, conds ...interface{}) error {
-	args := m.Called(dest, conds)
-	return args.Error(0)
-}
-
-func (m *MockDB) Save(value interface{}) error {
-	args := m.Called(value)
-	return args.Error(0)
-}
-
-func (m *MockDB) Delete(value interface{}, conds ...interface{}) error {
-	args := m.Called(value, conds)
-	return args.Error(0)
-}
-
-// MockCache is a mock implementation of the cache interface
-type MockCache struct {
-	mock.Mock
-}
-
-func (m *MockCache) SetMessage(ctx context.Context, message *models.Message) error {
-	args := m.Called(ctx, message)
-	return args.Error(0)
-}
-
-func (m *MockCache) GetMessage(ctx context.Context, id string) (*models.Message, error) {
-	args := m.Called(ctx, id)
-	return args.Get(0).(*models.Message), args.Error(1)
-}
-
-// MockProducer is a mock implementation of the Kafka producer
-type MockProducer struct {
-	mock.Mock
-}
-
-func (m *MockProducer) PublishMessage(message *models.Message) error {
-	args := m.Called(message)
-	return args.Error(0)
+// drainOutbox dispatches every pending outbox event and returns them in order.
+func drainOutbox(t *testing.T, store *MemoryStore) []models.OutboxEvent {
+	t.Helper()
+	var events []models.OutboxEvent
+	_, err := store.DispatchOutbox(context.Background(), 1000, func(event *models.OutboxEvent) error {
+		events = append(events, *event)
+		return nil
+	})
+	require.NoError(t, err)
+	return events
 }
 
 func TestMessageService_CreateMessage(t *testing.T) {
 	// Setup
-	mockDB := new(MockDB)
-	mockCache := new(MockCache)
-	mockProducer := new(MockProducer)
-	
-	service := NewMessageService(mockDB, mockCache, mockProducer)
-	
+	service, store, memoryCache := newTestService()
+
 	ctx := context.Background()
 	message := &models.Message{
 		Content: "Test message",
 	}
-	
-	// Expectations
-	mockDB.On("Create", message).Return(nil)
-	mockCache.On("SetMessage", ctx, message).Return(nil)
-	mockProducer.On("PublishMessage", message).Return(nil)
-	
+
 	// Test
 	err := service.CreateMessage(ctx, message)
-	
+
 	// Assertions
-	assert.NoError(t, err)
-	mockDB.AssertExpectations(t)
-	mockCache.AssertExpectations(t)
-	mockProducer.AssertExpectations(t)
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, message.ID)
+	assert.False(t, message.CreatedAt.IsZero())
+
+	stored, err := store.GetMessage(ctx, message.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Test message", stored.Content)
+
+	var cached models.Message
+	require.NoError(t, memoryCache.Get(ctx, message.ID.String(), &cached))
+	assert.Equal(t, message.ID, cached.ID)
+
+	outbox := drainOutbox(t, store)
+	require.Len(t, outbox, 1)
+	assert.Equal(t, events.TypeMessageCreated, outbox[0].EventType)
+	assert.Equal(t, message.ID, outbox[0].AggregateID)
 }
 
 func TestMessageService_GetMessage(t *testing.T) {
 	// Setup
-	mockDB := new(MockDB)
-	mockCache := new(MockCache)
-	mockProducer := new(MockProducer)
-	
-	service := NewMessageService(mockDB, mockCache, mockProducer)
-	
-	ctx := context.Background()
-	id := uuid.New()
+	service, store, memoryCache := newTestService()
+
+	ctx := context.Background()
 	expectedMessage := &models.Message{
-		ID:        id,
-		Content:   "Test message",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
-	
-	// Expectations
-	mockCache.On("GetMessage", ctx, id.String()).Return(expectedMessage, nil)
-	
+		Content: "Test message",
+	}
+	require.NoError(t, store.CreateMessage(ctx, expectedMessage))
+
 	// Test
-	message, err := service.GetMessage(ctx, id)
-	
+	message, err := service.GetMessage(ctx, expectedMessage.ID)
+
 	// Assertions
-	assert.NoError(t, err)
-	assert.Equal(t, expectedMessage, message)
-	mockCache.AssertExpectations(t)
+	require.NoError(t, err)
+	assert.Equal(t, expectedMessage.ID, message.ID)
+	assert.Equal(t, expectedMessage.Content, message.Content)
+
+	var cached models.Message
+	assert.NoError(t, memoryCache.Get(ctx, expectedMessage.ID.String(), &cached))
+}
+
+func TestMessageService_GetMessage_NotFound(t *testing.T) {
+	service, _, _ := newTestService()
+
+	_, err := service.GetMessage(context.Background(), uuid.New())
+
+	assert.ErrorIs(t, err, ErrMessageNotFound)
 }
 
 func TestMessageService_UpdateMessage(t *testing.T) {
 	// Setup
-	mockDB := new(MockDB)
-	mockCache := new(MockCache)
-	mockProducer := new(MockProducer)
-	
-	service := NewMessageService(mockDB, mockCache, mockProducer)
-	
-	ctx := context.Background()
-	id := uuid.New()
-	message := &models.Message{
-		ID:      id,
+	service, store, memoryCache := newTestService()
+
+	ctx := context.Background()
+	message := &models.Message{Content: "Original content"}
+	require.NoError(t, service.CreateMessage(ctx, message))
+
+	update := &models.Message{
+		ID:      message.ID,
 		Content: "Updated content",
 	}
-	
-	// Expectations
-	mockDB.On("Save", message).Return(nil)
-	mockCache.On("SetMessage", ctx, message).Return(nil)
-	mockProducer.On("PublishMessage", message).Return(nil)
-	
+
 	// Test
-	err := service.UpdateMessage(ctx, message)
-	
+	err := service.UpdateMessage(ctx, update)
+
 	// Assertions
-	assert.NoError(t, err)
-	mockDB.AssertExpectations(t)
-	mockCache.AssertExpectations(t)
-	mockProducer.AssertExpectations(t)
+	require.NoError(t, err)
+	assert.Equal(t, message.CreatedAt, update.CreatedAt)
+
+	var cached models.Message
+	require.NoError(t, memoryCache.Get(ctx, message.ID.String(), &cached))
+	assert.Equal(t, "Updated content", cached.Content)
+
+	outbox := drainOutbox(t, store)
+	require.Len(t, outbox, 2)
+	event, err := events.Decode(outbox[1].Payload)
+	require.NoError(t, err)
+	updated, ok := event.(*events.MessageUpdated)
+	require.True(t, ok)
+	assert.Equal(t, message.ID.String(), updated.Subject)
+	assert.Equal(t, "Original content", updated.Before.Content)
+	assert.Equal(t, "Updated content", updated.After.Content)
+}
+
+func TestMessageService_UpdateMessage_VersionConflict(t *testing.T) {
+	service, store, _ := newTestService()
+
+	ctx := context.Background()
+	message := &models.Message{Content: "Original content"}
+	require.NoError(t, service.CreateMessage(ctx, message))
+	assert.Equal(t, int64(1), message.Version)
+
+	// The first writer holding version 1 wins and bumps the version
+	first := &models.Message{ID: message.ID, Content: "First writer", Version: 1}
+	require.NoError(t, service.UpdateMessage(ctx, first))
+	assert.Equal(t, int64(2), first.Version)
+
+	// The second writer still holds version 1 and must be rejected
+	second := &models.Message{ID: message.ID, Content: "Second writer", Version: 1}
+	err := service.UpdateMessage(ctx, second)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	stored, err := store.GetMessage(ctx, message.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "First writer", stored.Content)
+	assert.Equal(t, int64(2), stored.Version)
+}
+
+func TestMessageService_UpdateMessage_NotFound(t *testing.T) {
+	service, store, _ := newTestService()
+
+	err := service.UpdateMessage(context.Background(), &models.Message{ID: uuid.New(), Content: "x"})
+
+	// The failed update must not leave an event behind in the outbox
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+	assert.Empty(t, drainOutbox(t, store))
 }
 
 func TestMessageService_DeleteMessage(t *testing.T) {
 	// Setup
-	mockDB := new(MockDB)
-	mockCache := new(MockCache)
-	mockProducer := new(MockProducer)
-	
-	service := NewMessageService(mockDB, mockCache, mockProducer)
-	
-	ctx := context.Background()
-	id := uuid.New()
-	
-	// Expectations
-	mockDB.On("Delete", &models.Message{}, "id = ?", id).Return(nil)
-	
+	service, store, _ := newTestService()
+
+	ctx := context.Background()
+	message := &models.Message{Content: "Test message"}
+	require.NoError(t, service.CreateMessage(ctx, message))
+
 	// Test
-	err := service.DeleteMessage(ctx, id)
-	
+	err := service.DeleteMessage(ctx, message.ID)
+
 	// Assertions
+	require.NoError(t, err)
+	_, err = service.GetMessage(ctx, message.ID)
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+
+	outbox := drainOutbox(t, store)
+	require.Len(t, outbox, 2)
+	event, err := events.Decode(outbox[1].Payload)
+	require.NoError(t, err)
+	deleted, ok := event.(*events.MessageDeleted)
+	require.True(t, ok)
+	assert.Equal(t, "Test message", deleted.Message.Content)
+
+	// Deleting it again is a no-op and emits no further event
+	require.NoError(t, service.DeleteMessage(ctx, message.ID))
+	assert.Empty(t, drainOutbox(t, store))
+}
+
+func TestMessageService_ListMessagesPaginated(t *testing.T) {
+	service, _, _ := newTestService()
+
+	ctx := context.Background()
+	for _, content := range []string{"first", "second", "third"} {
+		require.NoError(t, service.CreateMessage(ctx, &models.Message{Content: content}))
+	}
+
+	messages, total, err := service.ListMessagesPaginated(ctx, MessageFilter{}, Sort{}, 1, 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Len(t, messages, 2)
+
+	messages, _, err = service.ListMessagesPaginated(ctx, MessageFilter{}, Sort{}, 2, 2)
+	require.NoError(t, err)
+	assert.Len(t, messages, 1)
+
+	_, _, err = service.ListMessagesPaginated(ctx, MessageFilter{}, Sort{}, 1<<31, 2)
+	assert.ErrorIs(t, err, ErrInvalidPage)
+	_, _, err = service.ListMessagesPaginated(ctx, MessageFilter{}, Sort{}, 1, 1<<31)
+	assert.ErrorIs(t, err, ErrInvalidPageSize)
+}
+
+func TestMessageService_ListMessagesKeyset(t *testing.T) {
+	service, _, _ := newTestService()
+
+	ctx := context.Background()
+	for _, content := range []string{"first", "second", "third"} {
+		require.NoError(t, service.CreateMessage(ctx, &models.Message{Content: content}))
+	}
+
+	first, err := service.ListMessagesKeyset(ctx, MessageFilter{}, Sort{}, 2, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, first.Messages, 2)
+	assert.Equal(t, "third", first.Messages[0].Content)
+	assert.Nil(t, first.Prev)
+	require.NotNil(t, first.Next)
+
+	second, err := service.ListMessagesKeyset(ctx, MessageFilter{}, Sort{}, 2, first.Next, nil)
+	require.NoError(t, err)
+	require.Len(t, second.Messages, 1)
+	assert.Equal(t, "first", second.Messages[0].Content)
+	assert.Nil(t, second.Next)
+	require.NotNil(t, second.Prev)
+
+	back, err := service.ListMessagesKeyset(ctx, MessageFilter{}, Sort{}, 2, nil, second.Prev)
+	require.NoError(t, err)
+	assert.Equal(t, first.Messages, back.Messages)
+	assert.Nil(t, back.Prev)
+	assert.NotNil(t, back.Next)
+
+	_, err = service.ListMessagesKeyset(ctx, MessageFilter{}, Sort{}, 2, first.Next, second.Prev)
+	assert.ErrorIs(t, err, ErrInvalidPage)
+	_, err = service.ListMessagesKeyset(ctx, MessageFilter{}, Sort{}, 0, nil, nil)
+	assert.ErrorIs(t, err, ErrInvalidPageSize)
+	assert.Equal(t, "page_size", apperr.ToProblem(err).Errors[0].Field)
+}
+
+func TestMessageService_ListMessagesFilterAndSort(t *testing.T) {
+	service, _, _ := newTestService()
+
+	ctx := context.Background()
+	created := map[string]*models.Message{}
+	for _, content := range []string{"alpha", "beta", "alphabet"} {
+		message := &models.Message{Content: content}
+		require.NoError(t, service.CreateMessage(ctx, message))
+		created[content] = message
+	}
+	require.NoError(t, service.UpdateMessage(ctx, &models.Message{ID: created["alpha"].ID, Content: "alpha 2"}))
+
+	contents := func(messages []*models.Message) []string {
+		var out []string
+		for _, message := range messages {
+			out = append(out, message.Content)
+		}
+		return out
+	}
+
+	messages, total, err := service.ListMessagesPaginated(ctx, MessageFilter{ContentPrefix: "alpha"}, Sort{Field: SortUpdatedAt}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, []string{"alpha 2", "alphabet"}, contents(messages))
+
+	messages, _, err = service.ListMessagesPaginated(ctx, MessageFilter{}, Sort{Ascending: true}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alpha 2", "beta", "alphabet"}, contents(messages))
+
+	filter := MessageFilter{
+		IDs:          []uuid.UUID{created["alpha"].ID, created["alphabet"].ID},
+		CreatedAfter: created["alpha"].CreatedAt,
+	}
+	messages, total, err = service.ListMessagesPaginated(ctx, filter, Sort{}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, []string{"alphabet"}, contents(messages))
+
+	// Keyset pages follow the requested order
+	order := Sort{Field: SortUpdatedAt, Ascending: true}
+	page, err := service.ListMessagesKeyset(ctx, MessageFilter{}, order, 2, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"beta", "alphabet"}, contents(page.Messages))
+	page, err = service.ListMessagesKeyset(ctx, MessageFilter{}, order, 2, page.Next, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alpha 2"}, contents(page.Messages))
+	assert.Nil(t, page.Next)
+}
+
+func TestMessageService_Revisions(t *testing.T) {
+	service, store, _ := newTestService()
+	ctx := auth.NewContext(context.Background(), &auth.Claims{UserID: "user-1"})
+
+	message := &models.Message{Content: "First"}
+	require.NoError(t, service.CreateMessage(ctx, message))
+	message.Content = "Second"
+	message.Version = 0
+	require.NoError(t, service.UpdateMessage(ctx, message))
+
+	revisions, total, err := service.ListRevisions(ctx, message.ID, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, int64(2), revisions[0].Revision)
+	assert.Equal(t, "Second", revisions[0].Content)
+	assert.Equal(t, int64(1), revisions[1].Revision)
+	assert.Equal(t, "First", revisions[1].Content)
+	assert.Equal(t, "user-1", revisions[1].Editor)
+
+	first, err := service.GetRevision(ctx, message.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "First", first.Content)
+
+	_, err = service.GetRevision(ctx, message.ID, 3)
+	assert.ErrorIs(t, err, ErrRevisionNotFound)
+
+	_, _, err = service.ListRevisions(ctx, uuid.New(), 1, 10)
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+
+	drainOutbox(t, store)
+
+	// Restoring appends a new revision instead of rewriting history
+	restored, err := service.RestoreRevision(ctx, message.ID, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "First", restored.Content)
+	assert.Equal(t, int64(3), restored.Version)
+
+	latest, err := service.GetRevision(ctx, message.ID, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "First", latest.Content)
+
+	outbox := drainOutbox(t, store)
+	require.Len(t, outbox, 1)
+	assert.Equal(t, events.TypeMessageUpdated, outbox[0].EventType)
+
+	_, err = service.RestoreRevision(ctx, message.ID, 2, 2)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	_, err = service.RestoreRevision(ctx, message.ID, 9, 0)
+	assert.ErrorIs(t, err, ErrRevisionNotFound)
+}
+
+func TestMessageService_RestoreAndPurge(t *testing.T) {
+	service, store, memoryCache := newTestService()
+	ctx := context.Background()
+
+	message := &models.Message{Content: "Test message"}
+	require.NoError(t, service.CreateMessage(ctx, message))
+
+	_, err := service.RestoreMessage(ctx, message.ID)
+	assert.ErrorIs(t, err, ErrMessageNotFound, "live messages are not in the trash")
+
+	require.NoError(t, service.DeleteMessage(ctx, message.ID))
+	drainOutbox(t, store)
+
+	restored, err := service.RestoreMessage(ctx, message.ID)
+	require.NoError(t, err)
+	assert.Nil(t, restored.DeletedAt)
+
+	var cached models.Message
+	require.NoError(t, memoryCache.Get(ctx, message.ID.String(), &cached))
+
+	require.NoError(t, service.PurgeMessage(ctx, message.ID))
+	assert.ErrorIs(t, service.PurgeMessage(ctx, message.ID), ErrMessageNotFound)
+	assert.Error(t, memoryCache.Get(ctx, message.ID.String(), &cached))
+
+	outbox := drainOutbox(t, store)
+	require.Len(t, outbox, 2)
+	assert.Equal(t, events.TypeMessageRestored, outbox[0].EventType)
+	assert.Equal(t, events.TypeMessagePurged, outbox[1].EventType)
+
+	event, err := events.Decode(outbox[1].Payload)
+	require.NoError(t, err)
+	purged, ok := event.(*events.MessagePurged)
+	require.True(t, ok)
+	assert.Equal(t, "Test message", purged.Message.Content)
+}
+
+func TestMessageService_Batch(t *testing.T) {
+	service, store, _ := newTestService()
+	ctx := context.Background()
+
+	messages := []*models.Message{{Content: "first"}, {Content: "second"}}
+	results, err := service.BatchCreateMessages(ctx, messages, true)
+	require.NoError(t, err)
+	assert.Equal(t, []error{nil, nil}, results)
+	assert.NotEqual(t, uuid.Nil, messages[1].ID)
+	assert.Equal(t, int64(1), messages[1].Version)
+
+	revisions, total, err := service.ListRevisions(ctx, messages[0].ID, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "first", revisions[0].Content)
+
+	outbox := drainOutbox(t, store)
+	require.Len(t, outbox, 2)
+	assert.Equal(t, events.TypeMessageCreated, outbox[1].EventType)
+	assert.Equal(t, messages[1].ID, outbox[1].AggregateID)
+
+	// Invalid items and replies to missing messages fail on their own
+	missing := uuid.New()
+	results, err = service.BatchCreateMessages(ctx, []*models.Message{
+		{Content: ""},
+		{Content: "orphan", ParentID: &missing},
+		{Content: "reply", ParentID: &messages[0].ID},
+		{Content: "draft", Status: "gone"},
+	}, false)
+	require.NoError(t, err)
+	assert.ErrorIs(t, results[0], ErrInvalidContent)
+	assert.ErrorIs(t, results[1], ErrParentNotFound)
+	assert.NoError(t, results[2])
+	assert.ErrorIs(t, results[3], ErrInvalidStatus)
+	outbox = drainOutbox(t, store)
+	require.Len(t, outbox, 1)
+	assert.Equal(t, events.TypeMessageCreated, outbox[0].EventType)
+
+	// An atomic batch applies nothing when one item fails
+	results, err = service.BatchCreateMessages(ctx, []*models.Message{
+		{Content: "kept back"},
+		{Content: "orphan", ParentID: &missing},
+	}, true)
+	require.NoError(t, err)
+	assert.ErrorIs(t, results[0], ErrBatchAborted)
+	assert.ErrorIs(t, results[1], ErrParentNotFound)
+	assert.Empty(t, drainOutbox(t, store))
+
+	results, err = service.BatchUpdateMessages(ctx, []*models.Message{
+		{ID: messages[0].ID, Content: "edited"},
+		{ID: missing, Content: "missing"},
+	}, true)
+	require.NoError(t, err)
+	assert.ErrorIs(t, results[0], ErrBatchAborted)
+	assert.ErrorIs(t, results[1], ErrMessageNotFound)
+	assert.Empty(t, drainOutbox(t, store))
+
+	updates := []*models.Message{
+		{ID: messages[0].ID, Content: "edited"},
+		{ID: messages[1].ID, Content: "stale", Version: 7},
+	}
+	results, err = service.BatchUpdateMessages(ctx, updates, false)
+	require.NoError(t, err)
+	assert.NoError(t, results[0])
+	assert.ErrorIs(t, results[1], ErrVersionConflict)
+	assert.Equal(t, int64(2), updates[0].Version)
+
+	got, err := service.GetMessage(ctx, messages[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "edited", got.Content)
+	require.Len(t, drainOutbox(t, store), 1)
+
+	results, err = service.BatchDeleteMessages(ctx, []uuid.UUID{messages[0].ID, missing, messages[0].ID}, false)
+	require.NoError(t, err)
+	assert.NoError(t, results[0])
+	assert.ErrorIs(t, results[1], ErrMessageNotFound)
+	assert.ErrorIs(t, results[2], ErrMessageNotFound, "repeated IDs are deleted once")
+
+	_, err = service.GetMessage(ctx, messages[0].ID)
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+
+	outbox = drainOutbox(t, store)
+	require.Len(t, outbox, 1)
+	event, err := events.Decode(outbox[0].Payload)
+	require.NoError(t, err)
+	deleted, ok := event.(*events.MessageDeleted)
+	require.True(t, ok)
+	assert.Equal(t, "edited", deleted.Message.Content)
+	assert.Nil(t, deleted.Message.DeletedAt)
+
+	_, err = service.BatchDeleteMessages(ctx, nil, false)
+	assert.ErrorIs(t, err, ErrInvalidBatch)
+}
+
+func TestMessageService_Ownership(t *testing.T) {
+	service, _, _ := newTestService()
+	alice := auth.NewContext(context.Background(), &auth.Claims{UserID: "alice"})
+	bob := auth.NewContext(context.Background(), &auth.Claims{UserID: "bob"})
+	moderator := auth.NewContext(context.Background(), &auth.Claims{UserID: "carol", Permissions: []string{PermissionModerate}})
+	anonymous := context.Background()
+
+	message := &models.Message{Content: "by alice", AuthorID: "mallory"}
+	require.NoError(t, service.CreateMessage(alice, message))
+	assert.Equal(t, "alice", message.AuthorID, "the author is the caller")
+
+	unowned := &models.Message{Content: "anonymous"}
+	require.NoError(t, service.CreateMessage(anonymous, unowned))
+	assert.Empty(t, unowned.AuthorID)
+
+	for _, ctx := range []context.Context{bob, anonymous} {
+		err := service.UpdateMessage(ctx, &models.Message{ID: message.ID, Content: "edited"})
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+		assert.ErrorIs(t, service.DeleteMessage(ctx, message.ID), ErrPermissionDenied)
+	}
+	assert.ErrorIs(t, service.DeleteMessage(alice, unowned.ID), ErrPermissionDenied)
+
+	update := &models.Message{ID: message.ID, Content: "moderated"}
+	require.NoError(t, service.UpdateMessage(moderator, update))
+	assert.Equal(t, "alice", update.AuthorID)
+
+	results, err := service.BatchDeleteMessages(bob, []uuid.UUID{message.ID, unowned.ID}, false)
+	require.NoError(t, err)
+	assert.ErrorIs(t, results[0], ErrPermissionDenied)
+	assert.ErrorIs(t, results[1], ErrPermissionDenied)
+
+	mine, total, err := service.ListMessagesPaginated(alice, MessageFilter{AuthorID: "alice"}, Sort{}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, message.ID, mine[0].ID)
+
+	// The trash of each caller only holds their own messages, except for
+	// moderators
+	require.NoError(t, service.DeleteMessage(alice, message.ID))
+	trash := func(ctx context.Context) int64 {
+		_, total, err := service.ListDeletedMessages(ctx, 1, 10)
+		require.NoError(t, err)
+		return total
+	}
+	assert.Equal(t, int64(1), trash(alice))
+	assert.Equal(t, int64(0), trash(bob))
+	assert.Equal(t, int64(0), trash(anonymous))
+	assert.Equal(t, int64(1), trash(moderator))
+
+	_, err = service.RestoreMessage(bob, message.ID)
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+	_, err = service.RestoreMessage(alice, message.ID)
 	assert.NoError(t, err)
-	mockDB.AssertExpectations(t)
+
+	require.NoError(t, service.DeleteMessage(alice, message.ID))
+	_, err = service.RestoreMessage(moderator, message.ID)
+	assert.NoError(t, err)
+}
+
+func TestMessageService_TenantIsolation(t *testing.T) {
+	service, store, _ := newTestService()
+	acme := tenant.NewContext(context.Background(), "acme")
+	globex := tenant.NewContext(context.Background(), "globex")
+
+	message := &models.Message{Content: "acme only"}
+	require.NoError(t, service.CreateMessage(acme, message))
+	require.NoError(t, service.CreateMessage(globex, &models.Message{Content: "globex only"}))
+
+	// Other tenants cannot see or change the message, cached or not
+	_, err := service.GetMessage(globex, message.ID)
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+	err = service.UpdateMessage(globex, &models.Message{ID: message.ID, Content: "taken over"})
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+	_, _, err = service.ListRevisions(globex, message.ID, 1, 10)
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+	require.NoError(t, service.DeleteMessage(globex, message.ID))
+
+	results, total, err := service.SearchMessages(globex, "acme", 1, 10)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	assert.Zero(t, total)
+
+	listed, total, err := service.ListMessagesPaginated(acme, MessageFilter{}, Sort{}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, message.ID, listed[0].ID)
+
+	// Events name the tenant of their message
+	outbox := drainOutbox(t, store)
+	require.Len(t, outbox, 2)
+	envelope, err := events.Parse(outbox[0].Payload)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", envelope.TenantID)
+
+	// The retention job purges the trash of every tenant
+	require.NoError(t, service.DeleteMessage(acme, message.ID))
+	_, total, err = service.ListDeletedMessages(globex, 1, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	purged, err := service.PurgeDeletedMessages(context.Background(), time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	outbox = drainOutbox(t, store)
+	require.Len(t, outbox, 2)
+	envelope, err = events.Parse(outbox[1].Payload)
+	require.NoError(t, err)
+	assert.Equal(t, events.TypeMessagePurged, envelope.Type)
+	assert.Equal(t, "acme", envelope.TenantID)
+}
+
+func TestMessageService_LabelsAndMetadata(t *testing.T) {
+	service, _, _ := newTestService()
+	ctx := context.Background()
+
+	tagged := &models.Message{
+		Content:  "tagged",
+		Labels:   []string{"urgent", "team:ops"},
+		Metadata: map[string]interface{}{"source": "web", "priority": 2},
+	}
+	require.NoError(t, service.CreateMessage(ctx, tagged))
+	require.NoError(t, service.CreateMessage(ctx, &models.Message{Content: "plain", Labels: []string{"urgent"}}))
+
+	got, err := service.GetMessage(ctx, tagged.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"urgent", "team:ops"}, got.Labels)
+	assert.Equal(t, map[string]interface{}{"source": "web", "priority": float64(2)}, got.Metadata)
+
+	// Filters keep the messages having every label and metadata key
+	for _, filter := range []MessageFilter{
+		{Labels: []string{"urgent", "team:ops"}},
+		{MetadataKeys: []string{"source"}},
+		{Labels: []string{"urgent"}, MetadataKeys: []string{"priority", "source"}},
+	} {
+		listed, total, err := service.ListMessagesPaginated(ctx, filter, Sort{}, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total, "%+v", filter)
+		assert.Equal(t, tagged.ID, listed[0].ID)
+	}
+	_, total, err := service.ListMessagesPaginated(ctx, MessageFilter{Labels: []string{"urgent"}}, Sort{}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+
+	// A full update replaces the labels and metadata
+	update := &models.Message{ID: tagged.ID, Content: "retagged", Labels: []string{"done"}}
+	require.NoError(t, service.UpdateMessage(ctx, update))
+	assert.Equal(t, []string{"done"}, update.Labels)
+	assert.Nil(t, update.Metadata)
+
+	// Batch updates keep what they leave out
+	results, err := service.BatchUpdateMessages(ctx, []*models.Message{
+		{ID: tagged.ID, Metadata: map[string]interface{}{"source": "api"}},
+	}, false)
+	require.NoError(t, err)
+	require.NoError(t, results[0])
+	got, err = service.GetMessage(ctx, tagged.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "retagged", got.Content)
+	assert.Equal(t, []string{"done"}, got.Labels)
+	assert.Equal(t, "api", got.Metadata["source"])
+
+	invalid := []*models.Message{
+		{Content: "bad label", Labels: []string{"no spaces"}},
+		{Content: "repeated", Labels: []string{"a", "a"}},
+		{Content: "too long", Labels: []string{strings.Repeat("x", MaxLabelLength+1)}},
+	}
+	for _, message := range invalid {
+		assert.ErrorIs(t, service.CreateMessage(ctx, message), ErrInvalidLabels, message.Content)
+	}
+	huge := &models.Message{Content: "huge", Metadata: map[string]interface{}{"blob": strings.Repeat("x", MaxMetadataSize)}}
+	assert.ErrorIs(t, service.CreateMessage(ctx, huge), ErrInvalidMetadata)
+
+	results, err = service.BatchUpdateMessages(ctx, []*models.Message{
+		{ID: tagged.ID, Labels: []string{"bad label"}},
+	}, false)
+	require.NoError(t, err)
+	assert.ErrorIs(t, results[0], ErrInvalidLabels)
+}
+
+func TestMessageService_MetadataSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"type": "object",
+		"properties": {"source": {"enum": ["web", "api"]}},
+		"required": ["source"]
+	}`), 0o600))
+	schema, err := metadata.LoadSchema(path)
+	require.NoError(t, err)
+
+	service := NewMessageService(NewMemoryStore(), cache.NewMemoryCache(), Options{MetadataSchema: schema})
+	ctx := context.Background()
+
+	message := &models.Message{Content: "valid", Metadata: map[string]interface{}{"source": "web"}}
+	require.NoError(t, service.CreateMessage(ctx, message))
+
+	err = service.CreateMessage(ctx, &models.Message{Content: "invalid", Metadata: map[string]interface{}{"source": "fax"}})
+	assert.ErrorIs(t, err, ErrInvalidMetadata)
+	err = service.CreateMessage(ctx, &models.Message{Content: "missing"})
+	assert.ErrorIs(t, err, ErrInvalidMetadata, "no metadata is the empty object")
+
+	// Updates are checked against the schema too
+	err = service.UpdateMessage(ctx, &models.Message{ID: message.ID, Content: "edited"})
+	assert.ErrorIs(t, err, ErrInvalidMetadata)
+}
+
+func TestMessageService_Threads(t *testing.T) {
+	service, _, _ := newTestService()
+	ctx := context.Background()
+
+	reply := func(parent *models.Message, content string) *models.Message {
+		t.Helper()
+		message := &models.Message{Content: content, ParentID: &parent.ID}
+		require.NoError(t, service.CreateMessage(ctx, message))
+		return message
+	}
+
+	root := &models.Message{Content: "root"}
+	require.NoError(t, service.CreateMessage(ctx, root))
+	assert.Equal(t, root.ID, root.ThreadID)
+	assert.Nil(t, root.ParentID)
+
+	// Read the root so the cache holds it before the replies arrive
+	_, err := service.GetMessage(ctx, root.ID)
+	require.NoError(t, err)
+
+	first := reply(root, "first")
+	second := reply(root, "second")
+	nested := reply(first, "nested")
+	assert.Equal(t, root.ID, nested.ThreadID)
+	assert.Equal(t, first.ID, *nested.ParentID)
+
+	got, err := service.GetMessage(ctx, root.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), got.ReplyCount, "the cached parent is refreshed")
+
+	replies, total, err := service.ListReplies(ctx, root.ID, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, replies, 2)
+	assert.Equal(t, first.ID, replies[0].ID, "oldest first")
+	assert.Equal(t, second.ID, replies[1].ID)
+
+	err = service.CreateMessage(ctx, &models.Message{Content: "orphan", ParentID: &[]uuid.UUID{uuid.New()}[0]})
+	assert.ErrorIs(t, err, ErrParentNotFound)
+
+	// A deleted message with live replies stays as a tombstone, one without
+	// is left out
+	require.NoError(t, service.DeleteMessage(ctx, first.ID))
+	require.NoError(t, service.DeleteMessage(ctx, second.ID))
+	got, err = service.GetMessage(ctx, root.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), got.ReplyCount)
+
+	thread, err := service.GetThread(ctx, root.ID, 0)
+	require.NoError(t, err)
+	assert.False(t, thread.Truncated)
+	assert.Equal(t, root.ID, thread.Root.ID)
+	require.Len(t, thread.Root.Replies, 1)
+	tombstone := thread.Root.Replies[0]
+	assert.Equal(t, first.ID, tombstone.ID)
+	assert.True(t, tombstone.Tombstone)
+	assert.Empty(t, tombstone.Content)
+	require.Len(t, tombstone.Replies, 1)
+	assert.Equal(t, "nested", tombstone.Replies[0].Content)
+
+	// The depth cuts the tree off
+	thread, err = service.GetThread(ctx, root.ID, 1)
+	require.NoError(t, err)
+	assert.True(t, thread.Truncated)
+	require.Len(t, thread.Root.Replies, 1)
+	assert.Empty(t, thread.Root.Replies[0].Replies)
+
+	// Restoring counts the reply again
+	_, err = service.RestoreMessage(ctx, second.ID)
+	require.NoError(t, err)
+	got, err = service.GetMessage(ctx, root.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), got.ReplyCount)
+
+	// Replies to a purged root hang off a tombstone in its place
+	require.NoError(t, service.PurgeMessage(ctx, root.ID))
+	thread, err = service.GetThread(ctx, root.ID, 0)
+	require.NoError(t, err)
+	assert.True(t, thread.Root.Tombstone)
+	assert.Len(t, thread.Root.Replies, 2)
+
+	_, err = service.GetThread(ctx, uuid.New(), 0)
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+}
+
+func TestMessageService_DeleteCascade(t *testing.T) {
+	store := NewMemoryStore()
+	service := NewMessageService(store, cache.NewMemoryCache(), Options{DeletePolicy: DeleteCascade})
+	ctx := context.Background()
+
+	create := func(content string, parent *models.Message) *models.Message {
+		t.Helper()
+		message := &models.Message{Content: content}
+		if parent != nil {
+			message.ParentID = &parent.ID
+		}
+		require.NoError(t, service.CreateMessage(ctx, message))
+		return message
+	}
+
+	root := create("root", nil)
+	child := create("child", root)
+	grandchild := create("grandchild", child)
+	other := create("other", nil)
+
+	// The walk goes through replies that are already deleted
+	require.NoError(t, service.DeleteMessage(ctx, child.ID))
+	_, err := service.GetMessage(ctx, grandchild.ID)
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+
+	_, err = service.RestoreMessage(ctx, child.ID)
+	require.NoError(t, err)
+	_, err = service.GetMessage(ctx, grandchild.ID)
+	assert.ErrorIs(t, err, ErrMessageNotFound, "restoring does not restore the replies")
+
+	drainOutbox(t, store)
+	results, err := service.BatchDeleteMessages(ctx, []uuid.UUID{root.ID}, false)
+	require.NoError(t, err)
+	require.NoError(t, results[0])
+
+	var deleted []string
+	for _, event := range drainOutbox(t, store) {
+		assert.Equal(t, events.TypeMessageDeleted, event.EventType)
+		deleted = append(deleted, event.AggregateID.String())
+	}
+	assert.ElementsMatch(t, []string{root.ID.String(), child.ID.String()}, deleted)
+
+	_, err = service.GetMessage(ctx, other.ID)
+	assert.NoError(t, err, "other threads are left alone")
+}
+
+func TestMessageService_Expiry(t *testing.T) {
+	service, _, memoryCache := newTestService()
+	ctx := context.Background()
+
+	past := time.Now().Add(-time.Minute)
+	err := service.CreateMessage(ctx, &models.Message{Content: "stale", ExpiresAt: &past})
+	assert.ErrorIs(t, err, ErrInvalidExpiry)
+
+	root := &models.Message{Content: "root"}
+	require.NoError(t, service.CreateMessage(ctx, root))
+
+	soon := time.Now().Add(50 * time.Millisecond)
+	notice := &models.Message{Content: "notice", ParentID: &root.ID, ExpiresAt: &soon}
+	require.NoError(t, service.CreateMessage(ctx, notice))
+	reply := &models.Message{Content: "reply", ParentID: &notice.ID}
+	require.NoError(t, service.CreateMessage(ctx, reply))
+
+	got, err := service.GetMessage(ctx, notice.ID)
+	require.NoError(t, err)
+	assert.WithinDuration(t, soon, *got.ExpiresAt, time.Millisecond)
+
+	time.Sleep(60 * time.Millisecond)
+
+	// The cache entry expires with the message
+	var cached models.Message
+	assert.Error(t, memoryCache.Get(ctx, notice.ID.String(), &cached))
+
+	_, err = service.GetMessage(ctx, notice.ID)
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+
+	messages, total, err := service.ListMessagesPaginated(ctx, MessageFilter{}, Sort{}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, messages, 2)
+
+	err = service.UpdateMessage(ctx, &models.Message{ID: notice.ID, Content: "edited"})
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+
+	// An expired message with live replies is a tombstone of its thread
+	thread, err := service.GetThread(ctx, root.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, thread.Root.Replies, 1)
+	assert.True(t, thread.Root.Replies[0].Tombstone)
+	require.Len(t, thread.Root.Replies[0].Replies, 1)
+
+	expired, err := service.ExpireMessages(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	// Restoring clears the expiry that has passed
+	restored, err := service.RestoreMessage(ctx, notice.ID)
+	require.NoError(t, err)
+	assert.Nil(t, restored.ExpiresAt)
+	_, err = service.GetMessage(ctx, notice.ID)
+	assert.NoError(t, err)
+
+	// Clearing the expiry with an update keeps the message
+	later := time.Now().Add(time.Hour)
+	message := &models.Message{Content: "temporary", ExpiresAt: &later}
+	require.NoError(t, service.CreateMessage(ctx, message))
+	updated, err := service.PatchMessage(ctx, message.ID, 0, func(current *models.Message) error {
+		current.ExpiresAt = &time.Time{}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Nil(t, updated.ExpiresAt)
+}
+
+func TestMessageService_Publishing(t *testing.T) {
+	service, store, _ := newTestService()
+	alice := auth.NewContext(context.Background(), &auth.Claims{UserID: "alice"})
+	bob := auth.NewContext(context.Background(), &auth.Claims{UserID: "bob"})
+	moderator := auth.NewContext(context.Background(), &auth.Claims{UserID: "carol", Permissions: []string{PermissionModerate}})
+
+	later := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Minute)
+	for _, message := range []*models.Message{
+		{Content: "no publish time", Status: models.StatusScheduled},
+		{Content: "published later", Status: models.StatusPublished, PublishAt: &later},
+		{Content: "unknown", Status: "archived"},
+	} {
+		assert.ErrorIs(t, service.CreateMessage(alice, message), ErrInvalidStatus)
+	}
+	err := service.CreateMessage(alice, &models.Message{Content: "gone first", PublishAt: &later, ExpiresAt: &later})
+	assert.ErrorIs(t, err, ErrInvalidExpiry)
+
+	public := &models.Message{Content: "public"}
+	require.NoError(t, service.CreateMessage(alice, public))
+	assert.Equal(t, models.StatusPublished, public.Status)
+	require.NotNil(t, public.PublishAt)
+
+	draft := &models.Message{Content: "draft", Status: models.StatusDraft}
+	require.NoError(t, service.CreateMessage(alice, draft))
+	assert.Nil(t, draft.PublishAt)
+
+	scheduled := &models.Message{Content: "scheduled", PublishAt: &later}
+	require.NoError(t, service.CreateMessage(alice, scheduled))
+	assert.Equal(t, models.StatusScheduled, scheduled.Status)
+
+	// A scheduled time that has already come publishes right away
+	overdue := &models.Message{Content: "overdue", Status: models.StatusScheduled, PublishAt: &past}
+	require.NoError(t, service.CreateMessage(alice, overdue))
+	assert.Equal(t, models.StatusPublished, overdue.Status)
+
+	// Only the author sees unpublished messages, moderators included
+	for _, id := range []uuid.UUID{draft.ID, scheduled.ID} {
+		_, err := service.GetMessage(alice, id)
+		assert.NoError(t, err)
+		for _, ctx := range []context.Context{bob, moderator, context.Background()} {
+			_, err := service.GetMessage(ctx, id)
+			assert.ErrorIs(t, err, ErrMessageNotFound)
+		}
+	}
+	_, total, err := service.ListMessagesPaginated(bob, MessageFilter{}, Sort{}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	_, total, err = service.ListMessagesPaginated(alice, MessageFilter{}, Sort{}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+	drafts, _, err := service.ListMessagesPaginated(alice, MessageFilter{Status: models.StatusDraft}, Sort{}, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, drafts, 1)
+	assert.Equal(t, draft.ID, drafts[0].ID)
+
+	// Nobody else can reply to an unpublished message, nor change it
+	err = service.CreateMessage(bob, &models.Message{Content: "reply", ParentID: &draft.ID})
+	assert.ErrorIs(t, err, ErrParentNotFound)
+	err = service.UpdateMessage(moderator, &models.Message{ID: draft.ID, Content: "moderated"})
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+
+	drainOutbox(t, store)
+
+	// Publishing a draft emits message.published after message.updated
+	update := &models.Message{ID: draft.ID, Content: "draft", Status: models.StatusPublished}
+	require.NoError(t, service.UpdateMessage(alice, update))
+	assert.Equal(t, models.StatusPublished, update.Status)
+	require.NotNil(t, update.PublishAt)
+	_, err = service.GetMessage(bob, draft.ID)
+	assert.NoError(t, err)
+
+	outbox := drainOutbox(t, store)
+	require.Len(t, outbox, 2)
+	assert.Equal(t, events.TypeMessageUpdated, outbox[0].EventType)
+	assert.Equal(t, events.TypeMessagePublished, outbox[1].EventType)
+
+	// Published messages stay published
+	err = service.UpdateMessage(alice, &models.Message{ID: draft.ID, Content: "draft", Status: models.StatusDraft})
+	assert.ErrorIs(t, err, ErrInvalidStatus)
+
+	// Other edits keep the status and publish time
+	update = &models.Message{ID: scheduled.ID, Content: "rescheduled", PublishAt: scheduled.PublishAt}
+	require.NoError(t, service.UpdateMessage(alice, update))
+	assert.Equal(t, models.StatusScheduled, update.Status)
+	outbox = drainOutbox(t, store)
+	require.Len(t, outbox, 1)
+	assert.Equal(t, events.TypeMessageUpdated, outbox[0].EventType)
+
+	// The scheduler publishes due messages of every tenant once
+	soon := time.Now().Add(20 * time.Millisecond)
+	due := &models.Message{Content: "due", PublishAt: &soon}
+	require.NoError(t, service.CreateMessage(alice, due))
+	drainOutbox(t, store)
+
+	published, err := service.PublishMessages(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Zero(t, published)
+
+	time.Sleep(30 * time.Millisecond)
+	published, err = service.PublishMessages(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+	got, err := service.GetMessage(bob, due.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusPublished, got.Status)
+	assert.Equal(t, due.Version+1, got.Version, "publishing changes the ETag")
+	revision, err := service.GetRevision(bob, due.ID, got.Version)
+	require.NoError(t, err)
+	assert.Equal(t, "due", revision.Content)
+
+	outbox = drainOutbox(t, store)
+	require.Len(t, outbox, 1)
+	assert.Equal(t, events.TypeMessagePublished, outbox[0].EventType)
+	assert.Equal(t, due.ID, outbox[0].AggregateID)
+
+	published, err = service.PublishMessages(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Zero(t, published)
+}
+
+func TestMessageService_PublishingThreads(t *testing.T) {
+	service, _, _ := newTestService()
+	alice := auth.NewContext(context.Background(), &auth.Claims{UserID: "alice"})
+	bob := auth.NewContext(context.Background(), &auth.Claims{UserID: "bob"})
+
+	root := &models.Message{Content: "root"}
+	require.NoError(t, service.CreateMessage(bob, root))
+	draft := &models.Message{Content: "draft", ParentID: &root.ID, Status: models.StatusDraft}
+	require.NoError(t, service.CreateMessage(alice, draft))
+	reply := &models.Message{Content: "reply to the draft", ParentID: &draft.ID}
+	require.NoError(t, service.CreateMessage(alice, reply))
+
+	// The author sees the draft in the thread
+	thread, err := service.GetThread(alice, root.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, thread.Root.Replies, 1)
+	assert.False(t, thread.Root.Replies[0].Tombstone)
+	assert.Equal(t, "draft", thread.Root.Replies[0].Content)
+
+	// Others only see a tombstone above the live reply
+	thread, err = service.GetThread(bob, root.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, thread.Root.Replies, 1)
+	assert.True(t, thread.Root.Replies[0].Tombstone)
+	assert.Empty(t, thread.Root.Replies[0].Content)
+	require.Len(t, thread.Root.Replies[0].Replies, 1)
+}
+
+func TestMessageService_Attachments(t *testing.T) {
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	store := NewMemoryStore()
+	service := NewMessageService(store, cache.NewMemoryCache(), Options{
+		Blobs:             blobs,
+		MaxAttachmentSize: 1 << 10,
+		AttachmentTypes:   []string{"text/plain", "image/*"},
+	})
+	alice := auth.NewContext(context.Background(), &auth.Claims{UserID: "alice"})
+	bob := auth.NewContext(context.Background(), &auth.Claims{UserID: "bob"})
+
+	message := &models.Message{Content: "with files"}
+	require.NoError(t, service.CreateMessage(alice, message))
+
+	upload := func(ctx context.Context, filename string, content []byte) (*models.Attachment, error) {
+		return service.AddAttachment(ctx, message.ID, Upload{
+			Filename: filename,
+			Size:     int64(len(content)),
+			Content:  bytes.NewReader(content),
+		})
+	}
+
+	attachment, err := upload(alice, "../notes\n.txt", []byte("hello, attachments"))
+	require.NoError(t, err)
+	assert.Equal(t, "notes.txt", attachment.Filename)
+	assert.Equal(t, "text/plain; charset=utf-8", attachment.ContentType)
+	assert.Equal(t, int64(18), attachment.Size)
+	assert.Equal(t, "alice", attachment.UploaderID)
+	sum := sha256.Sum256([]byte("hello, attachments"))
+	assert.Equal(t, hex.EncodeToString(sum[:]), attachment.Checksum)
+
+	// A GIF header is enough to be sniffed as an image, whatever its name
+	_, err = upload(alice, "image.txt", []byte("GIF89a\x01\x00\x01\x00"))
+	require.NoError(t, err)
+
+	listed, err := service.ListAttachments(bob, message.ID)
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	assert.Equal(t, attachment.ID, listed[0].ID)
+	assert.Equal(t, "image/gif", listed[1].ContentType)
+
+	found, content, err := service.OpenAttachment(bob, message.ID, attachment.ID)
+	require.NoError(t, err)
+	data, err := io.ReadAll(content)
+	require.NoError(t, err)
+	require.NoError(t, content.Close())
+	assert.Equal(t, "hello, attachments", string(data))
+	assert.Equal(t, attachment.Checksum, found.Checksum)
+
+	_, _, err = service.OpenAttachment(bob, message.ID, uuid.New())
+	assert.ErrorIs(t, err, ErrAttachmentNotFound)
+
+	// Only those who may change the message may attach to it
+	_, err = upload(bob, "bob.txt", []byte("not yours"))
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+
+	_, err = upload(alice, "big.txt", bytes.Repeat([]byte("a"), 1<<10+1))
+	assert.ErrorIs(t, err, ErrAttachmentTooLarge)
+	_, err = upload(alice, "doc.pdf", []byte("%PDF-1.7\n"))
+	assert.ErrorIs(t, err, ErrAttachmentType)
+
+	// Purging the message removes its attachments and their contents
+	require.NoError(t, service.PurgeMessage(alice, message.ID))
+	_, err = blobs.Open(alice, attachment.StorageKey)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	remaining, err := store.ListAttachments(alice, message.ID)
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+}
+
+func TestMessageService_Moderation(t *testing.T) {
+	store := NewMemoryStore()
+	service := NewMessageService(store, cache.NewMemoryCache(), Options{
+		Moderation: moderation.NewChain(moderation.NewMetrics(prometheus.NewRegistry()),
+			moderation.NewWordFilter([]string{"darn"}, moderation.ActionMask),
+			moderation.NewPatternFilter([]*regexp.Regexp{regexp.MustCompile(`(?i)casino`)}, moderation.ActionFlag),
+			moderation.NewLinkFilter(0, moderation.ActionReject),
+		),
+	})
+	ctx := context.Background()
+
+	// Masked content is stored as masked
+	message := &models.Message{Content: "darn it"}
+	require.NoError(t, service.CreateMessage(ctx, message))
+	assert.Equal(t, "**** it", message.Content)
+	outbox := drainOutbox(t, store)
+	require.Len(t, outbox, 1)
+	assert.Equal(t, events.TypeMessageCreated, outbox[0].EventType)
+
+	// Rejected content is not written at all
+	err := service.CreateMessage(ctx, &models.Message{Content: "see https://spam.example"})
+	var rejection *moderation.RejectionError
+	require.ErrorAs(t, err, &rejection)
+	require.Len(t, rejection.Violations, 1)
+	assert.Equal(t, "links", rejection.Violations[0].Filter)
+	assert.Empty(t, drainOutbox(t, store))
+
+	// Flagged messages are written and announced for review
+	updated := &models.Message{ID: message.ID, Content: "darn casino"}
+	require.NoError(t, service.UpdateMessage(ctx, updated))
+	assert.Equal(t, "**** casino", updated.Content)
+	outbox = drainOutbox(t, store)
+	require.Len(t, outbox, 2)
+	assert.Equal(t, events.TypeMessageUpdated, outbox[0].EventType)
+	event, err := events.Decode(outbox[1].Payload)
+	require.NoError(t, err)
+	flagged, ok := event.(*events.MessageFlagged)
+	require.True(t, ok)
+	assert.Equal(t, "**** casino", flagged.Message.Content)
+	require.Len(t, flagged.Violations, 2)
+	assert.Equal(t, "patterns", flagged.Violations[1].Filter)
+
+	// Updates that leave the content alone are not reviewed again
+	require.NoError(t, service.UpdateMessage(ctx, &models.Message{ID: message.ID, Content: updated.Content, Labels: []string{"ok"}}))
+	outbox = drainOutbox(t, store)
+	require.Len(t, outbox, 1)
+
+	// Each rejected item of a batch fails on its own
+	results, err := service.BatchUpdateMessages(ctx, []*models.Message{
+		{ID: message.ID, Content: "www.spam.example"},
+	}, false)
+	require.NoError(t, err)
+	assert.ErrorIs(t, results[0], moderation.ErrRejected)
+
+	messages := []*models.Message{{Content: "fine"}, {Content: "casino"}, {Content: "www.spam.example"}}
+	results, err = service.BatchCreateMessages(ctx, messages, false)
+	require.NoError(t, err)
+	assert.ErrorIs(t, results[2], moderation.ErrRejected)
+	outbox = drainOutbox(t, store)
+	require.Len(t, outbox, 3)
+	assert.Equal(t, events.TypeMessageFlagged, outbox[2].EventType)
+	assert.Equal(t, messages[1].ID, outbox[2].AggregateID)
 }
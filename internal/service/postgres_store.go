@@ -0,0 +1,828 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go-boilerplate/internal/db"
+	"go-boilerplate/internal/models"
+	"go-boilerplate/internal/search"
+	"go-boilerplate/internal/tenant"
+)
+
+// PostgresStore is a MessageStore and OutboxStore backed by PostgreSQL
+// through the sqlc-generated queries. Every query is confined to the tenant
+// in its context, on top of the row-level security policies.
+type PostgresStore struct {
+	queries *db.Queries
+	pool    *pgxpool.Pool
+	tx      pgx.Tx // set when the store is bound to a transaction
+}
+
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{
+		queries: db.New(pool),
+		pool:    pool,
+	}
+}
+
+func (s *PostgresStore) CreateMessage(ctx context.Context, message *models.Message) error {
+	metadata, err := encodeMetadata(message.Metadata)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.queries.CreateMessage(ctx, db.CreateMessageParams{
+		Content:   message.Content,
+		AuthorID:  pgtype.Text{String: message.AuthorID, Valid: message.AuthorID != ""},
+		TenantID:  tenant.FromContext(ctx),
+		Labels:    encodeLabels(message.Labels),
+		Metadata:  metadata,
+		ParentID:  encodeUUID(message.ParentID),
+		ThreadID:  encodeThreadID(message.ThreadID),
+		ExpiresAt: encodeTime(message.ExpiresAt),
+		Status:    message.Status,
+		PublishAt: encodeTime(message.PublishAt),
+	})
+	if err != nil {
+		return translateError(err)
+	}
+
+	*message = *toModel(result)
+	return nil
+}
+
+func (s *PostgresStore) CreateMessages(ctx context.Context, messages []*models.Message) error {
+	params := make([]db.CreateMessagesParams, len(messages))
+	for i, message := range messages {
+		metadata, err := encodeMetadata(message.Metadata)
+		if err != nil {
+			return err
+		}
+		params[i] = db.CreateMessagesParams{
+			Content:   message.Content,
+			AuthorID:  pgtype.Text{String: message.AuthorID, Valid: message.AuthorID != ""},
+			TenantID:  tenant.FromContext(ctx),
+			Labels:    encodeLabels(message.Labels),
+			Metadata:  metadata,
+			ParentID:  encodeUUID(message.ParentID),
+			ThreadID:  encodeThreadID(message.ThreadID),
+			ExpiresAt: encodeTime(message.ExpiresAt),
+			Status:    message.Status,
+			PublishAt: encodeTime(message.PublishAt),
+		}
+	}
+
+	var batchErr error
+	s.queries.CreateMessages(ctx, params).QueryRow(func(i int, result db.Message, err error) {
+		if err != nil {
+			if batchErr == nil {
+				batchErr = err
+			}
+			return
+		}
+		*messages[i] = *toModel(result)
+	})
+
+	return translateError(batchErr)
+}
+
+func (s *PostgresStore) GetMessage(ctx context.Context, id uuid.UUID) (*models.Message, error) {
+	result, err := s.queries.GetMessage(ctx, db.GetMessageParams{
+		ID:       id,
+		TenantID: tenant.FromContext(ctx),
+		Viewer:   viewer(ctx),
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return toModel(result), nil
+}
+
+func (s *PostgresStore) GetMessageForUpdate(ctx context.Context, id uuid.UUID) (*models.Message, error) {
+	result, err := s.queries.GetMessageForUpdate(ctx, db.GetMessageForUpdateParams{
+		ID:       id,
+		TenantID: tenant.FromContext(ctx),
+		Viewer:   viewer(ctx),
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return toModel(result), nil
+}
+
+func (s *PostgresStore) UpdateMessage(ctx context.Context, message *models.Message) error {
+	metadata, err := encodeMetadata(message.Metadata)
+	if err != nil {
+		return err
+	}
+
+	params := db.UpdateMessageParams{
+		ID:        message.ID,
+		Content:   message.Content,
+		TenantID:  tenant.FromContext(ctx),
+		Labels:    encodeLabels(message.Labels),
+		Metadata:  metadata,
+		ExpiresAt: encodeTime(message.ExpiresAt),
+		Status:    message.Status,
+		PublishAt: encodeTime(message.PublishAt),
+	}
+	if message.Version != 0 {
+		params.ExpectedVersion = pgtype.Int8{Int64: message.Version, Valid: true}
+	}
+
+	result, err := s.queries.UpdateMessage(ctx, params)
+	if errors.Is(err, pgx.ErrNoRows) && params.ExpectedVersion.Valid {
+		// Tell a stale version apart from a missing message
+		if _, getErr := s.GetMessage(ctx, message.ID); getErr == nil {
+			return ErrVersionConflict
+		}
+	}
+	if err != nil {
+		return translateError(err)
+	}
+
+	*message = *toModel(result)
+	return nil
+}
+
+func (s *PostgresStore) DeleteMessage(ctx context.Context, id uuid.UUID) error {
+	err := s.queries.DeleteMessage(ctx, db.DeleteMessageParams{
+		ID:       id,
+		TenantID: tenant.FromContext(ctx),
+	})
+	return translateError(err)
+}
+
+func (s *PostgresStore) DeleteMessages(ctx context.Context, ids []uuid.UUID) ([]*models.Message, error) {
+	results, err := s.queries.DeleteMessages(ctx, db.DeleteMessagesParams{
+		Ids:      ids,
+		TenantID: tenant.FromContext(ctx),
+		Viewer:   viewer(ctx),
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return toModels(results), nil
+}
+
+func (s *PostgresStore) DeleteReplies(ctx context.Context, parentIDs []uuid.UUID) ([]*models.Message, error) {
+	results, err := s.queries.DeleteReplies(ctx, db.DeleteRepliesParams{
+		Ids:      parentIDs,
+		TenantID: tenant.FromContext(ctx),
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return toModels(results), nil
+}
+
+func (s *PostgresStore) ListMessages(ctx context.Context, opts ListOptions) ([]*models.Message, error) {
+	params := db.FilterMessagesParams{
+		TenantID:   tenant.FromContext(ctx),
+		Viewer:     callerID(ctx),
+		Filter:     toFilter(opts.Filter),
+		SortColumn: string(opts.Sort.field()),
+		Descending: !opts.Sort.Ascending,
+		Limit:      opts.Limit,
+		Offset:     opts.Offset,
+	}
+	switch {
+	case opts.After != nil:
+		params.After = &db.MessagePosition{Value: opts.After.At, ID: opts.After.ID}
+		params.Offset = 0
+	case opts.Before != nil:
+		// Walk away from the position in the opposite direction
+		params.After = &db.MessagePosition{Value: opts.Before.At, ID: opts.Before.ID}
+		params.Descending = !params.Descending
+		params.Offset = 0
+	}
+
+	results, err := s.queries.FilterMessages(ctx, params)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	messages := toModels(results)
+	if opts.Before != nil {
+		reverse(messages)
+	}
+	return messages, nil
+}
+
+func (s *PostgresStore) CountMessages(ctx context.Context, filter MessageFilter) (int64, error) {
+	count, err := s.queries.CountFilteredMessages(ctx, tenant.FromContext(ctx), callerID(ctx), toFilter(filter))
+	return count, translateError(err)
+}
+
+func (s *PostgresStore) ListThread(ctx context.Context, threadID uuid.UUID, limit int32) ([]*models.Message, error) {
+	results, err := s.queries.ListThreadMessages(ctx, db.ListThreadMessagesParams{
+		ThreadID: threadID,
+		TenantID: tenant.FromContext(ctx),
+		Limit:    limit,
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return toModels(results), nil
+}
+
+func (s *PostgresStore) SearchMessages(ctx context.Context, query search.Query, opts ListOptions) ([]*models.SearchResult, error) {
+	results, err := s.queries.SearchMessages(ctx, db.SearchMessagesParams{
+		Query:    query.TSQuery(),
+		TenantID: tenant.FromContext(ctx),
+		Viewer:   viewer(ctx),
+		Limit:    opts.Limit,
+		Offset:   opts.Offset,
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	matches := make([]*models.SearchResult, len(results))
+	for i, result := range results {
+		message := toModel(db.Message{
+			ID:         result.ID,
+			Content:    result.Content,
+			CreatedAt:  result.CreatedAt,
+			UpdatedAt:  result.UpdatedAt,
+			Version:    result.Version,
+			DeletedAt:  result.DeletedAt,
+			AuthorID:   result.AuthorID,
+			TenantID:   result.TenantID,
+			Labels:     result.Labels,
+			Metadata:   result.Metadata,
+			ParentID:   result.ParentID,
+			ThreadID:   result.ThreadID,
+			ReplyCount: result.ReplyCount,
+			ExpiresAt:  result.ExpiresAt,
+			Status:     result.Status,
+			PublishAt:  result.PublishAt,
+		})
+		matches[i] = &models.SearchResult{
+			Message: *message,
+			Rank:    result.Rank,
+			Snippet: result.Snippet,
+		}
+	}
+
+	return matches, nil
+}
+
+func (s *PostgresStore) CountSearchResults(ctx context.Context, query search.Query) (int64, error) {
+	count, err := s.queries.CountSearchMessages(ctx, db.CountSearchMessagesParams{
+		TenantID: tenant.FromContext(ctx),
+		Viewer:   viewer(ctx),
+		Query:    query.TSQuery(),
+	})
+	return count, translateError(err)
+}
+
+func (s *PostgresStore) ListDeletedMessages(ctx context.Context, opts ListOptions, everyAuthor bool) ([]*models.Message, error) {
+	results, err := s.queries.ListDeletedMessages(ctx, db.ListDeletedMessagesParams{
+		TenantID:    tenant.FromContext(ctx),
+		Limit:       opts.Limit,
+		Offset:      opts.Offset,
+		Viewer:      viewer(ctx),
+		EveryAuthor: everyAuthor,
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return toModels(results), nil
+}
+
+func (s *PostgresStore) CountDeletedMessages(ctx context.Context, everyAuthor bool) (int64, error) {
+	count, err := s.queries.CountDeletedMessages(ctx, db.CountDeletedMessagesParams{
+		TenantID:    tenant.FromContext(ctx),
+		Viewer:      viewer(ctx),
+		EveryAuthor: everyAuthor,
+	})
+	return count, translateError(err)
+}
+
+func (s *PostgresStore) RestoreMessage(ctx context.Context, id uuid.UUID) (*models.Message, error) {
+	result, err := s.queries.RestoreMessage(ctx, db.RestoreMessageParams{
+		ID:       id,
+		TenantID: tenant.FromContext(ctx),
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return toModel(result), nil
+}
+
+func (s *PostgresStore) PurgeMessage(ctx context.Context, id uuid.UUID) (*models.Message, error) {
+	result, err := s.queries.PurgeMessage(ctx, db.PurgeMessageParams{
+		ID:       id,
+		TenantID: tenant.FromContext(ctx),
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return toModel(result), nil
+}
+
+func (s *PostgresStore) PurgeDeletedMessages(ctx context.Context, deletedBefore time.Time, limit int32) ([]*models.Message, error) {
+	results, err := s.queries.PurgeDeletedMessages(ctx, db.PurgeDeletedMessagesParams{
+		DeletedAt: pgtype.Timestamptz{Time: deletedBefore, Valid: true},
+		Limit:     limit,
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return toModels(results), nil
+}
+
+func (s *PostgresStore) ExpireMessages(ctx context.Context, expiredBy time.Time, limit int32) ([]*models.Message, error) {
+	results, err := s.queries.ExpireMessages(ctx, db.ExpireMessagesParams{
+		ExpiresAt: pgtype.Timestamptz{Time: expiredBy, Valid: true},
+		Limit:     limit,
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return toModels(results), nil
+}
+
+func (s *PostgresStore) PublishMessages(ctx context.Context, publishedBy time.Time, limit int32) ([]*models.Message, error) {
+	results, err := s.queries.PublishMessages(ctx, db.PublishMessagesParams{
+		PublishAt: pgtype.Timestamptz{Time: publishedBy, Valid: true},
+		Limit:     limit,
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return toModels(results), nil
+}
+
+func (s *PostgresStore) CreateRevision(ctx context.Context, revision *models.MessageRevision) error {
+	result, err := s.queries.InsertMessageRevision(ctx, db.InsertMessageRevisionParams{
+		MessageID: revision.MessageID,
+		Revision:  revision.Revision,
+		Content:   revision.Content,
+		Editor:    pgtype.Text{String: revision.Editor, Valid: revision.Editor != ""},
+		TenantID:  tenant.FromContext(ctx),
+	})
+	if err != nil {
+		return translateError(err)
+	}
+
+	*revision = *toRevision(result)
+	return nil
+}
+
+func (s *PostgresStore) CreateRevisions(ctx context.Context, revisions []*models.MessageRevision) error {
+	params := make([]db.InsertMessageRevisionsParams, len(revisions))
+	for i, revision := range revisions {
+		params[i] = db.InsertMessageRevisionsParams{
+			MessageID: revision.MessageID,
+			Revision:  revision.Revision,
+			Content:   revision.Content,
+			Editor:    pgtype.Text{String: revision.Editor, Valid: revision.Editor != ""},
+			TenantID:  tenant.FromContext(ctx),
+		}
+	}
+
+	_, err := s.queries.InsertMessageRevisions(ctx, params)
+	return translateError(err)
+}
+
+func (s *PostgresStore) GetRevision(ctx context.Context, messageID uuid.UUID, revision int64) (*models.MessageRevision, error) {
+	result, err := s.queries.GetMessageRevision(ctx, db.GetMessageRevisionParams{
+		MessageID: messageID,
+		Revision:  revision,
+		TenantID:  tenant.FromContext(ctx),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return toRevision(result), nil
+}
+
+func (s *PostgresStore) ListRevisions(ctx context.Context, messageID uuid.UUID, opts ListOptions) ([]*models.MessageRevision, error) {
+	results, err := s.queries.ListMessageRevisions(ctx, db.ListMessageRevisionsParams{
+		MessageID: messageID,
+		TenantID:  tenant.FromContext(ctx),
+		Limit:     opts.Limit,
+		Offset:    opts.Offset,
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	revisions := make([]*models.MessageRevision, len(results))
+	for i, result := range results {
+		revisions[i] = toRevision(result)
+	}
+
+	return revisions, nil
+}
+
+func (s *PostgresStore) CountRevisions(ctx context.Context, messageID uuid.UUID) (int64, error) {
+	count, err := s.queries.CountMessageRevisions(ctx, db.CountMessageRevisionsParams{
+		MessageID: messageID,
+		TenantID:  tenant.FromContext(ctx),
+	})
+	return count, translateError(err)
+}
+
+func (s *PostgresStore) CreateAttachment(ctx context.Context, attachment *models.Attachment) error {
+	result, err := s.queries.CreateAttachment(ctx, db.CreateAttachmentParams{
+		ID:          attachment.ID,
+		MessageID:   attachment.MessageID,
+		TenantID:    tenant.FromContext(ctx),
+		Filename:    attachment.Filename,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		Checksum:    attachment.Checksum,
+		StorageKey:  attachment.StorageKey,
+		UploaderID:  pgtype.Text{String: attachment.UploaderID, Valid: attachment.UploaderID != ""},
+	})
+	if err != nil {
+		return translateError(err)
+	}
+
+	*attachment = *toAttachment(result)
+	return nil
+}
+
+func (s *PostgresStore) GetAttachment(ctx context.Context, messageID, id uuid.UUID) (*models.Attachment, error) {
+	result, err := s.queries.GetAttachment(ctx, db.GetAttachmentParams{
+		ID:        id,
+		MessageID: messageID,
+		TenantID:  tenant.FromContext(ctx),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return toAttachment(result), nil
+}
+
+func (s *PostgresStore) ListAttachments(ctx context.Context, messageID uuid.UUID) ([]*models.Attachment, error) {
+	results, err := s.queries.ListAttachments(ctx, db.ListAttachmentsParams{
+		MessageID: messageID,
+		TenantID:  tenant.FromContext(ctx),
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return toAttachments(results), nil
+}
+
+func (s *PostgresStore) PurgeAttachments(ctx context.Context, messageIDs []uuid.UUID) ([]*models.Attachment, error) {
+	results, err := s.queries.PurgeAttachments(ctx, db.PurgeAttachmentsParams{
+		Ids:      messageIDs,
+		TenantID: tenant.FromContext(ctx),
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return toAttachments(results), nil
+}
+
+func (s *PostgresStore) EnqueueEvent(ctx context.Context, event *models.OutboxEvent) error {
+	result, err := s.queries.InsertOutboxEvent(ctx, db.InsertOutboxEventParams{
+		AggregateID: event.AggregateID,
+		EventType:   event.EventType,
+		Payload:     event.Payload,
+	})
+	if err != nil {
+		return translateError(err)
+	}
+
+	*event = *toOutboxEvent(result)
+	return nil
+}
+
+func (s *PostgresStore) EnqueueEvents(ctx context.Context, events []*models.OutboxEvent) error {
+	params := make([]db.InsertOutboxEventsParams, len(events))
+	for i, event := range events {
+		params[i] = db.InsertOutboxEventsParams{
+			AggregateID: event.AggregateID,
+			EventType:   event.EventType,
+			Payload:     event.Payload,
+		}
+	}
+
+	_, err := s.queries.InsertOutboxEvents(ctx, params)
+	return translateError(err)
+}
+
+func (s *PostgresStore) WithTx(ctx context.Context, fn func(tx MessageStore) error) error {
+	// Join the surrounding transaction rather than nesting a new one
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		return fn(&PostgresStore{
+			queries: s.queries.WithTx(tx),
+			pool:    s.pool,
+			tx:      tx,
+		})
+	})
+	return translateError(err)
+}
+
+func (s *PostgresStore) DispatchOutbox(ctx context.Context, limit int32, publish func(*models.OutboxEvent) error) (int, error) {
+	var dispatched int
+	var publishErr error
+
+	// The advisory lock is held until the transaction ends, so only one
+	// relay publishes at a time and events leave in insertion order
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		queries := s.queries.WithTx(tx)
+
+		locked, err := queries.TryLockOutbox(ctx)
+		if err != nil {
+			return err
+		}
+		if !locked {
+			return ErrOutboxLocked
+		}
+
+		events, err := queries.ListPendingOutboxEvents(ctx, limit)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if err := publish(toOutboxEvent(event)); err != nil {
+				publishErr = err
+				return queries.RecordOutboxEventFailure(ctx, db.RecordOutboxEventFailureParams{
+					ID:        event.ID,
+					LastError: pgtype.Text{String: err.Error(), Valid: true},
+				})
+			}
+
+			if err := queries.MarkOutboxEventDispatched(ctx, event.ID); err != nil {
+				return err
+			}
+			dispatched++
+		}
+
+		return nil
+	})
+	if err != nil {
+		// Nothing was marked dispatched; the events will be published again
+		return 0, translateError(err)
+	}
+
+	return dispatched, publishErr
+}
+
+func (s *PostgresStore) PurgeOutbox(ctx context.Context, dispatchedBefore time.Time) (int64, error) {
+	purged, err := s.queries.PurgeDispatchedOutboxEvents(ctx, pgtype.Timestamptz{Time: dispatchedBefore, Valid: true})
+	return purged, translateError(err)
+}
+
+func (s *PostgresStore) OutboxBacklog(ctx context.Context) (OutboxBacklog, error) {
+	result, err := s.queries.GetOutboxBacklog(ctx)
+	if err != nil {
+		return OutboxBacklog{}, translateError(err)
+	}
+
+	return OutboxBacklog{
+		Pending:         result.Pending,
+		OldestCreatedAt: result.OldestCreatedAt,
+	}, nil
+}
+
+func (s *PostgresStore) Ping(ctx context.Context) error {
+	return translateError(s.pool.Ping(ctx))
+}
+
+func toModel(result db.Message) *models.Message {
+	message := &models.Message{
+		ID:         result.ID,
+		Content:    result.Content,
+		AuthorID:   result.AuthorID.String,
+		TenantID:   result.TenantID,
+		ThreadID:   result.ThreadID,
+		ReplyCount: result.ReplyCount,
+		Version:    result.Version,
+		CreatedAt:  result.CreatedAt.Time,
+		UpdatedAt:  result.UpdatedAt.Time,
+		Status:     result.Status,
+	}
+	if result.ParentID.Valid {
+		parentID := uuid.UUID(result.ParentID.Bytes)
+		message.ParentID = &parentID
+	}
+	if len(result.Labels) > 0 {
+		message.Labels = result.Labels
+	}
+	if result.DeletedAt.Valid {
+		deletedAt := result.DeletedAt.Time
+		message.DeletedAt = &deletedAt
+	}
+	if result.ExpiresAt.Valid {
+		expiresAt := result.ExpiresAt.Time
+		message.ExpiresAt = &expiresAt
+	}
+	if result.PublishAt.Valid {
+		publishAt := result.PublishAt.Time
+		message.PublishAt = &publishAt
+	}
+	// Empty metadata is left nil, like empty labels
+	if err := json.Unmarshal(result.Metadata, &message.Metadata); err != nil || len(message.Metadata) == 0 {
+		message.Metadata = nil
+	}
+	return message
+}
+
+// encodeLabels returns labels as stored in the labels column, which is
+// never NULL.
+func encodeLabels(labels []string) []string {
+	if labels == nil {
+		return []string{}
+	}
+	return labels
+}
+
+// encodeMetadata returns metadata as stored in the metadata column, which
+// holds an object and is never NULL.
+func encodeMetadata(metadata map[string]interface{}) ([]byte, error) {
+	if metadata == nil {
+		return []byte("{}"), nil
+	}
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	return encoded, nil
+}
+
+// encodeUUID returns id as a nullable uuid column.
+func encodeUUID(id *uuid.UUID) pgtype.UUID {
+	if id == nil {
+		return pgtype.UUID{}
+	}
+	return pgtype.UUID{Bytes: *id, Valid: true}
+}
+
+// encodeThreadID returns the thread of a new message, NULL for one that
+// starts its own thread; the database then uses the message's ID.
+func encodeThreadID(threadID uuid.UUID) pgtype.UUID {
+	if threadID == uuid.Nil {
+		return pgtype.UUID{}
+	}
+	return pgtype.UUID{Bytes: threadID, Valid: true}
+}
+
+// encodeTime returns t as a nullable timestamptz column.
+func encodeTime(t *time.Time) pgtype.Timestamptz {
+	if t == nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: *t, Valid: true}
+}
+
+// viewer returns the caller in ctx as the viewer of the queries that only
+// show unpublished messages to their author, NULL for anonymous callers.
+func viewer(ctx context.Context) pgtype.Text {
+	id := callerID(ctx)
+	return pgtype.Text{String: id, Valid: id != ""}
+}
+
+func toModels(results []db.Message) []*models.Message {
+	messages := make([]*models.Message, len(results))
+	for i, result := range results {
+		messages[i] = toModel(result)
+	}
+	return messages
+}
+
+func toFilter(filter MessageFilter) db.MessageFilter {
+	return db.MessageFilter{
+		CreatedAfter:  filter.CreatedAfter,
+		CreatedBefore: filter.CreatedBefore,
+		UpdatedAfter:  filter.UpdatedAfter,
+		UpdatedBefore: filter.UpdatedBefore,
+		IDs:           filter.IDs,
+		ContentPrefix: filter.ContentPrefix,
+		AuthorID:      filter.AuthorID,
+		Labels:        filter.Labels,
+		MetadataKeys:  filter.MetadataKeys,
+		ParentID:      filter.ParentID,
+		Status:        filter.Status,
+	}
+}
+
+func reverse(messages []*models.Message) {
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+}
+
+func toRevision(result db.MessageRevision) *models.MessageRevision {
+	return &models.MessageRevision{
+		MessageID: result.MessageID,
+		Revision:  result.Revision,
+		Content:   result.Content,
+		Editor:    result.Editor.String,
+		CreatedAt: result.CreatedAt,
+		TenantID:  result.TenantID,
+	}
+}
+
+func toAttachment(result db.Attachment) *models.Attachment {
+	return &models.Attachment{
+		ID:          result.ID,
+		MessageID:   result.MessageID,
+		Filename:    result.Filename,
+		ContentType: result.ContentType,
+		Size:        result.Size,
+		Checksum:    result.Checksum,
+		UploaderID:  result.UploaderID.String,
+		StorageKey:  result.StorageKey,
+		TenantID:    result.TenantID,
+		CreatedAt:   result.CreatedAt,
+	}
+}
+
+func toAttachments(results []db.Attachment) []*models.Attachment {
+	attachments := make([]*models.Attachment, len(results))
+	for i, result := range results {
+		attachments[i] = toAttachment(result)
+	}
+	return attachments
+}
+
+func toOutboxEvent(result db.OutboxEvent) *models.OutboxEvent {
+	event := &models.OutboxEvent{
+		ID:          result.ID,
+		AggregateID: result.AggregateID,
+		EventType:   result.EventType,
+		Payload:     result.Payload,
+		Attempts:    result.Attempts,
+		LastError:   result.LastError.String,
+		CreatedAt:   result.CreatedAt,
+	}
+	if result.DispatchedAt.Valid {
+		dispatchedAt := result.DispatchedAt.Time
+		event.DispatchedAt = &dispatchedAt
+	}
+	return event
+}
+
+// translateError converts the errors of the database that the service
+// reports to its clients: a missing row, and a database that cannot be
+// reached or refuses connections for now. Every method of the store returns its
+// errors through it.
+func translateError(err error) error {
+	var netErr net.Error
+	var pgErr *pgconn.PgError
+	switch {
+	case err == nil, errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err
+	case errors.Is(err, pgx.ErrNoRows):
+		return ErrMessageNotFound
+	case errors.As(err, &netErr), pgconn.SafeToRetry(err),
+		errors.As(err, &pgErr) && unavailableStates[pgErr.Code]:
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return err
+}
+
+// unavailableStates are the SQLSTATEs of a server that cannot serve for now.
+var unavailableStates = map[string]bool{
+	"08000": true, // connection_exception
+	"08001": true, // sqlclient_unable_to_establish_sqlconnection
+	"08004": true, // sqlserver_rejected_establishment_of_sqlconnection
+	"08006": true, // connection_failure
+	"53300": true, // too_many_connections
+	"57P01": true, // admin_shutdown
+	"57P03": true, // cannot_connect_now
+}
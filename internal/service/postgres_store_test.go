@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-boilerplate/internal/cache"
+	"go-boilerplate/internal/db"
+	"go-boilerplate/internal/models"
+	"go-boilerplate/internal/tenant"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newPostgresTestStore returns a PostgresStore on a schema of its own in the
+// database named by TEST_DATABASE_URL, migrated to the latest version. The
+// URL must name a role that may create schemas and roles; the store talks to
+// the database as a new role that owns nothing, so that row-level security
+// applies to it as it does in production. The test is skipped without
+// TEST_DATABASE_URL.
+func newPostgresTestStore(t *testing.T) *PostgresStore {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+
+	admin, err := pgx.Connect(ctx, url)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Close(context.Background()) })
+
+	suffix := strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+	schema, role := "test_"+suffix, "test_app_"+suffix
+	_, err = admin.Exec(ctx, "CREATE SCHEMA "+schema)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		ctx := context.Background()
+		_, _ = admin.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE")
+		_, _ = admin.Exec(ctx, "DROP OWNED BY "+role)
+		_, _ = admin.Exec(ctx, "DROP ROLE IF EXISTS "+role)
+	})
+
+	_, err = admin.Exec(ctx, "SET search_path TO "+schema+", public")
+	require.NoError(t, err)
+	migrations, err := filepath.Glob("../../migrations/*.up.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	sort.Strings(migrations)
+	for _, migration := range migrations {
+		sql, err := os.ReadFile(migration)
+		require.NoError(t, err)
+		_, err = admin.Exec(ctx, string(sql))
+		require.NoError(t, err, migration)
+	}
+
+	for _, statement := range []string{
+		"CREATE ROLE " + role + " NOLOGIN NOSUPERUSER NOBYPASSRLS",
+		"GRANT " + role + " TO CURRENT_USER",
+		"GRANT USAGE ON SCHEMA " + schema + " TO " + role,
+		"GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA " + schema + " TO " + role,
+		"GRANT USAGE ON ALL SEQUENCES IN SCHEMA " + schema + " TO " + role,
+	} {
+		_, err = admin.Exec(ctx, statement)
+		require.NoError(t, err, statement)
+	}
+
+	config, err := pgxpool.ParseConfig(url)
+	require.NoError(t, err)
+	config.ConnConfig.RuntimeParams["search_path"] = schema + ", public"
+	config.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		_, err := conn.Exec(ctx, "SET ROLE "+role)
+		return err
+	}
+	db.ConfigureTenantSession(config)
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	return NewPostgresStore(pool)
+}
+
+func TestPostgresStore_RowLevelSecurity(t *testing.T) {
+	store := newPostgresTestStore(t)
+	service := NewMessageService(store, cache.NewMemoryCache(), Options{})
+	acme := tenant.NewContext(context.Background(), "acme")
+	globex := tenant.NewContext(context.Background(), "globex")
+	all := tenant.NewContext(context.Background(), tenant.All)
+
+	message := &models.Message{Content: "acme only"}
+	require.NoError(t, service.CreateMessage(acme, message))
+
+	// A tenant neither sees nor writes the rows of another
+	var count int
+	require.NoError(t, store.pool.QueryRow(globex, "SELECT COUNT(*) FROM messages").Scan(&count))
+	assert.Zero(t, count)
+	_, err := store.pool.Exec(globex, "INSERT INTO messages (content, tenant_id) VALUES ('forged', 'acme')")
+	var pgErr *pgconn.PgError
+	require.ErrorAs(t, err, &pgErr)
+	assert.Equal(t, "42501", pgErr.Code, "the row violates the tenant isolation policy")
+
+	// Maintenance jobs work on the rows of every tenant
+	require.NoError(t, store.pool.QueryRow(all, "SELECT COUNT(*) FROM messages").Scan(&count))
+	assert.Equal(t, 1, count)
+	tag, err := store.pool.Exec(all, "UPDATE messages SET content = 'swept' WHERE id = $1", message.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), tag.RowsAffected())
+
+	got, err := store.GetMessage(acme, message.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "swept", got.Content)
+}
+
+func TestPostgresStore_ExpireMessages(t *testing.T) {
+	store := newPostgresTestStore(t)
+	service := NewMessageService(store, cache.NewMemoryCache(), Options{})
+	acme := tenant.NewContext(context.Background(), "acme")
+	globex := tenant.NewContext(context.Background(), "globex")
+
+	parent := &models.Message{Content: "parent"}
+	require.NoError(t, service.CreateMessage(acme, parent))
+	reply := &models.Message{Content: "reply", ParentID: &parent.ID}
+	require.NoError(t, service.CreateMessage(acme, reply))
+	other := &models.Message{Content: "other tenant"}
+	require.NoError(t, service.CreateMessage(globex, other))
+
+	for ctx, id := range map[context.Context]uuid.UUID{acme: reply.ID, globex: other.ID} {
+		_, err := store.pool.Exec(ctx, "UPDATE messages SET expires_at = CURRENT_TIMESTAMP - interval '1 minute' WHERE id = $1", id)
+		require.NoError(t, err)
+	}
+
+	// The sweeper moves the messages of every tenant to the trash, and the
+	// trigger counting replies updates the parent on its behalf
+	expired, err := service.ExpireMessages(context.Background(), 100)
+	require.NoError(t, err)
+	assert.Equal(t, 2, expired)
+
+	_, err = store.GetMessage(acme, reply.ID)
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+	_, err = store.GetMessage(globex, other.ID)
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+	got, err := store.GetMessage(acme, parent.ID)
+	require.NoError(t, err)
+	assert.Zero(t, got.ReplyCount)
+}
+
+func TestPostgresStore_PublishMessages(t *testing.T) {
+	store := newPostgresTestStore(t)
+	service := NewMessageService(store, cache.NewMemoryCache(), Options{})
+	acme := tenant.NewContext(context.Background(), "acme")
+
+	later := time.Now().Add(time.Hour)
+	scheduled := &models.Message{Content: "scheduled", PublishAt: &later}
+	require.NoError(t, service.CreateMessage(acme, scheduled))
+	require.Equal(t, models.StatusScheduled, scheduled.Status)
+	_, err := store.pool.Exec(acme, "UPDATE messages SET publish_at = CURRENT_TIMESTAMP - interval '1 minute' WHERE id = $1", scheduled.ID)
+	require.NoError(t, err)
+
+	// The scheduler publishes across tenants, bumping the version and
+	// recording it as a revision of the message's tenant
+	published, err := service.PublishMessages(context.Background(), 100)
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+
+	got, err := store.GetMessage(acme, scheduled.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusPublished, got.Status)
+	assert.Equal(t, scheduled.Version+1, got.Version)
+	revision, err := store.GetRevision(acme, scheduled.ID, got.Version)
+	require.NoError(t, err)
+	assert.Equal(t, "scheduled", revision.Content)
+}
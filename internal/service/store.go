@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"go-boilerplate/internal/apperr"
+	"go-boilerplate/internal/models"
+	"go-boilerplate/internal/search"
+)
+
+// ErrMessageNotFound is returned by a MessageStore when no live message
+// exists for the requested ID.
+var ErrMessageNotFound = apperr.New(apperr.NotFound, "MESSAGE_NOT_FOUND", "message not found")
+
+// ErrRevisionNotFound is returned when a message has no revision with the
+// requested number.
+var ErrRevisionNotFound = apperr.New(apperr.NotFound, "REVISION_NOT_FOUND", "revision not found")
+
+// ErrVersionConflict is returned when an update names an expected version
+// that no longer matches the stored message.
+var ErrVersionConflict = apperr.New(apperr.FailedPrecondition, "VERSION_CONFLICT", "message has been modified")
+
+// ErrAttachmentNotFound is returned when a message has no attachment with
+// the requested ID.
+var ErrAttachmentNotFound = apperr.New(apperr.NotFound, "ATTACHMENT_NOT_FOUND", "attachment not found")
+
+// ErrOutboxLocked is returned by OutboxStore.DispatchOutbox when another
+// relay is already dispatching the outbox.
+var ErrOutboxLocked = errors.New("outbox is locked by another relay")
+
+// ErrUnavailable is returned when the database cannot be reached, or
+// fails in a way that retrying may fix.
+var ErrUnavailable = apperr.New(apperr.Unavailable, "STORE_UNAVAILABLE", "message store unavailable").WithRetryAfter(time.Second)
+
+// ListOptions controls which page of messages a MessageStore returns.
+type ListOptions struct {
+	Limit  int32
+	Offset int32
+
+	// Filter and Sort are only honoured by ListMessages.
+	Filter MessageFilter
+	Sort   Sort
+
+	// After or Before select the page right after or before a position in
+	// the Sort order, instead of skipping Offset messages. They are only
+	// honoured by ListMessages.
+	After  *Keyset
+	Before *Keyset
+}
+
+// MessageFilter narrows a listing of live messages. Zero fields do not
+// filter; the time bounds are exclusive.
+type MessageFilter struct {
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+	IDs           []uuid.UUID
+	ContentPrefix string
+	// AuthorID keeps the messages created by one user.
+	AuthorID string
+	// Labels keeps the messages carrying every one of the labels, and
+	// MetadataKeys those whose metadata has every one of the keys.
+	Labels       []string
+	MetadataKeys []string
+	// ParentID keeps the replies to one message.
+	ParentID *uuid.UUID
+	// Status keeps the messages in one of the models.Status* statuses.
+	Status string
+}
+
+// SortField is a message field listings can be sorted by.
+type SortField string
+
+const (
+	SortCreatedAt SortField = "created_at"
+	SortUpdatedAt SortField = "updated_at"
+)
+
+// Sort orders a listing by Field, ties broken by ID in the same direction.
+// The zero Sort lists newest first by created_at.
+type Sort struct {
+	Field     SortField
+	Ascending bool
+}
+
+// ParseSortField returns the SortField named name, if there is one.
+func ParseSortField(name string) (SortField, bool) {
+	switch field := SortField(name); field {
+	case SortCreatedAt, SortUpdatedAt:
+		return field, true
+	}
+	return "", false
+}
+
+// Key identifies the sort order in one byte, so that pagination cursors
+// can be bound to the order they were issued for. The zero Sort has key 0.
+func (s Sort) Key() uint8 {
+	var key uint8
+	if s.field() == SortUpdatedAt {
+		key = 2
+	}
+	if s.Ascending {
+		key++
+	}
+	return key
+}
+
+// field returns the sort field, defaulting to created_at.
+func (s Sort) field() SortField {
+	if s.Field == "" {
+		return SortCreatedAt
+	}
+	return s.Field
+}
+
+// value returns the value message is sorted by.
+func (s Sort) value(message *models.Message) time.Time {
+	if s.field() == SortUpdatedAt {
+		return message.UpdatedAt
+	}
+	return message.CreatedAt
+}
+
+// keyset returns the position of message in the sort order.
+func (s Sort) keyset(message *models.Message) *Keyset {
+	return &Keyset{At: s.value(message), ID: message.ID}
+}
+
+// Keyset is a position in a sort order: the value of the sort field and
+// the ID breaking ties.
+type Keyset struct {
+	At time.Time
+	ID uuid.UUID
+}
+
+// OutboxBacklog summarizes the events still waiting to be dispatched.
+type OutboxBacklog struct {
+	Pending         int64
+	OldestCreatedAt time.Time
+}
+
+// MessageStore persists messages. Implementations fill in the
+// store-assigned fields (ID, timestamps) on the message they are given.
+// Live messages are those neither deleted nor expired: a message whose
+// ExpiresAt has passed is hidden like a deleted one until ExpireMessages
+// moves it to the trash. Except for ListThread, reads and DeleteMessages
+// only see the unpublished messages written by the caller in ctx, as
+// decided by visible.
+type MessageStore interface {
+	CreateMessage(ctx context.Context, message *models.Message) error
+	// CreateMessages creates several messages in one round trip, filling in
+	// each of them.
+	CreateMessages(ctx context.Context, messages []*models.Message) error
+	GetMessage(ctx context.Context, id uuid.UUID) (*models.Message, error)
+	// GetMessageForUpdate reads a message and locks it until the end of the
+	// surrounding transaction.
+	GetMessageForUpdate(ctx context.Context, id uuid.UUID) (*models.Message, error)
+	// UpdateMessage stores the new content and bumps the version. A non-zero
+	// message.Version is the expected current version; the update fails with
+	// ErrVersionConflict if the stored version differs.
+	UpdateMessage(ctx context.Context, message *models.Message) error
+	DeleteMessage(ctx context.Context, id uuid.UUID) error
+	// DeleteMessages soft-deletes the live messages among ids and returns
+	// them as deleted. Missing and already deleted IDs are skipped.
+	DeleteMessages(ctx context.Context, ids []uuid.UUID) ([]*models.Message, error)
+	// DeleteReplies soft-deletes the live replies to the given messages,
+	// the replies to those and so on down their threads, and returns them as
+	// deleted.
+	DeleteReplies(ctx context.Context, parentIDs []uuid.UUID) ([]*models.Message, error)
+	// ListMessages returns the live messages matching opts.Filter in
+	// opts.Sort order.
+	ListMessages(ctx context.Context, opts ListOptions) ([]*models.Message, error)
+	CountMessages(ctx context.Context, filter MessageFilter) (int64, error)
+	// ListThread returns up to limit messages of a thread, deleted ones
+	// included, oldest first.
+	ListThread(ctx context.Context, threadID uuid.UUID, limit int32) ([]*models.Message, error)
+
+	// SearchMessages returns the live messages matching query, best match
+	// first, then newest first.
+	SearchMessages(ctx context.Context, query search.Query, opts ListOptions) ([]*models.SearchResult, error)
+	CountSearchResults(ctx context.Context, query search.Query) (int64, error)
+
+	// ListDeletedMessages returns the soft-deleted messages written by the
+	// caller in ctx, most recently deleted first. With everyAuthor it also
+	// returns the published messages of other authors.
+	ListDeletedMessages(ctx context.Context, opts ListOptions, everyAuthor bool) ([]*models.Message, error)
+	CountDeletedMessages(ctx context.Context, everyAuthor bool) (int64, error)
+	// RestoreMessage takes a soft-deleted message out of the trash, clearing
+	// its expiry if that has passed. It returns ErrMessageNotFound if the
+	// message is not in the trash.
+	RestoreMessage(ctx context.Context, id uuid.UUID) (*models.Message, error)
+	// PurgeMessage permanently removes a message, deleted or not, together
+	// with its revisions and returns its last state.
+	PurgeMessage(ctx context.Context, id uuid.UUID) (*models.Message, error)
+	// PurgeDeletedMessages permanently removes up to limit messages deleted
+	// before the given time and returns them. Rows being purged by another
+	// caller are skipped rather than waited for.
+	PurgeDeletedMessages(ctx context.Context, deletedBefore time.Time, limit int32) ([]*models.Message, error)
+	// ExpireMessages soft-deletes up to limit live messages of any tenant
+	// that expired by the given time, soonest expiry first, and returns them
+	// as deleted. Like PurgeDeletedMessages it skips rows another caller is
+	// working on.
+	ExpireMessages(ctx context.Context, expiredBy time.Time, limit int32) ([]*models.Message, error)
+	// PublishMessages publishes up to limit live scheduled messages of any
+	// tenant that are due by the given time, soonest first, bumping their
+	// version, and returns them as published. It skips rows another caller is
+	// working on, so each message is published by one caller only.
+	PublishMessages(ctx context.Context, publishedBy time.Time, limit int32) ([]*models.Message, error)
+
+	// CreateRevision records a revision of a message. Revisions are never
+	// changed once written.
+	CreateRevision(ctx context.Context, revision *models.MessageRevision) error
+	// CreateRevisions records several revisions in bulk. Unlike
+	// CreateRevision it does not fill in the revisions.
+	CreateRevisions(ctx context.Context, revisions []*models.MessageRevision) error
+	GetRevision(ctx context.Context, messageID uuid.UUID, revision int64) (*models.MessageRevision, error)
+	// ListRevisions returns the revisions of a message, newest first.
+	ListRevisions(ctx context.Context, messageID uuid.UUID, opts ListOptions) ([]*models.MessageRevision, error)
+	CountRevisions(ctx context.Context, messageID uuid.UUID) (int64, error)
+
+	// CreateAttachment records an attachment of a message, filling in its
+	// creation time. The ID is chosen by the caller.
+	CreateAttachment(ctx context.Context, attachment *models.Attachment) error
+	GetAttachment(ctx context.Context, messageID, id uuid.UUID) (*models.Attachment, error)
+	// ListAttachments returns the attachments of a message, oldest first.
+	ListAttachments(ctx context.Context, messageID uuid.UUID) ([]*models.Attachment, error)
+	// PurgeAttachments removes the attachments of the given messages, those
+	// of any tenant when called across tenants, and returns them so their
+	// blobs can be deleted.
+	PurgeAttachments(ctx context.Context, messageIDs []uuid.UUID) ([]*models.Attachment, error)
+
+	// EnqueueEvent records an event in the outbox. Call it inside WithTx so
+	// the event commits or rolls back together with the change it describes.
+	EnqueueEvent(ctx context.Context, event *models.OutboxEvent) error
+	// EnqueueEvents records several events in bulk, in order. Unlike
+	// EnqueueEvent it does not fill in the events.
+	EnqueueEvents(ctx context.Context, events []*models.OutboxEvent) error
+
+	// WithTx runs fn against a store bound to a single transaction. The
+	// transaction commits if fn returns nil and rolls back otherwise.
+	WithTx(ctx context.Context, fn func(tx MessageStore) error) error
+
+	Ping(ctx context.Context) error
+}
+
+// OutboxStore is the relay's view of the transactional outbox.
+type OutboxStore interface {
+	// DispatchOutbox passes up to limit pending events, oldest first, to
+	// publish while holding an exclusive lock on the outbox. Events are
+	// marked dispatched as they succeed; the first failure is recorded on its
+	// event, stops the batch and is returned. It returns ErrOutboxLocked if
+	// another relay holds the lock.
+	DispatchOutbox(ctx context.Context, limit int32, publish func(*models.OutboxEvent) error) (int, error)
+
+	// PurgeOutbox deletes events dispatched before the given time.
+	PurgeOutbox(ctx context.Context, dispatchedBefore time.Time) (int64, error)
+
+	// OutboxBacklog reports how many events are waiting to be dispatched.
+	OutboxBacklog(ctx context.Context) (OutboxBacklog, error)
+}
+
+// MessageCache is a key/value cache for serialized messages.
+type MessageCache interface {
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	Get(ctx context.Context, key string, dest interface{}) error
+	Del(ctx context.Context, key string) error
+	Ping(ctx context.Context) error
+}
+
+// BlobStore keeps the contents of attachments under opaque keys. Open
+// returns an error wrapping fs.ErrNotExist for a missing key, and deleting
+// a missing key succeeds.
+type BlobStore interface {
+	// Put stores the size bytes read from body under key.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// EventPublisher publishes outbox events to downstream consumers.
+type EventPublisher interface {
+	PublishEvent(event *models.OutboxEvent) error
+}
@@ -0,0 +1,379 @@
+// Package stream fans message changes out to live subscribers.
+//
+// A Broadcaster is fed the events the service consumes from Kafka and hands
+// the changes they describe to every Subscription whose Filter matches. It
+// keeps the last STREAM_HISTORY_SIZE changes, so that a subscriber that
+// reconnects can resume after the last change it received, by its ID, or
+// after a point in time. Every subscription buffers up to STREAM_BUFFER_SIZE
+// changes; one that falls further behind is ended with ErrSlowConsumer
+// rather than holding up the others, and can resume from where it got to.
+// Closing the Broadcaster ends every subscription with ErrClosed.
+//
+// Each replica consumes every event, so a change can be resumed from on any
+// replica that still remembers it.
+//
+// Metrics:
+//   - stream_subscribers: open subscriptions
+//   - stream_changes_total{kind}: changes broadcast
+//   - stream_disconnects_total{reason}: subscriptions ended by the server,
+//     for slow_consumer or shutdown
+package stream
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"go-boilerplate/config"
+	"go-boilerplate/internal/events"
+	"go-boilerplate/internal/models"
+	"go-boilerplate/internal/tenant"
+)
+
+// ErrSlowConsumer ends a subscription whose buffer overflowed.
+var ErrSlowConsumer = errors.New("subscriber fell behind")
+
+// ErrClosed ends the subscriptions of a closed Broadcaster.
+var ErrClosed = errors.New("stream closed")
+
+// ErrResumeExpired is returned for a resume point older than the changes
+// the Broadcaster remembers.
+var ErrResumeExpired = errors.New("resume point is no longer available")
+
+// Kind is what happened to a message.
+type Kind string
+
+const (
+	// KindCreated is a message.created or message.restored event: the
+	// message joined the live messages.
+	KindCreated Kind = "created"
+	// KindUpdated is a message.updated or message.published event.
+	KindUpdated Kind = "updated"
+	// KindDeleted is a message.deleted or message.expired event: the
+	// message left the live messages.
+	KindDeleted Kind = "deleted"
+)
+
+// Change is a change to a message, as sent to subscribers.
+type Change struct {
+	// ID is the ID of the event describing the change, used to resume
+	// after it.
+	ID   string
+	Kind Kind
+	Time time.Time
+	// Message is the state after the change, or the last state before a
+	// deletion.
+	Message models.Message
+}
+
+// newChange returns the change described by event, or nil for events that
+// are not streamed.
+func newChange(event events.Event) *Change {
+	meta := event.Meta()
+	change := &Change{ID: meta.ID, Time: meta.Time}
+	switch e := event.(type) {
+	case *events.MessageCreated:
+		change.Kind, change.Message = KindCreated, e.Message
+	case *events.MessageRestored:
+		change.Kind, change.Message = KindCreated, e.Message
+	case *events.MessageUpdated:
+		change.Kind, change.Message = KindUpdated, e.After
+	case *events.MessagePublished:
+		change.Kind, change.Message = KindUpdated, e.Message
+	case *events.MessageDeleted:
+		change.Kind, change.Message = KindDeleted, e.Message
+	case *events.MessageExpired:
+		change.Kind, change.Message = KindDeleted, e.Message
+	default:
+		return nil
+	}
+
+	// The tenant of the message travels in the envelope
+	change.Message.TenantID = meta.TenantID
+	if change.Message.TenantID == "" {
+		change.Message.TenantID = tenant.Default
+	}
+	return change
+}
+
+// Filter selects the changes a subscription receives. Zero fields do not
+// filter, except that changes are always limited to one tenant and, for
+// drafts and scheduled messages, to their author.
+type Filter struct {
+	TenantID string
+	// CallerID is the subscriber, who only sees the unpublished messages
+	// they wrote. Anonymous subscribers own the anonymous messages.
+	CallerID string
+	Kinds    []Kind
+	// Labels keeps the messages carrying all of them.
+	Labels   []string
+	ThreadID uuid.UUID
+	AuthorID string
+}
+
+// Match reports whether change passes the filter.
+func (f *Filter) Match(change *Change) bool {
+	message := &change.Message
+	if message.TenantID != f.TenantID {
+		return false
+	}
+	if message.Status != models.StatusPublished && message.AuthorID != f.CallerID {
+		return false
+	}
+	if len(f.Kinds) > 0 && !contains(f.Kinds, change.Kind) {
+		return false
+	}
+	for _, label := range f.Labels {
+		if !contains(message.Labels, label) {
+			return false
+		}
+	}
+	if f.ThreadID != uuid.Nil && message.ThreadID != f.ThreadID {
+		return false
+	}
+	if f.AuthorID != "" && message.AuthorID != f.AuthorID {
+		return false
+	}
+	return true
+}
+
+// Position is where a subscription starts: after the change with ID
+// AfterID, after the time Since, or with the next change if both are zero.
+type Position struct {
+	AfterID string
+	Since   time.Time
+}
+
+// Metrics holds the Prometheus collectors reported by a Broadcaster.
+type Metrics struct {
+	subscribers prometheus.Gauge
+	changes     *prometheus.CounterVec
+	disconnects *prometheus.CounterVec
+}
+
+// NewMetrics creates the stream metrics and registers them with reg.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		subscribers: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "stream_subscribers",
+			Help: "Number of open message stream subscriptions.",
+		}),
+		changes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "stream_changes_total",
+			Help: "Number of message changes broadcast to subscribers, by kind.",
+		}, []string{"kind"}),
+		disconnects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "stream_disconnects_total",
+			Help: "Number of subscriptions ended by the server, by reason.",
+		}, []string{"reason"}),
+	}
+
+	reg.MustRegister(m.subscribers, m.changes, m.disconnects)
+	return m
+}
+
+// Broadcaster hands message changes to subscribers until it is closed.
+type Broadcaster struct {
+	cfg     config.StreamConfig
+	metrics *Metrics
+
+	mu sync.Mutex
+	// history holds the last cfg.HistorySize changes, oldest first.
+	history []*Change
+	// horizon is the time of the newest change dropped from history, or of
+	// the start of the Broadcaster; changes before it are unknown.
+	horizon       time.Time
+	subscriptions map[*Subscription]struct{}
+	closed        bool
+}
+
+func NewBroadcaster(cfg config.StreamConfig, metrics *Metrics) *Broadcaster {
+	return &Broadcaster{
+		cfg:           cfg,
+		metrics:       metrics,
+		horizon:       time.Now(),
+		subscriptions: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish broadcasts the change described by event, if it is one that is
+// streamed. A subscriber whose buffer is full is disconnected with
+// ErrSlowConsumer.
+func (b *Broadcaster) Publish(event events.Event) {
+	change := newChange(event)
+	if change == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	b.remember(change)
+	b.metrics.changes.WithLabelValues(string(change.Kind)).Inc()
+	for subscription := range b.subscriptions {
+		if !subscription.filter.Match(change) {
+			continue
+		}
+		select {
+		case subscription.changes <- change:
+		default:
+			b.end(subscription, ErrSlowConsumer)
+			b.metrics.disconnects.WithLabelValues("slow_consumer").Inc()
+		}
+	}
+}
+
+// remember adds change to the history, dropping the oldest change if it is
+// full.
+func (b *Broadcaster) remember(change *Change) {
+	if b.cfg.HistorySize <= 0 {
+		b.horizon = change.Time
+		return
+	}
+	if len(b.history) == b.cfg.HistorySize {
+		b.horizon = b.history[0].Time
+		b.history[0] = nil
+		b.history = b.history[1:]
+	}
+	b.history = append(b.history, change)
+}
+
+// Subscribe starts a subscription to the changes matching filter, from
+// position. The remembered changes after position come first. It fails
+// with ErrResumeExpired if position is older than the history, and with
+// ErrClosed once the Broadcaster is closed.
+func (b *Broadcaster) Subscribe(filter Filter, position Position) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	replay, err := b.replay(position)
+	if err != nil {
+		return nil, err
+	}
+	var matched []*Change
+	for _, change := range replay {
+		if filter.Match(change) {
+			matched = append(matched, change)
+		}
+	}
+
+	// Make room for the replay on top of the usual buffer
+	subscription := &Subscription{
+		broadcaster: b,
+		filter:      filter,
+		changes:     make(chan *Change, b.cfg.BufferSize+len(matched)),
+		done:        make(chan struct{}),
+	}
+	for _, change := range matched {
+		subscription.changes <- change
+	}
+
+	b.subscriptions[subscription] = struct{}{}
+	b.metrics.subscribers.Inc()
+	return subscription, nil
+}
+
+// replay returns the remembered changes after position.
+func (b *Broadcaster) replay(position Position) ([]*Change, error) {
+	switch {
+	case position.AfterID != "":
+		for i, change := range b.history {
+			if change.ID == position.AfterID {
+				return b.history[i+1:], nil
+			}
+		}
+		return nil, ErrResumeExpired
+	case !position.Since.IsZero():
+		if position.Since.Before(b.horizon) {
+			return nil, ErrResumeExpired
+		}
+		for i, change := range b.history {
+			if change.Time.After(position.Since) {
+				return b.history[i:], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// Close ends every subscription with ErrClosed and refuses new ones.
+func (b *Broadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	b.closed = true
+	for subscription := range b.subscriptions {
+		b.end(subscription, ErrClosed)
+		b.metrics.disconnects.WithLabelValues("shutdown").Inc()
+	}
+}
+
+// end removes subscription, which ends with err. The caller holds b.mu.
+func (b *Broadcaster) end(subscription *Subscription, err error) {
+	if _, ok := b.subscriptions[subscription]; !ok {
+		return
+	}
+	delete(b.subscriptions, subscription)
+	b.metrics.subscribers.Dec()
+	subscription.err = err
+	close(subscription.done)
+}
+
+// Subscription receives the changes matching its filter until it is closed
+// or ended by the Broadcaster.
+type Subscription struct {
+	broadcaster *Broadcaster
+	filter      Filter
+	changes     chan *Change
+	done        chan struct{}
+	// err is set before done is closed
+	err error
+}
+
+// Changes returns the channel the changes are delivered on, in order.
+func (s *Subscription) Changes() <-chan *Change {
+	return s.changes
+}
+
+// Done is closed when the Broadcaster ends the subscription; Err then says
+// why. Changes still buffered may be dropped.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns why the subscription ended, once Done is closed.
+func (s *Subscription) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Close ends the subscription on behalf of the subscriber.
+func (s *Subscription) Close() {
+	s.broadcaster.mu.Lock()
+	defer s.broadcaster.mu.Unlock()
+	s.broadcaster.end(s, nil)
+}
+
+func contains[T comparable](items []T, item T) bool {
+	for _, candidate := range items {
+		if candidate == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-boilerplate/config"
+	"go-boilerplate/internal/events"
+	"go-boilerplate/internal/models"
+	"go-boilerplate/internal/tenant"
+)
+
+func newTestBroadcaster(cfg config.StreamConfig) *Broadcaster {
+	return NewBroadcaster(cfg, NewMetrics(prometheus.NewRegistry()))
+}
+
+func created(id string, message models.Message) events.Event {
+	return &events.MessageCreated{
+		Envelope:           events.Envelope{ID: id, Time: time.Now(), TenantID: message.TenantID},
+		MessageCreatedData: events.MessageCreatedData{Message: message},
+	}
+}
+
+func published(content string) models.Message {
+	return models.Message{ID: uuid.New(), Content: content, Status: models.StatusPublished}
+}
+
+// receive returns the changes buffered for subscription
+func receive(subscription *Subscription) []*Change {
+	var changes []*Change
+	for {
+		select {
+		case change := <-subscription.Changes():
+			changes = append(changes, change)
+		default:
+			return changes
+		}
+	}
+}
+
+func TestFilter_Match(t *testing.T) {
+	thread := uuid.New()
+	message := models.Message{TenantID: tenant.Default, AuthorID: "author", Status: models.StatusPublished, Labels: []string{"a", "b"}, ThreadID: thread}
+	draft := message
+	draft.Status = models.StatusDraft
+
+	tests := []struct {
+		name   string
+		filter Filter
+		change Change
+		want   bool
+	}{
+		{"everything", Filter{TenantID: tenant.Default}, Change{Kind: KindCreated, Message: message}, true},
+		{"other tenant", Filter{TenantID: "acme"}, Change{Kind: KindCreated, Message: message}, false},
+		{"kind", Filter{TenantID: tenant.Default, Kinds: []Kind{KindDeleted}}, Change{Kind: KindCreated, Message: message}, false},
+		{"all labels", Filter{TenantID: tenant.Default, Labels: []string{"a", "b"}}, Change{Kind: KindCreated, Message: message}, true},
+		{"missing label", Filter{TenantID: tenant.Default, Labels: []string{"a", "c"}}, Change{Kind: KindCreated, Message: message}, false},
+		{"thread", Filter{TenantID: tenant.Default, ThreadID: uuid.New()}, Change{Kind: KindCreated, Message: message}, false},
+		{"author", Filter{TenantID: tenant.Default, AuthorID: "other"}, Change{Kind: KindCreated, Message: message}, false},
+		{"own draft", Filter{TenantID: tenant.Default, CallerID: "author"}, Change{Kind: KindUpdated, Message: draft}, true},
+		{"draft of another", Filter{TenantID: tenant.Default, CallerID: "reader"}, Change{Kind: KindUpdated, Message: draft}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Match(&tt.change))
+		})
+	}
+}
+
+func TestBroadcaster_Publish(t *testing.T) {
+	b := newTestBroadcaster(config.StreamConfig{BufferSize: 10, HistorySize: 10})
+	everything, err := b.Subscribe(Filter{TenantID: tenant.Default}, Position{})
+	require.NoError(t, err)
+	deletions, err := b.Subscribe(Filter{TenantID: tenant.Default, Kinds: []Kind{KindDeleted}}, Position{})
+	require.NoError(t, err)
+
+	message := published("hello")
+	b.Publish(created("1", message))
+	b.Publish(&events.MessageDeleted{
+		Envelope:           events.Envelope{ID: "2", Time: time.Now()},
+		MessageDeletedData: events.MessageDeletedData{Message: message},
+	})
+	// Purges are not streamed
+	b.Publish(&events.MessagePurged{Envelope: events.Envelope{ID: "3", Time: time.Now()}})
+
+	changes := receive(everything)
+	require.Len(t, changes, 2)
+	assert.Equal(t, KindCreated, changes[0].Kind)
+	assert.Equal(t, message.ID, changes[0].Message.ID)
+	assert.Equal(t, tenant.Default, changes[0].Message.TenantID, "an event without a tenant belongs to the default one")
+	assert.Equal(t, KindDeleted, changes[1].Kind)
+
+	changes = receive(deletions)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "2", changes[0].ID)
+}
+
+func TestBroadcaster_Resume(t *testing.T) {
+	b := newTestBroadcaster(config.StreamConfig{BufferSize: 10, HistorySize: 3})
+	start := time.Now()
+	for _, id := range []string{"1", "2", "3", "4"} {
+		b.Publish(created(id, published(id)))
+	}
+
+	subscription, err := b.Subscribe(Filter{TenantID: tenant.Default}, Position{AfterID: "2"})
+	require.NoError(t, err)
+	b.Publish(created("5", published("5")))
+
+	var ids []string
+	for _, change := range receive(subscription) {
+		ids = append(ids, change.ID)
+	}
+	assert.Equal(t, []string{"3", "4", "5"}, ids, "the replay comes before the live changes")
+
+	// Change 1 has dropped out of the history
+	_, err = b.Subscribe(Filter{TenantID: tenant.Default}, Position{AfterID: "1"})
+	assert.ErrorIs(t, err, ErrResumeExpired)
+	_, err = b.Subscribe(Filter{TenantID: tenant.Default}, Position{Since: start})
+	assert.ErrorIs(t, err, ErrResumeExpired)
+
+	subscription, err = b.Subscribe(Filter{TenantID: tenant.Default}, Position{Since: time.Now()})
+	require.NoError(t, err)
+	assert.Empty(t, receive(subscription))
+}
+
+func TestBroadcaster_SlowConsumer(t *testing.T) {
+	b := newTestBroadcaster(config.StreamConfig{BufferSize: 2, HistorySize: 10})
+	slow, err := b.Subscribe(Filter{TenantID: tenant.Default}, Position{})
+	require.NoError(t, err)
+	other, err := b.Subscribe(Filter{TenantID: "acme"}, Position{})
+	require.NoError(t, err)
+
+	for _, id := range []string{"1", "2", "3"} {
+		b.Publish(created(id, published(id)))
+	}
+
+	select {
+	case <-slow.Done():
+	default:
+		t.Fatal("slow subscriber was not disconnected")
+	}
+	assert.ErrorIs(t, slow.Err(), ErrSlowConsumer)
+	assert.NoError(t, other.Err(), "other subscribers are not held up")
+
+	// The slow subscriber can resume from where it got to
+	changes := receive(slow)
+	require.Len(t, changes, 2)
+	resumed, err := b.Subscribe(Filter{TenantID: tenant.Default}, Position{AfterID: changes[1].ID})
+	require.NoError(t, err)
+	assert.Len(t, receive(resumed), 1)
+}
+
+func TestBroadcaster_Close(t *testing.T) {
+	b := newTestBroadcaster(config.StreamConfig{BufferSize: 2, HistorySize: 10})
+	subscription, err := b.Subscribe(Filter{TenantID: tenant.Default}, Position{})
+	require.NoError(t, err)
+	closed, err := b.Subscribe(Filter{TenantID: tenant.Default}, Position{})
+	require.NoError(t, err)
+	closed.Close()
+
+	b.Close()
+	<-subscription.Done()
+	assert.ErrorIs(t, subscription.Err(), ErrClosed)
+	assert.NoError(t, closed.Err(), "a subscription closed by its subscriber is not ended")
+
+	_, err = b.Subscribe(Filter{TenantID: tenant.Default}, Position{})
+	assert.ErrorIs(t, err, ErrClosed)
+}
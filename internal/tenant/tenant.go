@@ -0,0 +1,85 @@
+// Package tenant identifies the tenant a request acts for.
+//
+// Every message belongs to exactly one tenant, and a request only ever sees
+// the messages of its own. The tenant is resolved once at the edge, from the
+// caller's token or a trusted header, and carried in the context from there
+// to the stores, the cache and the events.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"go-boilerplate/internal/auth"
+)
+
+const (
+	// Default is the tenant of requests that do not name one, and of every
+	// message written before tenants existed.
+	Default = "default"
+	// All lets maintenance jobs, such as the trash purger, act across
+	// tenants. It is never accepted from a request.
+	All = "*"
+)
+
+// ErrInvalidID is returned for a malformed tenant ID.
+var ErrInvalidID = errors.New("invalid tenant ID")
+
+// ErrMismatch is returned when the tenant header of a request names another
+// tenant than the caller's token, Default for a token without a tenant claim.
+var ErrMismatch = errors.New("tenant header does not match the caller's tenant")
+
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,62}$`)
+
+// Validate checks that id is a well-formed tenant ID: 1 to 63 letters,
+// digits, '_', '.' or '-', starting with a letter or digit.
+func Validate(id string) error {
+	if !idPattern.MatchString(id) {
+		return fmt.Errorf("%w %q", ErrInvalidID, id)
+	}
+	return nil
+}
+
+// Resolve returns the tenant a request acts for. An authenticated caller in
+// ctx acts for the tenant claim of its token, Default when it has none, and
+// header may only repeat that tenant. Only anonymous requests, which reach
+// the server when a gateway authenticates them, act for header, the value of
+// the trusted tenant header, else Default. header is "" when the request has
+// none or it is not trusted.
+func Resolve(ctx context.Context, header string) (string, error) {
+	id := header
+	if claims, ok := auth.FromContext(ctx); ok {
+		id = claims.TenantID
+		if id == "" {
+			id = Default
+		}
+		if header != "" && header != id {
+			return "", ErrMismatch
+		}
+	}
+	if id == "" {
+		return Default, nil
+	}
+
+	if err := Validate(id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+type tenantKey struct{}
+
+// NewContext returns a copy of ctx that acts for the tenant id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext returns the tenant stored in ctx by NewContext, or Default.
+func FromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(tenantKey{}).(string); ok && id != "" {
+		return id
+	}
+	return Default
+}
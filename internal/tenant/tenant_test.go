@@ -0,0 +1,46 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-boilerplate/internal/auth"
+)
+
+func TestResolve(t *testing.T) {
+	anonymous := context.Background()
+	claimless := auth.NewContext(anonymous, &auth.Claims{UserID: "user"})
+	acme := auth.NewContext(anonymous, &auth.Claims{UserID: "user", TenantID: "acme"})
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		header string
+		want   string
+		err    error
+	}{
+		{"anonymous without header", anonymous, "", Default, nil},
+		{"anonymous with header", anonymous, "acme", "acme", nil},
+		{"anonymous with malformed header", anonymous, "-acme", "", ErrInvalidID},
+		{"claim", acme, "", "acme", nil},
+		{"claim with matching header", acme, "acme", "acme", nil},
+		{"claim with foreign header", acme, "other", "", ErrMismatch},
+		{"claim-less token", claimless, "", Default, nil},
+		{"claim-less token with default header", claimless, Default, Default, nil},
+		{"claim-less token with foreign header", claimless, "acme", "", ErrMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := Resolve(tt.ctx, tt.header)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, id)
+		})
+	}
+}
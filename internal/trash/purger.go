@@ -0,0 +1,116 @@
+// Package trash permanently removes deleted messages once their retention
+// period has passed.
+//
+// Deleting a message only moves it to the trash, from where it can be
+// restored. The Purger periodically removes messages that have been in the
+// trash longer than TRASH_RETENTION, in batches of TRASH_PURGE_BATCH_SIZE,
+// and the service emits a message.purged event for each of them. Several
+// replicas can run a Purger at the same time: rows being purged by one are
+// skipped by the others.
+//
+// Metrics:
+//   - trash_purged_messages_total: messages permanently removed
+//   - trash_purge_failures_total: failed purge batches
+package trash
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go-boilerplate/config"
+	"go-boilerplate/internal/service"
+	"go.uber.org/zap"
+)
+
+// Metrics holds the Prometheus collectors reported by the purger.
+type Metrics struct {
+	purged   prometheus.Counter
+	failures prometheus.Counter
+}
+
+// NewMetrics creates the purger metrics and registers them with reg.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		purged: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "trash_purged_messages_total",
+			Help: "Number of deleted messages permanently removed after their retention period.",
+		}),
+		failures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "trash_purge_failures_total",
+			Help: "Number of failed attempts to purge a batch of deleted messages.",
+		}),
+	}
+
+	reg.MustRegister(m.purged, m.failures)
+	return m
+}
+
+// Purger purges expired messages from the trash until its context is
+// cancelled.
+type Purger struct {
+	messageService *service.MessageService
+	cfg            config.TrashConfig
+	metrics        *Metrics
+	logger         *zap.Logger
+}
+
+func NewPurger(messageService *service.MessageService, cfg config.TrashConfig, metrics *Metrics, logger *zap.Logger) *Purger {
+	return &Purger{
+		messageService: messageService,
+		cfg:            cfg,
+		metrics:        metrics,
+		logger:         logger,
+	}
+}
+
+// Run purges the trash every PurgeInterval until ctx is cancelled. It
+// returns immediately if retention is disabled.
+func (p *Purger) Run(ctx context.Context) {
+	if p.cfg.Retention <= 0 {
+		p.logger.Info("Trash retention disabled; deleted messages are kept forever")
+		return
+	}
+
+	ticker := time.NewTicker(p.cfg.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.PurgeOnce(ctx); err != nil {
+			p.logger.Error("Failed to purge deleted messages", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeOnce removes every message deleted longer ago than the retention
+// period, one batch at a time, and reports how many were removed.
+func (p *Purger) PurgeOnce(ctx context.Context) (int, error) {
+	deletedBefore := time.Now().Add(-p.cfg.Retention)
+
+	total := 0
+	for ctx.Err() == nil {
+		purged, err := p.messageService.PurgeDeletedMessages(ctx, deletedBefore, p.cfg.PurgeBatchSize)
+		if err != nil {
+			p.metrics.failures.Inc()
+			return total, err
+		}
+
+		p.metrics.purged.Add(float64(purged))
+		total += purged
+
+		if purged < int(p.cfg.PurgeBatchSize) {
+			break
+		}
+	}
+
+	if total > 0 {
+		p.logger.Info("Purged deleted messages", zap.Int("count", total))
+	}
+	return total, ctx.Err()
+}
@@ -0,0 +1,92 @@
+package trash
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-boilerplate/config"
+	"go-boilerplate/internal/cache"
+	"go-boilerplate/internal/events"
+	"go-boilerplate/internal/models"
+	"go-boilerplate/internal/service"
+	"go.uber.org/zap"
+)
+
+func TestPurger_PurgeOnce(t *testing.T) {
+	ctx := context.Background()
+	store := service.NewMemoryStore()
+	messageService := service.NewMessageService(store, cache.NewMemoryCache(), service.Options{})
+
+	var messages []*models.Message
+	for _, content := range []string{"keep", "purge", "purge too"} {
+		message := &models.Message{Content: content}
+		require.NoError(t, messageService.CreateMessage(ctx, message))
+		messages = append(messages, message)
+	}
+	require.NoError(t, messageService.DeleteMessage(ctx, messages[1].ID))
+	require.NoError(t, messageService.DeleteMessage(ctx, messages[2].ID))
+
+	// Skip the events recorded so far
+	_, err := store.DispatchOutbox(ctx, 100, func(*models.OutboxEvent) error { return nil })
+	require.NoError(t, err)
+
+	time.Sleep(time.Millisecond)
+
+	// A batch size of one exercises purging in several batches
+	purger := NewPurger(messageService, config.TrashConfig{
+		Retention:      time.Nanosecond,
+		PurgeInterval:  time.Hour,
+		PurgeBatchSize: 1,
+	}, NewMetrics(prometheus.NewRegistry()), zap.NewNop())
+
+	purged, err := purger.PurgeOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+
+	_, err = messageService.GetMessage(ctx, messages[0].ID)
+	assert.NoError(t, err)
+
+	trashed, total, err := messageService.ListDeletedMessages(ctx, 1, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.Empty(t, trashed)
+
+	revisions, err := store.CountRevisions(ctx, messages[1].ID)
+	require.NoError(t, err)
+	assert.Zero(t, revisions)
+
+	var purgedIDs []string
+	_, err = store.DispatchOutbox(ctx, 100, func(event *models.OutboxEvent) error {
+		assert.Equal(t, events.TypeMessagePurged, event.EventType)
+		purgedIDs = append(purgedIDs, event.AggregateID.String())
+		return nil
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{messages[1].ID.String(), messages[2].ID.String()}, purgedIDs)
+}
+
+func TestPurger_KeepsRecentlyDeleted(t *testing.T) {
+	ctx := context.Background()
+	messageService := service.NewMessageService(service.NewMemoryStore(), cache.NewMemoryCache(), service.Options{})
+
+	message := &models.Message{Content: "recently deleted"}
+	require.NoError(t, messageService.CreateMessage(ctx, message))
+	require.NoError(t, messageService.DeleteMessage(ctx, message.ID))
+
+	purger := NewPurger(messageService, config.TrashConfig{
+		Retention:      time.Hour,
+		PurgeInterval:  time.Hour,
+		PurgeBatchSize: 10,
+	}, NewMetrics(prometheus.NewRegistry()), zap.NewNop())
+
+	purged, err := purger.PurgeOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	_, err = messageService.RestoreMessage(ctx, message.ID)
+	assert.NoError(t, err)
+}
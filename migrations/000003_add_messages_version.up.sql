@@ -0,0 +1,2 @@
+-- Optimistic concurrency control: every successful update bumps the version
+ALTER TABLE messages ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS message_revisions;
+DROP FUNCTION IF EXISTS message_revisions_immutable();
@@ -0,0 +1,25 @@
+-- Every create and update of a message records an immutable revision. The
+-- revision number matches the message version it produced.
+CREATE TABLE message_revisions (
+    message_id UUID NOT NULL REFERENCES messages (id) ON DELETE CASCADE,
+    revision BIGINT NOT NULL,
+    content TEXT NOT NULL,
+    editor TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (message_id, revision)
+);
+
+CREATE FUNCTION message_revisions_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'message revisions are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER message_revisions_no_update
+    BEFORE UPDATE ON message_revisions
+    FOR EACH ROW EXECUTE FUNCTION message_revisions_immutable();
+
+-- Earlier history was never kept; start from the current content
+INSERT INTO message_revisions (message_id, revision, content, created_at)
+SELECT id, version, content, COALESCE(updated_at, created_at, CURRENT_TIMESTAMP)
+FROM messages;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS messages_deleted_at_idx;
+ALTER TABLE messages DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,6 @@
+-- Messages have always been soft deleted through deleted_at, but the column
+-- was never created by a migration
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- Listing the trash and the retention purge only look at deleted messages
+CREATE INDEX IF NOT EXISTS messages_deleted_at_idx ON messages (deleted_at) WHERE deleted_at IS NOT NULL;
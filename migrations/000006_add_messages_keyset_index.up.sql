@@ -0,0 +1,2 @@
+-- Keyset pagination walks live messages in (created_at, id) order
+CREATE INDEX IF NOT EXISTS messages_created_at_id_idx ON messages (created_at DESC, id DESC) WHERE deleted_at IS NULL;
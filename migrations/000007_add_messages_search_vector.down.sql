@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS messages_search_vector_idx;
+DROP TRIGGER IF EXISTS update_messages_search_vector ON messages;
+DROP FUNCTION IF EXISTS update_messages_search_vector();
+ALTER TABLE messages DROP COLUMN IF EXISTS search_vector;
+DROP FUNCTION IF EXISTS messages_search_config();
@@ -0,0 +1,25 @@
+-- The text search configuration is chosen per deployment through the
+-- app.search_language connection parameter, falling back to english
+CREATE FUNCTION messages_search_config() RETURNS regconfig AS $$
+    SELECT COALESCE(NULLIF(current_setting('app.search_language', true), ''), 'english')::regconfig;
+$$ LANGUAGE sql STABLE;
+
+ALTER TABLE messages ADD COLUMN search_vector tsvector;
+
+CREATE FUNCTION update_messages_search_vector() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector := to_tsvector(messages_search_config(), NEW.content);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER update_messages_search_vector
+    BEFORE INSERT OR UPDATE OF content ON messages
+    FOR EACH ROW EXECUTE FUNCTION update_messages_search_vector();
+
+-- Index the existing messages without touching their updated_at
+ALTER TABLE messages DISABLE TRIGGER update_messages_updated_at;
+UPDATE messages SET search_vector = to_tsvector(messages_search_config(), content);
+ALTER TABLE messages ENABLE TRIGGER update_messages_updated_at;
+
+CREATE INDEX messages_search_vector_idx ON messages USING GIN (search_vector);
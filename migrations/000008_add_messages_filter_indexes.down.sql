@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS messages_content_prefix_idx;
+DROP INDEX IF EXISTS messages_updated_at_id_idx;
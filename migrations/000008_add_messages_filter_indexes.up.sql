@@ -0,0 +1,6 @@
+-- Listing can be sorted by updated_at as well as created_at
+CREATE INDEX IF NOT EXISTS messages_updated_at_id_idx ON messages (updated_at DESC, id DESC) WHERE deleted_at IS NULL;
+
+-- Content prefix filters compare bytewise; only the start of the content is
+-- indexed to stay clear of the btree entry size limit
+CREATE INDEX IF NOT EXISTS messages_content_prefix_idx ON messages (left(content, 64) text_pattern_ops) WHERE deleted_at IS NULL;
@@ -0,0 +1,14 @@
+-- Responses to requests made with an idempotency key, replayed when the
+-- request is retried. Redis holds them normally; this table takes over while
+-- Redis is unavailable.
+CREATE TABLE idempotency_keys (
+    key TEXT PRIMARY KEY,
+    fingerprint TEXT NOT NULL,
+    status INTEGER NOT NULL,
+    header JSONB NOT NULL DEFAULT '{}',
+    body BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
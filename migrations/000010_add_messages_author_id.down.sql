@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS messages_author_id_created_at_idx;
+ALTER TABLE messages DROP COLUMN IF EXISTS author_id;
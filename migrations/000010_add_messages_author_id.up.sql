@@ -0,0 +1,6 @@
+-- The authenticated user who created the message. Messages created before
+-- authorship was recorded, or without authentication, have none.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS author_id TEXT;
+
+-- Serves the "mine" filter in either sort order
+CREATE INDEX IF NOT EXISTS messages_author_id_created_at_idx ON messages (author_id, created_at DESC, id DESC) WHERE deleted_at IS NULL;
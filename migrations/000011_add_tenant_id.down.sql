@@ -0,0 +1,20 @@
+DROP POLICY IF EXISTS message_revisions_tenant_isolation ON message_revisions;
+ALTER TABLE message_revisions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE message_revisions DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS messages_tenant_isolation ON messages;
+ALTER TABLE messages NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE messages DISABLE ROW LEVEL SECURITY;
+DROP FUNCTION IF EXISTS current_tenant();
+
+DROP INDEX IF EXISTS messages_tenant_deleted_at_idx;
+DROP INDEX IF EXISTS messages_tenant_author_id_created_at_idx;
+DROP INDEX IF EXISTS messages_tenant_content_prefix_idx;
+DROP INDEX IF EXISTS messages_tenant_updated_at_id_idx;
+DROP INDEX IF EXISTS messages_tenant_created_at_id_idx;
+CREATE INDEX IF NOT EXISTS messages_author_id_created_at_idx ON messages (author_id, created_at DESC, id DESC) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS messages_content_prefix_idx ON messages (left(content, 64) text_pattern_ops) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS messages_updated_at_id_idx ON messages (updated_at DESC, id DESC) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS messages_created_at_id_idx ON messages (created_at DESC, id DESC) WHERE deleted_at IS NULL;
+
+ALTER TABLE message_revisions DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE messages DROP COLUMN IF EXISTS tenant_id;
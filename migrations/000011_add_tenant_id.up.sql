@@ -0,0 +1,43 @@
+-- Every message belongs to one tenant (customer workspace), and so do its
+-- revisions. Existing data belongs to the default tenant; new rows must name
+-- theirs.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE messages ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE message_revisions ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE message_revisions ALTER COLUMN tenant_id DROP DEFAULT;
+
+-- Listings are always confined to one tenant, so their indexes lead with it
+DROP INDEX IF EXISTS messages_created_at_id_idx;
+DROP INDEX IF EXISTS messages_updated_at_id_idx;
+DROP INDEX IF EXISTS messages_content_prefix_idx;
+DROP INDEX IF EXISTS messages_author_id_created_at_idx;
+CREATE INDEX IF NOT EXISTS messages_tenant_created_at_id_idx ON messages (tenant_id, created_at DESC, id DESC) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS messages_tenant_updated_at_id_idx ON messages (tenant_id, updated_at DESC, id DESC) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS messages_tenant_content_prefix_idx ON messages (tenant_id, left(content, 64) text_pattern_ops) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS messages_tenant_author_id_created_at_idx ON messages (tenant_id, author_id, created_at DESC, id DESC) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS messages_tenant_deleted_at_idx ON messages (tenant_id, deleted_at DESC) WHERE deleted_at IS NOT NULL;
+
+-- current_tenant returns the app.tenant_id setting, which the application
+-- sets on every connection it takes from its pool. '*' is reserved for
+-- maintenance jobs that work across tenants, such as the expiry sweeper and
+-- the scheduler: they read and write the rows of every tenant, as do the
+-- triggers their writes fire.
+CREATE OR REPLACE FUNCTION current_tenant() RETURNS text AS $$
+    SELECT current_setting('app.tenant_id', true)
+$$ LANGUAGE sql STABLE;
+
+-- Row-level security backs up the tenant_id conditions of the queries: a
+-- connection only ever sees and writes the rows of its tenant. FORCE applies
+-- the policies to the table owner as well; superusers and BYPASSRLS roles
+-- remain exempt.
+ALTER TABLE messages ENABLE ROW LEVEL SECURITY;
+ALTER TABLE messages FORCE ROW LEVEL SECURITY;
+CREATE POLICY messages_tenant_isolation ON messages
+    USING (tenant_id = current_tenant() OR current_tenant() = '*')
+    WITH CHECK (tenant_id = current_tenant() OR current_tenant() = '*');
+
+ALTER TABLE message_revisions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE message_revisions FORCE ROW LEVEL SECURITY;
+CREATE POLICY message_revisions_tenant_isolation ON message_revisions
+    USING (tenant_id = current_tenant() OR current_tenant() = '*')
+    WITH CHECK (tenant_id = current_tenant() OR current_tenant() = '*');
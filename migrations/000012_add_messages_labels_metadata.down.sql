@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS messages_metadata_idx;
+DROP INDEX IF EXISTS messages_labels_idx;
+ALTER TABLE messages DROP COLUMN IF EXISTS metadata;
+ALTER TABLE messages DROP COLUMN IF EXISTS labels;
@@ -0,0 +1,8 @@
+-- Labels tag messages for filtering; metadata holds arbitrary key/value
+-- attributes such as the source system or a correlation ID.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS labels TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
+
+-- Serve the label (labels @> ...) and metadata key (metadata ?& ...) filters
+CREATE INDEX IF NOT EXISTS messages_labels_idx ON messages USING GIN (labels);
+CREATE INDEX IF NOT EXISTS messages_metadata_idx ON messages USING GIN (metadata);
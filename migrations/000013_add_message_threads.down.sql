@@ -0,0 +1,16 @@
+DROP TRIGGER IF EXISTS update_messages_updated_at ON messages;
+CREATE TRIGGER update_messages_updated_at
+    BEFORE UPDATE ON messages
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+DROP TRIGGER IF EXISTS update_messages_reply_count ON messages;
+DROP FUNCTION IF EXISTS update_messages_reply_count();
+DROP TRIGGER IF EXISTS set_messages_thread_id ON messages;
+DROP FUNCTION IF EXISTS set_messages_thread_id();
+
+DROP INDEX IF EXISTS messages_thread_id_idx;
+DROP INDEX IF EXISTS messages_tenant_parent_id_created_at_idx;
+ALTER TABLE messages DROP COLUMN IF EXISTS reply_count;
+ALTER TABLE messages DROP COLUMN IF EXISTS thread_id;
+ALTER TABLE messages DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,57 @@
+-- A reply names the message it answers. Every message also records the root
+-- of its thread, its own ID for messages that start one, so that a whole
+-- thread is read with one index scan. parent_id is deliberately not a
+-- foreign key: purging a message leaves its replies in the thread.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS parent_id UUID;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS thread_id UUID;
+UPDATE messages SET thread_id = id WHERE thread_id IS NULL;
+ALTER TABLE messages ALTER COLUMN thread_id SET NOT NULL;
+
+-- reply_count is the number of live direct replies, kept up to date by
+-- update_messages_reply_count()
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS reply_count BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS messages_tenant_parent_id_created_at_idx ON messages (tenant_id, parent_id, created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS messages_thread_id_idx ON messages (thread_id);
+
+-- New messages without a thread start their own
+CREATE OR REPLACE FUNCTION set_messages_thread_id() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.thread_id := COALESCE(NEW.thread_id, NEW.id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER set_messages_thread_id
+    BEFORE INSERT ON messages
+    FOR EACH ROW
+    EXECUTE FUNCTION set_messages_thread_id();
+
+-- Count a reply for its parent while it is live: when it is created,
+-- deleted, restored or purged
+CREATE OR REPLACE FUNCTION update_messages_reply_count() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP <> 'DELETE' AND NEW.parent_id IS NOT NULL AND NEW.deleted_at IS NULL
+        AND (TG_OP = 'INSERT' OR OLD.deleted_at IS NOT NULL) THEN
+        UPDATE messages SET reply_count = reply_count + 1 WHERE id = NEW.parent_id;
+    END IF;
+    IF TG_OP <> 'INSERT' AND OLD.parent_id IS NOT NULL AND OLD.deleted_at IS NULL
+        AND (TG_OP = 'DELETE' OR NEW.deleted_at IS NOT NULL) THEN
+        UPDATE messages SET reply_count = reply_count - 1 WHERE id = OLD.parent_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER update_messages_reply_count
+    AFTER INSERT OR UPDATE OF deleted_at OR DELETE ON messages
+    FOR EACH ROW
+    EXECUTE FUNCTION update_messages_reply_count();
+
+-- Counting a reply does not modify its parent
+DROP TRIGGER IF EXISTS update_messages_updated_at ON messages;
+CREATE TRIGGER update_messages_updated_at
+    BEFORE UPDATE ON messages
+    FOR EACH ROW
+    WHEN (OLD.reply_count IS NOT DISTINCT FROM NEW.reply_count)
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,6 @@
+-- Ephemeral messages carry the time after which they are hidden from reads
+-- and listings; the expiry sweeper then moves them to the trash.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE;
+
+-- Serve the sweeper, which only looks at live messages that expire
+CREATE INDEX IF NOT EXISTS messages_expires_at_idx ON messages (expires_at) WHERE deleted_at IS NULL AND expires_at IS NOT NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS messages_publish_at_idx;
+ALTER TABLE messages DROP COLUMN IF EXISTS publish_at;
+ALTER TABLE messages DROP COLUMN IF EXISTS status;
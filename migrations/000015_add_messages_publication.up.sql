@@ -0,0 +1,11 @@
+-- A message is written as a draft, scheduled for publish_at or published
+-- straight away. Only published messages are shown to readers other than
+-- their author; the scheduler publishes scheduled messages once they are
+-- due. Existing messages were published when they were created.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'published'
+    CHECK (status IN ('draft', 'scheduled', 'published'));
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS publish_at TIMESTAMP WITH TIME ZONE;
+UPDATE messages SET publish_at = created_at WHERE publish_at IS NULL;
+
+-- Serve the scheduler, which only looks at live scheduled messages
+CREATE INDEX IF NOT EXISTS messages_publish_at_idx ON messages (publish_at) WHERE status = 'scheduled' AND deleted_at IS NULL;
@@ -0,0 +1,24 @@
+-- Files attached to messages. The contents live in the blob store under
+-- storage_key; this table holds what is known about them. message_id is not
+-- a foreign key: attachments are purged along with their message by the
+-- application, which also has to delete the blobs.
+CREATE TABLE attachments (
+    id UUID PRIMARY KEY,
+    message_id UUID NOT NULL,
+    tenant_id TEXT NOT NULL,
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    checksum TEXT NOT NULL,
+    storage_key TEXT NOT NULL,
+    uploader_id TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS attachments_tenant_message_id_idx ON attachments (tenant_id, message_id, created_at, id);
+
+ALTER TABLE attachments ENABLE ROW LEVEL SECURITY;
+ALTER TABLE attachments FORCE ROW LEVEL SECURITY;
+CREATE POLICY attachments_tenant_isolation ON attachments
+    USING (tenant_id = current_tenant() OR current_tenant() = '*')
+    WITH CHECK (tenant_id = current_tenant());